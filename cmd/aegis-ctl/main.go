@@ -1,237 +1,899 @@
-package main
-
-import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"io"
-	"log"
-	"net/http"
-	"os"
-	"strings"
-
-	"gopkg.in/yaml.v3"
-)
-
-// ANSI Colors for Pretty CLI
-const (
-	Reset  = "\033[0m"
-	Red    = "\033[31m"
-	Green  = "\033[32m"
-	Yellow = "\033[33m"
-	Blue   = "\033[34m"
-	Cyan   = "\033[36m"
-)
-
-type AppConfig struct {
-	Name          string `yaml:"name" json:"name"`
-	Version       string `yaml:"version" json:"version"`
-	Image         string `yaml:"image" json:"image"`
-	Replicas      int    `yaml:"replicas" json:"replicas"`
-	SecurityLevel string `yaml:"security_level" json:"security_level"`
-	Resources     struct {
-		CPU    float64 `yaml:"cpu" json:"cpu"`
-		Memory int64   `yaml:"memory" json:"memory"`
-	} `yaml:"resources" json:"resources"`
-}
-
-type ServiceStatus struct {
-	Name   string `json:"name"`
-	Image  string `json:"image"`
-	Status string `json:"status"`
-}
-
-type AlertDetection struct {
-	ID        int    `json:"id"`
-	Command   string `json:"command"`
-	Risk      string `json:"risk"`
-	Source    string `json:"source"`
-	Timestamp string `json:"timestamp"`
-}
-
-func main() {
-	if len(os.Args) < 2 {
-		showHelp()
-		return
-	}
-
-	command := os.Args[1]
-
-	switch command {
-	case "status":
-		fetchStatus()
-	case "alerts":
-		fetchAlerts()
-	case "delete":
-		if len(os.Args) < 3 {
-			fmt.Printf("%s[ERROR] Service name is required. Usage: aegis-ctl delete <service_name>%s\n", Red, Reset)
-			return
-		}
-		deleteService(os.Args[2])
-	case "help":
-		showHelp()
-	default:
-		deploy(command)
-	}
-}
-
-func deploy(filename string) {
-	yamlFile, err := os.ReadFile(filename)
-	if err != nil {
-		log.Fatalf("%s[ERROR] Could not read file %s: %v%s", Red, filename, err, Reset)
-	}
-
-	var config AppConfig
-	if err := yaml.Unmarshal(yamlFile, &config); err != nil {
-		log.Fatalf("%s[ERROR] Invalid YAML: %v%s", Red, err, Reset)
-	}
-
-	if config.Resources.CPU == 0 { config.Resources.CPU = 0.5 }
-	if config.Resources.Memory == 0 { config.Resources.Memory = 128 }
-
-	fmt.Printf("%s[INFO] AEGIS-V Pipeline: Initializing %s (v%s)%s\n", Cyan, config.Name, config.Version, Reset)
-
-	if containsLatest(config.Image) {
-		fmt.Printf("%s[ERROR] Policy Violation: 'latest' tags are not allowed.%s\n", Red, Reset)
-		os.Exit(1)
-	}
-
-	jsonData, _ := json.Marshal(config)
-	resp, err := http.Post("http://localhost:8080/deploy", "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		log.Fatalf("%s[ERROR] Network failure: %v%s", Red, err, Reset)
-	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode == http.StatusOK {
-		fmt.Printf("%s[SUCCESS] %s%s\n", Green, string(body), Reset)
-	} else {
-		fmt.Printf("%s[REJECTED] %s (HTTP %d)%s\n", Red, string(body), resp.StatusCode, Reset)
-	}
-}
-func fetchStatus() {
-    // 1. Fetch Cluster/Service Health
-    fmt.Printf("%s[INFO] Fetching cluster health...%s\n", Cyan, Reset)
-    resp, err := http.Get("http://localhost:8080/status")
-    if err != nil {
-        fmt.Println(Red + "[ERROR] Control Plane unreachable. Make sure AEGIS-Engine is running." + Reset)
-        return
-    }
-    defer resp.Body.Close()
-
-    var statuses []ServiceStatus
-    json.NewDecoder(resp.Body).Decode(&statuses)
-
-    fmt.Println("\n" + Blue + strings.Repeat("=", 75) + Reset)
-    fmt.Printf("%-25s %-30s %-20s\n", "SERVICE NAME", "DOCKER IMAGE", "HEALTH STATUS")
-    fmt.Println(strings.Repeat("-", 75))
-    for _, s := range statuses {
-        statusColor := Green
-        if strings.Contains(s.Status, "RECOVERING") || strings.Contains(s.Status, "🚨") {
-            statusColor = Yellow
-        }
-        fmt.Printf("%-25s %-30s %s%-20s%s\n", s.Name, s.Image, statusColor, s.Status, Reset)
-    }
-    fmt.Println(Blue + strings.Repeat("=", 75) + Reset)
-
-    // 2. Fetch Security Incidents from API (No direct DB access)
-    fmt.Println("\n🛡️  RECENT SECURITY INCIDENTS (NEUTRALIZED)")
-    fmt.Println("---------------------------------------------------------------------------")
-    fmt.Printf("%-20s | %-10s | %-20s | %-15s\n", "TIMESTAMP", "CMD", "SOURCE", "ACTION")
-    fmt.Println("---------------------------------------------------------------------------")
-
-    // Engine API endpoint for logs
-    logResp, err := http.Get("http://localhost:8080/api/logs")
-    if err != nil {
-        fmt.Println("   [!] Could not fetch security logs from Engine.")
-    } else {
-        defer logResp.Body.Close()
-        var logs []struct {
-            Timestamp string `json:"timestamp"`
-            Command   string `json:"command"`
-            Source    string `json:"source"`
-            Risk      string `json:"risk"`
-        }
-        
-        if err := json.NewDecoder(logResp.Body).Decode(&logs); err != nil {
-            fmt.Println("   [!] No security logs recorded yet.")
-        } else {
-            // Sirf top 5 logs dikhao
-            for i, log := range logs {
-                if i >= 5 { break }
-                
-                action := "DETECTED 🚩"
-                if strings.Contains(log.Risk, "SENSITIVE") {
-                    action = "NEUTRALIZED 🛡️"
-                }
-                
-                // Agar source khali hai toh display clean rakho
-                sourceDisplay := log.Source
-                if sourceDisplay == "" || strings.Contains(sourceDisplay, "HOST") {
-                    sourceDisplay = "payment-service" // Defaulting for clarity
-                }
-
-                fmt.Printf("%-20s | %-10s | %-20s | %-15s\n", log.Timestamp, log.Command, sourceDisplay, action)
-            }
-        }
-    }
-    fmt.Println("---------------------------------------------------------------------------")
-}
-
-func fetchAlerts() {
-	fmt.Printf("%s[INFO] Fetching Security Alerts from AEGIS Database...%s\n", Cyan, Reset)
-	resp, err := http.Get("http://localhost:8080/alerts")
-	if err != nil {
-		log.Fatal(Red + "[ERROR] Control Plane unreachable." + Reset)
-	}
-	defer resp.Body.Close()
-
-	var alerts []AlertDetection
-	json.NewDecoder(resp.Body).Decode(&alerts)
-
-	fmt.Println("\n" + Red + strings.Repeat("!", 75) + Reset)
-	fmt.Printf("%-5s %-15s %-20s %-20s\n", "ID", "COMMAND", "RISK LEVEL", "SOURCE")
-	fmt.Println(strings.Repeat("-", 75))
-	
-	if len(alerts) == 0 {
-		fmt.Println("No unauthorized activities detected.")
-	}
-	
-	for _, a := range alerts {
-		riskColor := Yellow
-		if strings.Contains(a.Risk, "HIGH") || strings.Contains(a.Risk, "CRITICAL") {
-			riskColor = Red
-		}
-		fmt.Printf("%-5d %-15s %s%-20s%s %-20s\n", a.ID, a.Command, riskColor, a.Risk, Reset, a.Source)
-	}
-	fmt.Println(Red + strings.Repeat("!", 75) + Reset + "\n")
-}
-
-func deleteService(name string) {
-	client := &http.Client{}
-	url := fmt.Sprintf("http://localhost:8080/delete?name=%s", name)
-	req, _ := http.NewRequest(http.MethodDelete, url, nil)
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer resp.Body.Close()
-	body, _ := io.ReadAll(resp.Body)
-	fmt.Printf("%s[RESULT] %s%s\n", Yellow, string(body), Reset)
-}
-
-func containsLatest(image string) bool {
-	return !strings.Contains(image, ":") || strings.HasSuffix(image, ":latest")
-}
-
-func showHelp() {
-	fmt.Println("\n" + Cyan + "AEGIS-V COMMAND LINE INTERFACE v1.0.0" + Reset)
-	fmt.Println(strings.Repeat("-", 40))
-	fmt.Printf("%sUsage:%s\n", Yellow, Reset)
-	fmt.Println("  aegis-ctl <path-to-yaml>    Deploy a new service")
-	fmt.Println("  aegis-ctl status            Check service health")
-	fmt.Println("  aegis-ctl alerts            View security detections")
-	fmt.Println("  aegis-ctl delete <name>     Remove a service")
-	fmt.Println(strings.Repeat("-", 40))
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/rules"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ANSI Colors for Pretty CLI
+const (
+	Reset  = "\033[0m"
+	Red    = "\033[31m"
+	Green  = "\033[32m"
+	Yellow = "\033[33m"
+	Blue   = "\033[34m"
+	Cyan   = "\033[36m"
+)
+
+type AppConfig struct {
+	Name          string `yaml:"name" json:"name"`
+	Version       string `yaml:"version" json:"version"`
+	Image         string `yaml:"image" json:"image"`
+	Replicas      int    `yaml:"replicas" json:"replicas"`
+	SecurityLevel string `yaml:"security_level" json:"security_level"`
+	Resources     struct {
+		CPU    float64 `yaml:"cpu" json:"cpu"`
+		Memory int64   `yaml:"memory" json:"memory"`
+	} `yaml:"resources" json:"resources"`
+	Egress   []EgressRule `yaml:"egress,omitempty" json:"egress,omitempty"`
+	DenyExec []string     `yaml:"deny_exec,omitempty" json:"deny_exec,omitempty"`
+	Learn    string       `yaml:"learn,omitempty" json:"learn,omitempty"` // behaviour learning window, e.g. 2h
+	// Spawn names the main processes that may start programs: [] none, omitted the default profile
+	Spawn *[]string `yaml:"spawn,omitempty" json:"spawn,omitempty"`
+}
+
+// EgressRule is one allowed outbound destination (omit the section to disable egress policy)
+type EgressRule struct {
+	CIDR     string `yaml:"cidr" json:"cidr"`
+	Ports    []int  `yaml:"ports,omitempty" json:"ports,omitempty"`
+	Protocol string `yaml:"protocol,omitempty" json:"protocol,omitempty"`
+}
+
+// Bundle is a multi-service manifest (see cluster.yaml)
+type Bundle struct {
+	Services []AppConfig `yaml:"services" json:"services"`
+}
+
+type Finding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Detail   string `json:"detail"`
+	Action   string `json:"action"`
+}
+
+type ValidationReport struct {
+	Allowed  bool `json:"allowed"`
+	Services []struct {
+		Name     string    `json:"name"`
+		Image    string    `json:"image"`
+		Allowed  bool      `json:"allowed"`
+		Findings []Finding `json:"findings"`
+		Notes    []string  `json:"notes"`
+	} `json:"services"`
+}
+
+type PolicyException struct {
+	ID        int64     `json:"id"`
+	Service   string    `json:"service"`
+	Rule      string    `json:"rule"`
+	Owner     string    `json:"owner"`
+	Reason    string    `json:"reason"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AdmissionRecord struct {
+	ID            int64     `json:"id"`
+	Timestamp     time.Time `json:"timestamp"`
+	Service       string    `json:"service"`
+	Image         string    `json:"image"`
+	ImageDigest   string    `json:"image_digest"`
+	SpecHash      string    `json:"spec_hash"`
+	Decision      string    `json:"decision"`
+	Phase         string    `json:"phase"`
+	Reason        string    `json:"reason"`
+	PolicyVersion string    `json:"policy_version"`
+	Exceptions    []int64   `json:"exceptions"`
+	Client        string    `json:"client"`
+	Rules         []struct {
+		Rule     string    `json:"rule"`
+		Result   string    `json:"result"`
+		Findings []Finding `json:"findings"`
+	} `json:"rules"`
+}
+
+type ServiceStatus struct {
+	Name   string `json:"name"`
+	Image  string `json:"image"`
+	Status string `json:"status"`
+}
+
+type AlertDetection struct {
+	ID            int               `json:"id"`
+	Command       string            `json:"command"`
+	Risk          string            `json:"risk"`
+	Source        string            `json:"source"`
+	Timestamp     string            `json:"timestamp"`
+	Filename      string            `json:"filename"`
+	Argv          []string          `json:"argv"`
+	ArgvTruncated bool              `json:"argv_truncated"`
+	Cwd           string            `json:"cwd"`
+	DetectionType string            `json:"detection_type"`
+	DestIP        string            `json:"dest_ip"`
+	DestPort      int               `json:"dest_port"`
+	Protocol      string            `json:"protocol"`
+	OpenFlags     int               `json:"open_flags"`
+	OldUid        int               `json:"old_uid"`
+	NewUid        int               `json:"new_uid"`
+	OldEuid       int               `json:"old_euid"`
+	NewEuid       int               `json:"new_euid"`
+	CapsGained    []string          `json:"caps_gained"`
+	Response      string            `json:"response"`
+	Ancestry      []AncestorProcess `json:"ancestry"`
+	DropsInWindow bool              `json:"drops_in_window"`
+	RuleID        string            `json:"rule_id"`
+	Mitre         string            `json:"mitre"`
+	Deviation     string            `json:"deviation"`
+	SHA256        string            `json:"sha256"`
+	Fileless      string            `json:"fileless"`
+	Sample        string            `json:"sample"`
+	AIVerdict     string            `json:"ai_verdict"`
+	Context       DetectionContext  `json:"context"`
+}
+
+// DetectionContext is the deployment a detection happened in
+type DetectionContext struct {
+	Service       string `json:"service"`
+	ContainerID   string `json:"container_id"`
+	Image         string `json:"image"`
+	ImageDigest   string `json:"image_digest"`
+	Revision      int    `json:"revision"`
+	Version       string `json:"version"`
+	SecurityLevel string `json:"security_level"`
+	RulesVersion  string `json:"rules_version"`
+	Host          string `json:"host"`
+	Backend       string `json:"backend"`
+}
+
+// String renders the context on one line, leaving out what is unknown
+func (c DetectionContext) String() string {
+	var parts []string
+	if c.Service != "" {
+		parts = append(parts, c.Service)
+	}
+	if c.Revision > 0 {
+		rev := fmt.Sprintf("rev %d", c.Revision)
+		if c.Version != "" {
+			rev += " (v" + c.Version + ")"
+		}
+		parts = append(parts, rev)
+	}
+	if c.ContainerID != "" {
+		parts = append(parts, shortID(c.ContainerID))
+	}
+	if c.Image != "" {
+		parts = append(parts, c.Image)
+	}
+	if c.ImageDigest != "" && c.ImageDigest != c.Image {
+		parts = append(parts, c.ImageDigest)
+	}
+	if c.SecurityLevel != "" {
+		parts = append(parts, "level "+c.SecurityLevel)
+	}
+	if c.Host != "" {
+		parts = append(parts, "host "+c.Host)
+	}
+	if c.RulesVersion != "" {
+		parts = append(parts, c.RulesVersion)
+	}
+	return strings.Join(parts, " · ")
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// AncestorProcess is one link of a detection's process lineage
+type AncestorProcess struct {
+	Pid       int    `json:"pid"`
+	Comm      string `json:"comm"`
+	Exe       string `json:"exe"`
+	Container string `json:"container"`
+	Exited    bool   `json:"exited"`
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		showHelp()
+		return
+	}
+
+	command := os.Args[1]
+
+	switch command {
+	case "status":
+		fetchStatus()
+	case "alerts":
+		fetchAlerts(os.Args[2:])
+	case "delete":
+		if len(os.Args) < 3 {
+			fmt.Printf("%s[ERROR] Service name is required. Usage: aegis-ctl delete <service_name>%s\n", Red, Reset)
+			return
+		}
+		deleteService(os.Args[2])
+	case "validate":
+		if len(os.Args) < 3 {
+			fmt.Printf("%s[ERROR] Manifest is required. Usage: aegis-ctl validate <file>%s\n", Red, Reset)
+			os.Exit(1)
+		}
+		validate(os.Args[2])
+	case "policy":
+		policyCommand(os.Args[2:])
+	case "admissions":
+		admissionsCommand(os.Args[2:])
+	case "rules":
+		rulesCommand(os.Args[2:])
+	case "profile":
+		profileCommand(os.Args[2:])
+	case "monitor":
+		monitorCommand(os.Args[2:])
+	case "help":
+		showHelp()
+	default:
+		deploy(command)
+	}
+}
+
+func deploy(filename string) {
+	yamlFile, err := os.ReadFile(filename)
+	if err != nil {
+		log.Fatalf("%s[ERROR] Could not read file %s: %v%s", Red, filename, err, Reset)
+	}
+
+	var config AppConfig
+	if err := yaml.Unmarshal(yamlFile, &config); err != nil {
+		log.Fatalf("%s[ERROR] Invalid YAML: %v%s", Red, err, Reset)
+	}
+
+	if config.Resources.CPU == 0 { config.Resources.CPU = 0.5 }
+	if config.Resources.Memory == 0 { config.Resources.Memory = 128 }
+
+	fmt.Printf("%s[INFO] AEGIS-V Pipeline: Initializing %s (v%s)%s\n", Cyan, config.Name, config.Version, Reset)
+
+	resp, err := postJSON("http://localhost:8080/deploy", config)
+	if err != nil {
+		log.Fatalf("%s[ERROR] Network failure: %v%s", Red, err, Reset)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusOK {
+		fmt.Printf("%s[SUCCESS] %s%s\n", Green, string(body), Reset)
+	} else {
+		fmt.Printf("%s[REJECTED] %s (HTTP %d)%s\n", Red, string(body), resp.StatusCode, Reset)
+	}
+}
+func fetchStatus() {
+    // 1. Fetch Cluster/Service Health
+    fmt.Printf("%s[INFO] Fetching cluster health...%s\n", Cyan, Reset)
+    resp, err := http.Get("http://localhost:8080/status")
+    if err != nil {
+        fmt.Println(Red + "[ERROR] Control Plane unreachable. Make sure AEGIS-Engine is running." + Reset)
+        return
+    }
+    defer resp.Body.Close()
+
+    var statuses []ServiceStatus
+    json.NewDecoder(resp.Body).Decode(&statuses)
+
+    fmt.Println("\n" + Blue + strings.Repeat("=", 75) + Reset)
+    fmt.Printf("%-25s %-30s %-20s\n", "SERVICE NAME", "DOCKER IMAGE", "HEALTH STATUS")
+    fmt.Println(strings.Repeat("-", 75))
+    for _, s := range statuses {
+        statusColor := Green
+        if strings.Contains(s.Status, "RECOVERING") || strings.Contains(s.Status, "🚨") {
+            statusColor = Yellow
+        }
+        fmt.Printf("%-25s %-30s %s%-20s%s\n", s.Name, s.Image, statusColor, s.Status, Reset)
+    }
+    fmt.Println(Blue + strings.Repeat("=", 75) + Reset)
+
+    // 2. Fetch Security Incidents from API (No direct DB access)
+    fmt.Println("\n🛡️  RECENT SECURITY INCIDENTS (NEUTRALIZED)")
+    fmt.Println("---------------------------------------------------------------------------")
+    fmt.Printf("%-20s | %-10s | %-20s | %-15s\n", "TIMESTAMP", "CMD", "SOURCE", "ACTION")
+    fmt.Println("---------------------------------------------------------------------------")
+
+    // Engine API endpoint for logs
+    logResp, err := http.Get("http://localhost:8080/api/logs")
+    if err != nil {
+        fmt.Println("   [!] Could not fetch security logs from Engine.")
+    } else {
+        defer logResp.Body.Close()
+        var logs []struct {
+            Timestamp string `json:"timestamp"`
+            Command   string `json:"command"`
+            Source    string `json:"source"`
+            Risk      string `json:"risk"`
+        }
+        
+        if err := json.NewDecoder(logResp.Body).Decode(&logs); err != nil {
+            fmt.Println("   [!] No security logs recorded yet.")
+        } else {
+            // Sirf top 5 logs dikhao
+            for i, log := range logs {
+                if i >= 5 { break }
+                
+                action := "DETECTED 🚩"
+                if strings.Contains(log.Risk, "SENSITIVE") {
+                    action = "NEUTRALIZED 🛡️"
+                }
+                
+                // Agar source khali hai toh display clean rakho
+                sourceDisplay := log.Source
+                if sourceDisplay == "" || strings.Contains(sourceDisplay, "HOST") {
+                    sourceDisplay = "payment-service" // Defaulting for clarity
+                }
+
+                fmt.Printf("%-20s | %-10s | %-20s | %-15s\n", log.Timestamp, log.Command, sourceDisplay, action)
+            }
+        }
+    }
+    fmt.Println("---------------------------------------------------------------------------")
+}
+
+// fetchAlerts: aegis-ctl alerts [--service s] [--container id] [--image ref] [--revision n] [--level high] [--rule id] [--host h] [--type DRIFT] [--since 24h] [--limit n]
+func fetchAlerts(args []string) {
+	fs := flag.NewFlagSet("alerts", flag.ExitOnError)
+	service := fs.String("service", "", "only this service")
+	container := fs.String("container", "", "only this container (ID or prefix)")
+	image := fs.String("image", "", "only this image reference or digest")
+	revision := fs.Int("revision", 0, "only this deployment revision")
+	level := fs.String("level", "", "only services at this security_level")
+	rule := fs.String("rule", "", "only detections of this rule")
+	host := fs.String("host", "", "only detections on this host")
+	detType := fs.String("type", "", "only this detection type, e.g. DRIFT")
+	since := fs.String("since", "", "look-back window, e.g. 24h")
+	limit := fs.Int("limit", 50, "maximum detections")
+	fs.Parse(args)
+
+	q := url.Values{}
+	for k, v := range map[string]string{"service": *service, "container": *container, "image": *image, "level": *level,
+		"rule": *rule, "host": *host, "type": *detType, "since": *since} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	if *revision > 0 {
+		q.Set("revision", strconv.Itoa(*revision))
+	}
+	q.Set("limit", strconv.Itoa(*limit))
+
+	fmt.Printf("%s[INFO] Fetching Security Alerts from AEGIS Database...%s\n", Cyan, Reset)
+	resp, err := http.Get("http://localhost:8080/alerts?" + q.Encode())
+	if err != nil {
+		log.Fatal(Red + "[ERROR] Control Plane unreachable." + Reset)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("%s[ERROR] %s%s\n", Red, strings.TrimSpace(string(body)), Reset)
+		os.Exit(1)
+	}
+
+	var alerts []AlertDetection
+	json.NewDecoder(resp.Body).Decode(&alerts)
+
+	fmt.Println("\n" + Red + strings.Repeat("!", 75) + Reset)
+	fmt.Printf("%-5s %-15s %-20s %-20s\n", "ID", "COMMAND", "RISK LEVEL", "SOURCE")
+	fmt.Println(strings.Repeat("-", 75))
+	
+	if len(alerts) == 0 {
+		fmt.Println("No unauthorized activities detected.")
+	}
+	
+	for _, a := range alerts {
+		riskColor := Yellow
+		if strings.Contains(a.Risk, "HIGH") || strings.Contains(a.Risk, "CRITICAL") {
+			riskColor = Red
+		}
+		fmt.Printf("%-5d %-15s %s%-20s%s %-20s\n", a.ID, a.Command, riskColor, a.Risk, Reset, a.Source)
+		if ctx := a.Context.String(); ctx != "" {
+			fmt.Printf("      └─ %s\n", ctx)
+		}
+		if a.RuleID != "" {
+			ref := a.RuleID
+			if a.Mitre != "" {
+				ref += " · " + a.Mitre
+			}
+			fmt.Printf("      └─ rule: [%s]\n", ref)
+		}
+		if a.AIVerdict != "" {
+			fmt.Printf("      └─ ai: %s\n", a.AIVerdict)
+		}
+		if a.DetectionType == "EGRESS" {
+			fmt.Printf("      └─ egress → %s:%d/%s\n", a.DestIP, a.DestPort, a.Protocol)
+		} else if a.DetectionType == "FILE_ACCESS" {
+			fmt.Printf("      └─ open %s (flags 0x%x)\n", a.Filename, a.OpenFlags)
+		} else if a.DetectionType == "DEVIATION" {
+			fmt.Printf("      └─ not in learned profile: %s\n", a.Deviation)
+		} else if a.DetectionType == "DRIFT" {
+			fmt.Printf("      └─ drifted exe %s (sha256 %s)\n", a.Filename, a.SHA256)
+		} else if a.DetectionType == "REVERSE_SHELL" {
+			fmt.Printf("      └─ reverse shell: stdio on %s %s:%d [%s]\n", a.Protocol, a.DestIP, a.DestPort, a.Response)
+		} else if a.DetectionType == "FILELESS" {
+			fmt.Printf("      └─ fileless exe %s [%s] (sha256 %s)\n", a.Filename, a.Fileless, a.SHA256)
+			if a.Sample != "" {
+				fmt.Printf("      └─ sample kept: %s\n", a.Sample)
+			}
+		} else if a.DetectionType == "PRIV_ESCALATION" {
+			caps := ""
+			if len(a.CapsGained) > 0 {
+				caps = " +" + strings.Join(a.CapsGained, " +")
+			}
+			fmt.Printf("      └─ uid %d→%d euid %d→%d%s [%s]\n", a.OldUid, a.NewUid, a.OldEuid, a.NewEuid, caps, a.Response)
+		} else if len(a.Argv) > 0 {
+			args := strings.Join(a.Argv, " ")
+			if a.ArgvTruncated {
+				args += " ..."
+			}
+			if a.Response != "" {
+				args += " [" + a.Response + "]"
+			}
+			fmt.Printf("      └─ %s (cwd: %s)\n", args, a.Cwd)
+		} else if a.Response != "" {
+			fmt.Printf("      └─ exec %s [%s]\n", a.Filename, a.Response)
+		}
+		if len(a.Ancestry) > 0 {
+			chain := make([]string, 0, len(a.Ancestry))
+			for _, p := range a.Ancestry {
+				link := fmt.Sprintf("%s(%d)", p.Comm, p.Pid)
+				if p.Exited {
+					link += "†"
+				}
+				chain = append(chain, link)
+			}
+			fmt.Printf("      └─ lineage: %s\n", strings.Join(chain, " ← "))
+		}
+		if a.Context.Backend != "" && a.Context.Backend != "ebpf" {
+			fmt.Printf("      %s└─ ⚠ seen by the %s fallback (exec only, no kernel context); fields may be incomplete%s\n", Yellow, a.Context.Backend, Reset)
+		}
+		if a.DropsInWindow {
+			fmt.Printf("      %s└─ ⚠ kernel dropped events around this detection; context may be incomplete%s\n", Yellow, Reset)
+		}
+	}
+	fmt.Println(Red + strings.Repeat("!", 75) + Reset + "\n")
+}
+
+func deleteService(name string) {
+	client := &http.Client{}
+	url := fmt.Sprintf("http://localhost:8080/delete?name=%s", name)
+	req, _ := http.NewRequest(http.MethodDelete, url, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	fmt.Printf("%s[RESULT] %s%s\n", Yellow, string(body), Reset)
+}
+
+// validate runs the full admission dry-run on a spec or bundle; exits 1 on any violation (CI friendly)
+func validate(filename string) {
+	yamlFile, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Printf("%s[ERROR] Could not read file %s: %v%s\n", Red, filename, err, Reset)
+		os.Exit(1)
+	}
+
+	var payload interface{}
+	var bundle Bundle
+	if err := yaml.Unmarshal(yamlFile, &bundle); err == nil && len(bundle.Services) > 0 {
+		payload = bundle
+	} else {
+		var config AppConfig
+		if err := yaml.Unmarshal(yamlFile, &config); err != nil {
+			fmt.Printf("%s[ERROR] Invalid YAML: %v%s\n", Red, err, Reset)
+			os.Exit(1)
+		}
+		payload = config
+	}
+
+	resp, err := postJSON("http://localhost:8080/validate", payload)
+	if err != nil {
+		fmt.Printf("%s[ERROR] Network failure: %v%s\n", Red, err, Reset)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("%s[ERROR] %s (HTTP %d)%s\n", Red, strings.TrimSpace(string(body)), resp.StatusCode, Reset)
+		os.Exit(1)
+	}
+
+	var report ValidationReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		fmt.Printf("%s[ERROR] Unreadable validation report: %v%s\n", Red, err, Reset)
+		os.Exit(1)
+	}
+
+	fmt.Println("\n" + Blue + strings.Repeat("=", 75) + Reset)
+	for _, svc := range report.Services {
+		verdict := Green + "PASS" + Reset
+		if !svc.Allowed {
+			verdict = Red + "FAIL" + Reset
+		}
+		fmt.Printf("[%s] %s (%s)\n", verdict, svc.Name, svc.Image)
+		for _, f := range svc.Findings {
+			color := Yellow
+			if f.Action == "BLOCK" {
+				color = Red
+			}
+			fmt.Printf("   %s%-5s%s %-8s %-28s %s\n", color, f.Action, Reset, f.Severity, f.Rule, f.Detail)
+		}
+		for _, note := range svc.Notes {
+			fmt.Printf("   %sNOTE%s  %s\n", Cyan, Reset, note)
+		}
+	}
+	fmt.Println(Blue + strings.Repeat("=", 75) + Reset)
+
+	if !report.Allowed {
+		fmt.Printf("%s[REJECTED] Manifest violates admission policy.%s\n", Red, Reset)
+		os.Exit(1)
+	}
+	fmt.Printf("%s[SUCCESS] Manifest passes all admission checks.%s\n", Green, Reset)
+}
+
+// policyCommand: aegis-ctl policy except|list|revoke
+func policyCommand(args []string) {
+	if len(args) == 0 {
+		fmt.Printf("%s[ERROR] Usage: aegis-ctl policy except|list|revoke ...%s\n", Red, Reset)
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "except":
+		if len(args) < 3 {
+			fmt.Printf("%s[ERROR] Usage: aegis-ctl policy except <service> <rule> --owner <name> --reason <text> --expires <72h|30d|YYYY-MM-DD>%s\n", Red, Reset)
+			os.Exit(1)
+		}
+		fs := flag.NewFlagSet("except", flag.ExitOnError)
+		owner := fs.String("owner", os.Getenv("USER"), "who is accountable for the exception")
+		reason := fs.String("reason", "", "justification (required)")
+		expires := fs.String("expires", "7d", "duration (72h, 30d) or date (YYYY-MM-DD)")
+		fs.Parse(args[3:])
+
+		expiresAt, err := parseExpiry(*expires)
+		if err != nil {
+			fmt.Printf("%s[ERROR] %v%s\n", Red, err, Reset)
+			os.Exit(1)
+		}
+		createException(PolicyException{Service: args[1], Rule: args[2], Owner: *owner, Reason: *reason, ExpiresAt: expiresAt})
+	case "list":
+		listExceptions(len(args) > 1 && args[1] == "--all")
+	case "revoke":
+		if len(args) < 2 {
+			fmt.Printf("%s[ERROR] Usage: aegis-ctl policy revoke <id>%s\n", Red, Reset)
+			os.Exit(1)
+		}
+		revokeException(args[1])
+	default:
+		fmt.Printf("%s[ERROR] Unknown policy command '%s'%s\n", Red, args[0], Reset)
+		os.Exit(1)
+	}
+}
+
+// parseExpiry accepts Go durations, a "d" day suffix, or an absolute date
+func parseExpiry(v string) (time.Time, error) {
+	if strings.HasSuffix(v, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(v, "d")); err == nil {
+			return time.Now().Add(time.Duration(days) * 24 * time.Hour), nil
+		}
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return time.Now().Add(d), nil
+	}
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid expiry '%s' (use 72h, 30d or YYYY-MM-DD)", v)
+}
+
+func createException(e PolicyException) {
+	resp, err := postJSON("http://localhost:8080/policy/exceptions", e)
+	if err != nil {
+		fmt.Printf("%s[ERROR] Network failure: %v%s\n", Red, err, Reset)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("%s[REJECTED] %s (HTTP %d)%s\n", Red, strings.TrimSpace(string(body)), resp.StatusCode, Reset)
+		os.Exit(1)
+	}
+
+	var created PolicyException
+	json.NewDecoder(resp.Body).Decode(&created)
+	fmt.Printf("%s[SUCCESS] Exception #%d: %s may bypass %s until %s%s\n", Green, created.ID, created.Service, created.Rule,
+		created.ExpiresAt.Local().Format("2006-01-02 15:04"), Reset)
+}
+
+func listExceptions(all bool) {
+	url := "http://localhost:8080/policy/exceptions"
+	if all {
+		url += "?all=1"
+	}
+	resp, err := http.Get(url)
+	if err != nil {
+		log.Fatal(Red + "[ERROR] Control Plane unreachable." + Reset)
+	}
+	defer resp.Body.Close()
+
+	var exceptions []PolicyException
+	json.NewDecoder(resp.Body).Decode(&exceptions)
+
+	fmt.Println("\n" + Blue + strings.Repeat("=", 100) + Reset)
+	fmt.Printf("%-5s %-20s %-28s %-12s %-18s %s\n", "ID", "SERVICE", "RULE", "OWNER", "EXPIRES", "REASON")
+	fmt.Println(strings.Repeat("-", 100))
+	if len(exceptions) == 0 {
+		fmt.Println("No policy exceptions.")
+	}
+	for _, e := range exceptions {
+		color := Reset
+		if time.Until(e.ExpiresAt) < 72*time.Hour {
+			color = Yellow
+		}
+		if time.Now().After(e.ExpiresAt) {
+			color = Red
+		}
+		fmt.Printf("%-5d %-20s %-28s %-12s %s%-18s%s %s\n", e.ID, e.Service, e.Rule, e.Owner,
+			color, e.ExpiresAt.Local().Format("2006-01-02 15:04"), Reset, e.Reason)
+	}
+	fmt.Println(Blue + strings.Repeat("=", 100) + Reset)
+}
+
+func revokeException(id string) {
+	req, _ := http.NewRequest(http.MethodDelete, "http://localhost:8080/policy/exceptions?id="+id, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	fmt.Printf("%s[RESULT] %s%s\n", Yellow, strings.TrimSpace(string(body)), Reset)
+}
+
+// postJSON sends a request identified as aegis-ctl so the engine can log who asked
+func postJSON(url string, payload interface{}) (*http.Response, error) {
+	jsonData, _ := json.Marshal(payload)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "aegis-ctl/1.0.0")
+	req.Header.Set("X-Aegis-Client", fmt.Sprintf("%s@%s", os.Getenv("USER"), host))
+	return http.DefaultClient.Do(req)
+}
+
+// admissionsCommand: aegis-ctl admissions [--service s] [--decision DENIED] [--rule r] [--since 24h] [--limit n] | show <id>
+func admissionsCommand(args []string) {
+	if len(args) > 0 && args[0] == "show" {
+		if len(args) < 2 {
+			fmt.Printf("%s[ERROR] Usage: aegis-ctl admissions show <id>%s\n", Red, Reset)
+			os.Exit(1)
+		}
+		showAdmission(args[1])
+		return
+	}
+
+	fs := flag.NewFlagSet("admissions", flag.ExitOnError)
+	service := fs.String("service", "", "only this service")
+	decision := fs.String("decision", "", "ALLOWED, DENIED or ERROR")
+	rule := fs.String("rule", "", "only decisions where this rule fired")
+	since := fs.String("since", "", "look-back window, e.g. 24h")
+	limit := fs.Int("limit", 50, "maximum records")
+	fs.Parse(args)
+
+	q := url.Values{}
+	for k, v := range map[string]string{"service": *service, "decision": *decision, "rule": *rule, "since": *since} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	q.Set("limit", strconv.Itoa(*limit))
+
+	resp, err := http.Get("http://localhost:8080/admissions?" + q.Encode())
+	if err != nil {
+		log.Fatal(Red + "[ERROR] Control Plane unreachable." + Reset)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("%s[ERROR] %s%s\n", Red, strings.TrimSpace(string(body)), Reset)
+		os.Exit(1)
+	}
+
+	var records []AdmissionRecord
+	json.NewDecoder(resp.Body).Decode(&records)
+
+	fmt.Println("\n" + Blue + strings.Repeat("=", 110) + Reset)
+	fmt.Printf("%-6s %-20s %-20s %-25s %-9s %-10s %-16s %s\n", "ID", "TIMESTAMP", "SERVICE", "IMAGE", "DECISION", "PHASE", "POLICY", "EXCEPTIONS")
+	fmt.Println(strings.Repeat("-", 110))
+	if len(records) == 0 {
+		fmt.Println("No admission decisions match.")
+	}
+	for _, a := range records {
+		color := Green
+		if a.Decision != "ALLOWED" {
+			color = Red
+		}
+		fmt.Printf("%-6d %-20s %-20s %-25s %s%-9s%s %-10s %-16s %v\n", a.ID, a.Timestamp.Local().Format("2006-01-02 15:04:05"),
+			a.Service, a.Image, color, a.Decision, Reset, a.Phase, a.PolicyVersion, a.Exceptions)
+	}
+	fmt.Println(Blue + strings.Repeat("=", 110) + Reset)
+}
+
+// showAdmission answers "why was this allowed?" for a single decision
+func showAdmission(id string) {
+	resp, err := http.Get("http://localhost:8080/admissions?id=" + url.QueryEscape(id))
+	if err != nil {
+		log.Fatal(Red + "[ERROR] Control Plane unreachable." + Reset)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("%s[ERROR] %s%s\n", Red, strings.TrimSpace(string(body)), Reset)
+		os.Exit(1)
+	}
+
+	var a AdmissionRecord
+	json.NewDecoder(resp.Body).Decode(&a)
+
+	fmt.Printf("\n%sAdmission #%d — %s%s\n", Cyan, a.ID, a.Decision, Reset)
+	fmt.Println(strings.Repeat("-", 75))
+	fmt.Printf("  Time:        %s\n", a.Timestamp.Local().Format(time.RFC1123))
+	fmt.Printf("  Service:     %s\n", a.Service)
+	fmt.Printf("  Image:       %s\n", a.Image)
+	fmt.Printf("  Digest:      %s\n", a.ImageDigest)
+	fmt.Printf("  Spec hash:   %s\n", a.SpecHash)
+	fmt.Printf("  Phase:       %s\n", a.Phase)
+	fmt.Printf("  Policy:      %s\n", a.PolicyVersion)
+	fmt.Printf("  Exceptions:  %v\n", a.Exceptions)
+	fmt.Printf("  Client:      %s\n", a.Client)
+	if a.Reason != "" {
+		fmt.Printf("  Reason:      %s\n", a.Reason)
+	}
+	fmt.Println(strings.Repeat("-", 75))
+	for _, r := range a.Rules {
+		color := Green
+		switch r.Result {
+		case "BLOCK":
+			color = Red
+		case "WARN", "EXCEPTED":
+			color = Yellow
+		}
+		fmt.Printf("  %s%-9s%s %s\n", color, r.Result, Reset, r.Rule)
+		for _, f := range r.Findings {
+			fmt.Printf("            └─ %s\n", f.Detail)
+		}
+	}
+}
+
+// RuleSample is one sample event of `aegis-ctl rules test`: the rule input plus the
+// rule id expected to decide it ("none" when no rule should match)
+type RuleSample struct {
+	Name        string `yaml:"name"`
+	Expect      string `yaml:"expect"`
+	rules.Input `yaml:",inline"`
+}
+
+func rulesCommand(args []string) {
+	if len(args) < 3 || args[0] != "test" {
+		fmt.Printf("%s[ERROR] Usage: aegis-ctl rules test <rules.yaml> <events.yaml> [--with-defaults]%s\n", Red, Reset)
+		os.Exit(1)
+	}
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	withDefaults := fs.Bool("with-defaults", false, "evaluate together with the built-in rules")
+	fs.Parse(args[3:])
+
+	data, err := os.ReadFile(args[1])
+	if err != nil {
+		log.Fatalf("%s[ERROR] %v%s", Red, err, Reset)
+	}
+	file, err := rules.Parse(data, args[1])
+	if err != nil {
+		fmt.Printf("%s[INVALID] %v%s\n", Red, err, Reset)
+		os.Exit(1)
+	}
+	set := rules.NewSet(file)
+	if *withDefaults {
+		builtin, err := rules.Builtin()
+		if err != nil {
+			log.Fatalf("%s[ERROR] %v%s", Red, err, Reset)
+		}
+		set = rules.NewSet(builtin, file)
+	}
+
+	data, err = os.ReadFile(args[2])
+	if err != nil {
+		log.Fatalf("%s[ERROR] %v%s", Red, err, Reset)
+	}
+	var samples []RuleSample
+	if err := yaml.Unmarshal(data, &samples); err != nil {
+		log.Fatalf("%s[ERROR] Invalid events file: %v%s", Red, err, Reset)
+	}
+
+	fmt.Printf("%s[INFO] %d rule(s) from %s against %d sample event(s)%s\n", Cyan, len(set.Rules), args[1], len(samples), Reset)
+	failed := 0
+	for i, ev := range samples {
+		if ev.Event == "" {
+			ev.Event = "exec"
+		}
+		name := ev.Name
+		if name == "" {
+			name = fmt.Sprintf("%s %s", ev.Event, ev.Comm)
+		}
+
+		got, verdict := "none", "no rule matched"
+		if r := set.Evaluate(&ev.Input); r != nil {
+			got, verdict = r.ID, string(r.Action)
+			if r.Action != rules.ActionIgnore {
+				verdict += " " + r.Severity
+				if r.Mitre != "" {
+					verdict += " " + r.Mitre
+				}
+			}
+		}
+
+		status := ""
+		if ev.Expect != "" {
+			status = Green + "PASS" + Reset
+			if ev.Expect != got {
+				status = fmt.Sprintf("%sFAIL%s (expected %s)", Red, Reset, ev.Expect)
+				failed++
+			}
+		}
+		fmt.Printf("%-3d %-30s → %-28s %-28s %s\n", i+1, name, got, verdict, status)
+	}
+
+	if failed > 0 {
+		fmt.Printf("%s[FAIL] %d of %d sample(s) did not match the expected rule%s\n", Red, failed, len(samples), Reset)
+		os.Exit(1)
+	}
+}
+
+func showHelp() {
+	fmt.Println("\n" + Cyan + "AEGIS-V COMMAND LINE INTERFACE v1.0.0" + Reset)
+	fmt.Println(strings.Repeat("-", 40))
+	fmt.Printf("%sUsage:%s\n", Yellow, Reset)
+	fmt.Println("  aegis-ctl <path-to-yaml>    Deploy a new service")
+	fmt.Println("  aegis-ctl validate <file>   Dry-run admission checks (exit 1 on violation)")
+	fmt.Println("  aegis-ctl status            Check service health")
+	fmt.Println("  aegis-ctl alerts            View security detections")
+	fmt.Println("  aegis-ctl alerts [--service s] [--container id] [--image ref] [--revision n] [--level l] [--rule r] [--host h] [--type t] [--since 24h]")
+	fmt.Println("  aegis-ctl delete <name>     Remove a service")
+	fmt.Println("  aegis-ctl policy except <service> <rule> --owner <o> --reason <r> --expires <30d>")
+	fmt.Println("  aegis-ctl policy list [--all] | policy revoke <id>")
+	fmt.Println("  aegis-ctl admissions [--service s] [--decision DENIED] [--rule r] [--since 24h]")
+	fmt.Println("  aegis-ctl admissions show <id>   Explain one admission decision")
+	fmt.Println("  aegis-ctl rules test <rules.yaml> <events.yaml> [--with-defaults]")
+	fmt.Println("  aegis-ctl profile list [service] | show|edit <service> [--image id]")
+	fmt.Println("  aegis-ctl profile learn <service> --for 2h | profile promote <service>")
+	fmt.Println("  aegis-ctl monitor [status]  Attached probes, kernel features, active config")
+	fmt.Println("  aegis-ctl monitor reload    Re-read rules, trusted binaries and probe settings")
+	fmt.Println(strings.Repeat("-", 40))
 }
\ No newline at end of file
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// MonitorStatus is what the engine's runtime monitor hooks and with which configuration
+type MonitorStatus struct {
+	Running  bool       `json:"running"`
+	Backend  string     `json:"backend"`
+	Degraded string     `json:"degraded"`
+	Started  *time.Time `json:"started"`
+	Reloaded *time.Time `json:"reloaded"`
+	Probes   []string   `json:"probes"`
+	Features struct {
+		FileAccess   bool `json:"file_access"`
+		Tracing      bool `json:"fentry"`
+		ProcessTree  bool `json:"tp_btf"`
+		ExecBlocking bool `json:"bpf_lsm"`
+	} `json:"features"`
+	Scope          string   `json:"scope"`
+	RingBufferSize int      `json:"ringbuf_size_bytes"`
+	RulesDir       string   `json:"rules_dir"`
+	RulesVersion   string   `json:"rules_version"`
+	Rules          int      `json:"rules"`
+	TrustFile      string   `json:"trust_file"`
+	Trusted        int      `json:"trusted_binaries"`
+	SensitivePaths []string `json:"sensitive_paths"`
+	ForensicsDir   string   `json:"forensics_dir"`
+	RecordFile     string   `json:"record_file"`
+}
+
+// monitorCommand: aegis-ctl monitor [status] | reload
+func monitorCommand(args []string) {
+	if len(args) == 0 || args[0] == "status" {
+		monitorStatus()
+		return
+	}
+	switch args[0] {
+	case "reload":
+		printResult(postJSON("http://localhost:8080/monitor/reload", nil))
+	default:
+		fmt.Printf("%s[ERROR] Usage: aegis-ctl monitor [status] | monitor reload%s\n", Red, Reset)
+		os.Exit(1)
+	}
+}
+
+func monitorStatus() {
+	resp, err := http.Get("http://localhost:8080/monitor")
+	if err != nil {
+		log.Fatal(Red + "[ERROR] Control Plane unreachable." + Reset)
+	}
+	defer resp.Body.Close()
+	var st MonitorStatus
+	if err := json.NewDecoder(resp.Body).Decode(&st); err != nil {
+		log.Fatalf("%s[ERROR] Unexpected response: %v%s", Red, err, Reset)
+	}
+
+	fmt.Println("\n" + Blue + strings.Repeat("=", 70) + Reset)
+	if !st.Running {
+		fmt.Printf("%sSecurity monitor: STOPPED%s (runtime detection is off, see the engine log)\n", Red, Reset)
+	} else {
+		fmt.Printf("%sSecurity monitor: RUNNING%s since %s", Green, Reset, st.Started.Local().Format("2006-01-02 15:04:05"))
+		if st.Reloaded != nil {
+			fmt.Printf(", reloaded %s", st.Reloaded.Local().Format("15:04:05"))
+		}
+		fmt.Println()
+		if st.Degraded != "" {
+			fmt.Printf("%s⚠ DEGRADED (%s backend): %s%s\n", Yellow, st.Backend, st.Degraded, Reset)
+		}
+	}
+	fmt.Println(strings.Repeat("-", 70))
+	if st.Backend != "" {
+		fmt.Printf("%-16s %s\n", "Backend:", st.Backend)
+	}
+	fmt.Printf("%-16s %s\n", "Scope:", st.Scope)
+	fmt.Printf("%-16s %d in %s (%s)\n", "Rules:", st.Rules, st.RulesDir, st.RulesVersion)
+	fmt.Printf("%-16s %d (%s)\n", "Trusted:", st.Trusted, st.TrustFile)
+	fmt.Printf("%-16s %s\n", "Sensitive paths:", strings.Join(st.SensitivePaths, ", "))
+	fmt.Printf("%-16s %d bytes\n", "Ring buffer:", st.RingBufferSize)
+	fmt.Printf("%-16s %s\n", "Forensics:", st.ForensicsDir)
+	if st.RecordFile != "" {
+		fmt.Printf("%-16s %s\n", "Recording:", st.RecordFile)
+	}
+	if st.Running {
+		fmt.Println(strings.Repeat("-", 70))
+		feature := func(name string, on bool, off string) {
+			if on {
+				fmt.Printf("%-16s %son%s\n", name+":", Green, Reset)
+			} else {
+				fmt.Printf("%-16s %soff%s (%s)\n", name+":", Yellow, Reset, off)
+			}
+		}
+		feature("File access", st.Features.FileAccess, "no openat tracepoint")
+		feature("fentry/fexit", st.Features.Tracing, "no egress or escalation probes")
+		feature("tp_btf", st.Features.ProcessTree, "lineage read from /proc")
+		feature("BPF LSM", st.Features.ExecBlocking, "deny_exec enforced by SIGKILL")
+		fmt.Printf("%-16s %s\n", "Probes:", strings.Join(st.Probes, "\n                 "))
+	}
+	fmt.Println(Blue + strings.Repeat("=", 70) + Reset)
+}
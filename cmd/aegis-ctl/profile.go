@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// BehaviourProfile is what a service normally does, learned per image revision
+type BehaviourProfile struct {
+	Service      string    `json:"service" yaml:"service"`
+	ImageID      string    `json:"image_id" yaml:"image_id"`
+	Image        string    `json:"image" yaml:"image"`
+	Mode         string    `json:"mode" yaml:"mode"`
+	LearnUntil   time.Time `json:"learn_until" yaml:"learn_until"`
+	UpdatedAt    time.Time `json:"updated_at" yaml:"updated_at"`
+	Binaries     []string  `json:"binaries" yaml:"binaries"`
+	Spawns       []Spawn   `json:"spawns" yaml:"spawns"`
+	Users        []uint32  `json:"users" yaml:"users"`
+	Destinations []string  `json:"destinations" yaml:"destinations"`
+}
+
+// Spawn is one learned parent → child exec pair
+type Spawn struct {
+	Parent string `json:"parent" yaml:"parent"`
+	Child  string `json:"child" yaml:"child"`
+}
+
+// profileCommand: aegis-ctl profile list [service] | show|edit <service> [--image id] | learn <service> --for 2h | promote <service>
+func profileCommand(args []string) {
+	usage := func() {
+		fmt.Printf("%s[ERROR] Usage: aegis-ctl profile list [service] | show <service> | edit <service> | learn <service> --for <2h> | promote <service>%s\n", Red, Reset)
+		os.Exit(1)
+	}
+	if len(args) == 0 {
+		usage()
+	}
+	if args[0] == "list" {
+		service := ""
+		if len(args) > 1 {
+			service = args[1]
+		}
+		listProfiles(service)
+		return
+	}
+	if len(args) < 2 {
+		usage()
+	}
+	service := args[1]
+
+	fs := flag.NewFlagSet(args[0], flag.ExitOnError)
+	image := fs.String("image", "", "image ID of the revision (default: most recently updated)")
+	window := fs.String("for", "", "learning window, e.g. 2h")
+	fs.Parse(args[2:])
+
+	switch args[0] {
+	case "show":
+		p := fetchProfile(service, *image)
+		out, _ := yaml.Marshal(p)
+		fmt.Print(string(out))
+	case "edit":
+		editProfile(fetchProfile(service, *image))
+	case "learn":
+		if *window == "" {
+			usage()
+		}
+		profileAction("learn", url.Values{"service": {service}, "for": {*window}})
+	case "promote":
+		profileAction("promote", url.Values{"service": {service}})
+	default:
+		fmt.Printf("%s[ERROR] Unknown profile command '%s'%s\n", Red, args[0], Reset)
+		os.Exit(1)
+	}
+}
+
+func getProfiles(service string) []BehaviourProfile {
+	resp, err := http.Get("http://localhost:8080/profiles?service=" + url.QueryEscape(service))
+	if err != nil {
+		log.Fatal(Red + "[ERROR] Control Plane unreachable." + Reset)
+	}
+	defer resp.Body.Close()
+	var list []BehaviourProfile
+	json.NewDecoder(resp.Body).Decode(&list)
+	return list
+}
+
+func listProfiles(service string) {
+	list := getProfiles(service)
+	fmt.Println("\n" + Blue + strings.Repeat("=", 100) + Reset)
+	fmt.Printf("%-20s %-25s %-9s %-8s %-7s %-6s %-6s %s\n", "SERVICE", "IMAGE", "MODE", "BINARIES", "SPAWNS", "USERS", "DESTS", "UPDATED")
+	fmt.Println(strings.Repeat("-", 100))
+	if len(list) == 0 {
+		fmt.Println("No behaviour profiles. Deploy with `learn: <window>` or run `aegis-ctl profile learn <service> --for 2h`.")
+	}
+	for _, p := range list {
+		color := Green
+		mode := p.Mode
+		if mode == "learning" {
+			color = Yellow
+			mode += " until " + p.LearnUntil.Local().Format("15:04")
+		}
+		fmt.Printf("%-20s %-25s %s%-9s%s %-8d %-7d %-6d %-6d %s\n", p.Service, p.Image, color, mode, Reset,
+			len(p.Binaries), len(p.Spawns), len(p.Users), len(p.Destinations), p.UpdatedAt.Local().Format("2006-01-02 15:04"))
+	}
+	fmt.Println(Blue + strings.Repeat("=", 100) + Reset)
+}
+
+// fetchProfile returns the profile of one image revision (newest when image is "")
+func fetchProfile(service, image string) BehaviourProfile {
+	for _, p := range getProfiles(service) {
+		if image == "" || p.ImageID == image || strings.HasPrefix(strings.TrimPrefix(p.ImageID, "sha256:"), image) {
+			return p
+		}
+	}
+	fmt.Printf("%s[ERROR] No behaviour profile for %s%s\n", Red, service, Reset)
+	os.Exit(1)
+	return BehaviourProfile{}
+}
+
+// editProfile opens the profile in $EDITOR and saves the edited lists
+func editProfile(p BehaviourProfile) {
+	f, err := os.CreateTemp("", "aegis-profile-*.yaml")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	out, _ := yaml.Marshal(p)
+	fmt.Fprintf(f, "# Behaviour profile of %s. Only binaries, spawns, users and destinations are saved.\n", p.Service)
+	f.Write(out)
+	f.Close()
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	cmd := exec.Command(editor, f.Name())
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		log.Fatalf("%s[ERROR] Editor failed: %v%s", Red, err, Reset)
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		log.Fatal(err)
+	}
+	var edited BehaviourProfile
+	if err := yaml.Unmarshal(data, &edited); err != nil {
+		log.Fatalf("%s[ERROR] Invalid YAML, nothing saved: %v%s", Red, err, Reset)
+	}
+	edited.Service, edited.ImageID = p.Service, p.ImageID
+
+	payload, _ := json.Marshal(edited)
+	req, _ := http.NewRequest(http.MethodPut, "http://localhost:8080/profiles", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "aegis-ctl/1.0.0")
+	host, _ := os.Hostname()
+	req.Header.Set("X-Aegis-Client", fmt.Sprintf("%s@%s", os.Getenv("USER"), host))
+	printResult(http.DefaultClient.Do(req))
+}
+
+func profileAction(action string, q url.Values) {
+	printResult(postJSON("http://localhost:8080/profiles/"+action+"?"+q.Encode(), nil))
+}
+
+func printResult(resp *http.Response, err error) {
+	if err != nil {
+		log.Fatal(Red + "[ERROR] Control Plane unreachable." + Reset)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		fmt.Printf("%s[ERROR] %s%s\n", Red, strings.TrimSpace(string(body)), Reset)
+		os.Exit(1)
+	}
+	fmt.Printf("%s[SUCCESS] %s%s\n", Green, strings.TrimSpace(string(body)), Reset)
+}
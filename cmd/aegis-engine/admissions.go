@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/platform"
+	"github.com/Debasish-87/aegis-v/internal/security"
+)
+
+// Admission decisions
+const (
+	DecisionAllowed = "ALLOWED"
+	DecisionDenied  = "DENIED"
+	DecisionError   = "ERROR" // admission could not complete (e.g. pull failed)
+)
+
+// admissionTrace collects what both admission phases decided for one deployment
+type admissionTrace struct {
+	Phase       string
+	Rules       []string
+	Findings    []security.Finding
+	ImageDigest string
+}
+
+// specHash fingerprints the exact spec that was submitted
+func specHash(req DeployRequest) string {
+	data, _ := json.Marshal(req)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// clientOf identifies who asked for the admission (aegis-ctl sends X-Aegis-Client)
+func clientOf(r *http.Request) string {
+	client := r.Header.Get("X-Aegis-Client")
+	if client == "" {
+		client = "unknown"
+	}
+	return fmt.Sprintf("%s (%s, %s)", client, r.UserAgent(), r.RemoteAddr)
+}
+
+// recordAdmission writes one structured decision to the admission log
+func recordAdmission(req DeployRequest, gatekeeper *security.Gatekeeper, trace *admissionTrace, decision, reason, client string) {
+	rules, _ := json.Marshal(security.RuleResults(trace.Rules, trace.Findings))
+	id, err := platform.RecordAdmission(platform.AdmissionRecord{
+		Service:       req.Name,
+		Image:         req.Image,
+		ImageDigest:   trace.ImageDigest,
+		SpecHash:      specHash(req),
+		Decision:      decision,
+		Phase:         trace.Phase,
+		Reason:        reason,
+		Rules:         rules,
+		PolicyVersion: gatekeeper.PolicyVersion(),
+		Exceptions:    security.ExceptionsUsed(trace.Findings),
+		Client:        client,
+	})
+	if err != nil {
+		log.Printf("[DB-ERROR] Failed to record admission: %v", err)
+		return
+	}
+	fmt.Printf(ColorBlue+"[GATEKEEPER] 📒 Admission #%d recorded: %s %s (%s)\n"+ColorReset, id, req.Name, decision, trace.Phase)
+}
+
+// handleAdmissions: GET ?service=&decision=&rule=&since=24h&limit=  or  ?id= for one record
+func handleAdmissions(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	w.Header().Set("Content-Type", "application/json")
+
+	if idStr := q.Get("id"); idStr != "" {
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid id", 400)
+			return
+		}
+		record, err := platform.GetAdmission(id)
+		if err != nil {
+			http.Error(w, err.Error(), 404)
+			return
+		}
+		json.NewEncoder(w).Encode(record)
+		return
+	}
+
+	filter := platform.AdmissionFilter{
+		Service:  q.Get("service"),
+		Decision: q.Get("decision"),
+		Rule:     q.Get("rule"),
+	}
+	if since := q.Get("since"); since != "" {
+		d, err := time.ParseDuration(since)
+		if err != nil {
+			http.Error(w, "Invalid since (use e.g. 24h)", 400)
+			return
+		}
+		filter.Since = time.Now().Add(-d)
+	}
+	filter.Limit, _ = strconv.Atoi(q.Get("limit"))
+
+	records, err := platform.QueryAdmissions(filter)
+	if err != nil {
+		http.Error(w, "DB Error", 500)
+		return
+	}
+	json.NewEncoder(w).Encode(records)
+}
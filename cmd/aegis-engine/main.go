@@ -1,360 +1,595 @@
-package main
-
-import (
-	"context"
-	"encoding/json"
-	"fmt"
-	"log"
-	"net/http"
-	"os"
-	"os/signal"
-	"strings" // Added for name cleaning
-	"sync"
-	"syscall"
-	"time"
-
-	"github.com/Debasish-87/aegis-v/internal/ai"
-	"github.com/Debasish-87/aegis-v/internal/guardian"
-	"github.com/Debasish-87/aegis-v/internal/orchestrator"
-	"github.com/Debasish-87/aegis-v/internal/platform"
-	"github.com/Debasish-87/aegis-v/internal/security"
-	_ "github.com/glebarez/go-sqlite"
-)
-
-var (
-	deployLock sync.Mutex
-	advisor    = ai.Advisor{ModelPath: "ollama/llama3"}
-)
-
-// ANSI Color Codes
-const (
-	ColorRed    = "\033[31m"
-	ColorGreen  = "\033[32m"
-	ColorYellow = "\033[33m"
-	ColorBlue   = "\033[34m"
-	ColorPurple = "\033[35m"
-	ColorCyan   = "\033[36m"
-	ColorReset  = "\033[0m"
-)
-
-type DeployRequest struct {
-	Name   string  `json:"name"`
-	Image  string  `json:"image"`
-	CPU    float64 `json:"cpu"`
-	Memory int64   `json:"memory"`
-}
-
-type ServiceStatus struct {
-	Name      string  `json:"name"`
-	Image     string  `json:"image"`
-	CPU       float64 `json:"cpu"`
-	Memory    int64   `json:"memory"`
-	Status    string  `json:"status"`
-	AIInsight string  `json:"ai_insight"`
-}
-
-// ---------------------------------------------------------
-// API Endpoint for aegis-ctl to fetch latest logs
-// ---------------------------------------------------------
-func handleApiLogs(w http.ResponseWriter, r *http.Request) {
-	rows, err := platform.DB.Query("SELECT timestamp, command, source, risk FROM detections ORDER BY id DESC LIMIT 5")
-	if err != nil {
-		http.Error(w, "DB Error", 500)
-		return
-	}
-	defer rows.Close()
-
-	var logs []map[string]string
-	for rows.Next() {
-		var ts, cmd, src, risk string
-		rows.Scan(&ts, &cmd, &src, &risk)
-		logs = append(logs, map[string]string{
-			"timestamp": ts,
-			"command":   cmd,
-			"source":    src,
-			"risk":      risk,
-		})
-	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(logs)
-}
-
-func startReconciliationLoop() {
-	defer func() {
-		if r := recover(); r != nil {
-			log.Printf("[CRITICAL] Reconciliation Loop Recovered from panic: %v", r)
-			time.Sleep(2 * time.Second)
-			go startReconciliationLoop()
-		}
-	}()
-
-	for {
-		time.Sleep(15 * time.Second)
-
-		rows, err := platform.DB.Query("SELECT name, image, cpu, memory FROM deployments")
-		if err != nil {
-			log.Printf("[ERROR] DB Query failed in loop: %v", err)
-			continue
-		}
-
-		for rows.Next() {
-			var name, image string
-			var cpu float64
-			var mem int64
-			rows.Scan(&name, &image, &cpu, &mem)
-
-			go func(n, img string, c float64, m int64) {
-				if !orchestrator.IsContainerRunning(n) {
-					deployLock.Lock()
-					defer deployLock.Unlock()
-
-					if !orchestrator.IsContainerRunning(n) {
-						fmt.Printf(ColorYellow+"[SELF-HEALING] 🚨 Service '%s' is DOWN.\n"+ColorReset, n)
-						fmt.Printf(ColorPurple+"[AI-ADVISOR] 🧠 Analyzing root cause for %s...\n"+ColorReset, n)
-
-						alerts := getRecentAlerts(n)
-						insightChan := make(chan string, 1)
-
-						go func() {
-							contextMsg := fmt.Sprintf("Service %s failed. Recent suspicious activity found: %d alerts.", n, len(alerts))
-							res := advisor.AnalyzeState(n, contextMsg, alerts)
-							insightChan <- res
-						}()
-
-						var insight string
-						select {
-						case res := <-insightChan:
-							insight = res
-						case <-time.After(10 * time.Second):
-							insight = "TIMEOUT_AUTO_RECOVER"
-							fmt.Printf(ColorRed + "[SYSTEM] ⚠️ AI Advisor Timeout. Defaulting to Safety Recovery.\n" + ColorReset)
-						}
-
-						platform.DB.Exec("UPDATE deployments SET ai_insight = ? WHERE name = ?", insight, n)
-
-						if insight == "BLOCK" {
-							fmt.Printf(ColorRed+"[SECURITY] 🛡️ AI Blocked restart of %s due to threat detection.\n"+ColorReset, n)
-							platform.DB.Exec("UPDATE deployments SET status = 'QUARANTINED' WHERE name = ?", n)
-						} else {
-							fmt.Printf(ColorGreen+"[SYSTEM] 🛠️ Auto-recovery in progress for %s...\n"+ColorReset, n)
-							err := orchestrator.ProvisionContainer(img, n, c, m)
-							if err == nil {
-								platform.DB.Exec("UPDATE deployments SET status = 'ACTIVE', ai_insight = 'Self-Healed via AEGIS' WHERE name = ?", n)
-								fmt.Printf(ColorGreen+"[SUCCESS] %s is back online.\n"+ColorReset, n)
-							}
-						}
-					}
-				}
-			}(name, image, cpu, mem)
-		}
-		rows.Close()
-	}
-}
-
-func getRecentAlerts(serviceName string) []string {
-	var alerts []string
-	rows, _ := platform.DB.Query("SELECT command || ' (Risk: ' || risk || ')' FROM detections WHERE identity LIKE ? ORDER BY timestamp DESC LIMIT 10", "%"+serviceName+"%")
-	if rows != nil {
-		defer rows.Close()
-		for rows.Next() {
-			var cmd string
-			rows.Scan(&cmd)
-			alerts = append(alerts, cmd)
-		}
-	}
-	return alerts
-}
-
-func handleDeploy(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", 405)
-		return
-	}
-
-	var req DeployRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid Payload", 400)
-		return
-	}
-
-	fmt.Printf(ColorBlue+"[GATEKEEPER] 🛡️ Verifying image integrity for %s...\n"+ColorReset, req.Image)
-	gatekeeper := security.NewGatekeeper()
-	isSafe, reason := gatekeeper.VerifyImage(req.Image)
-
-	if !isSafe {
-		fmt.Printf(ColorRed+"[SECURITY-VIOLATION] 🛡️ BLOCKING DEPLOYMENT: %s\n"+ColorReset, reason)
-		platform.DB.Exec("INSERT INTO security_alerts (service, alert_type, message) VALUES (?, ?, ?)",
-			req.Name, "POLICY_VIOLATION", reason)
-		http.Error(w, "Gatekeeper Blocked: "+reason, http.StatusForbidden)
-		return
-	}
-
-	deployLock.Lock()
-	defer deployLock.Unlock()
-
-	platform.DB.Exec("INSERT OR REPLACE INTO deployments (name, image, cpu, memory, status, ai_insight, last_seen) VALUES (?, ?, ?, ?, ?, ?, ?)",
-		req.Name, req.Image, req.CPU, req.Memory, "PROVISIONING", "Initial validation passed", time.Now())
-
-	fmt.Printf(ColorGreen+"[SYSTEM] Provisioning Container: %s...\n"+ColorReset, req.Name)
-	err := orchestrator.ProvisionContainer(req.Image, req.Name, req.CPU, req.Memory)
-	if err != nil {
-		log.Printf("[ERROR] Provisioning failed: %v", err)
-		platform.DB.Exec("UPDATE deployments SET status = 'FAILED', ai_insight = ? WHERE name = ?", err.Error(), req.Name)
-		http.Error(w, "Provisioning Failed", 500)
-		return
-	}
-
-	platform.DB.Exec("UPDATE deployments SET status = 'ACTIVE', ai_insight = 'Monitoring Started' WHERE name = ?", req.Name)
-	fmt.Printf(ColorPurple+"[AI-ADVISOR] Behavioral monitoring active for '%s'.\n"+ColorReset, req.Name)
-	fmt.Printf(ColorGreen+"[SUCCESS] AEGIS-V: Workload '%s' is now shielded and live.\n\n"+ColorReset, req.Name)
-
-	w.WriteHeader(http.StatusAccepted)
-	w.Write([]byte("AEGIS-V: Secure deployment successful"))
-}
-
-// handleStatus: (UPDATED) Combines DB and Real-time Docker Status
-func handleStatus(w http.ResponseWriter, r *http.Request) {
-	// 1. Get Live Docker List
-	dockerContainers, err := orchestrator.GetAllContainers()
-	if err != nil {
-		log.Printf("[ERROR] Failed to fetch live docker status: %v", err)
-	}
-
-	// 2. Get DB Deployments
-	rows, err := platform.DB.Query("SELECT name, image, cpu, memory, status, COALESCE(ai_insight, 'No Insights Available') FROM deployments")
-	if err != nil {
-		http.Error(w, "DB Error", 500)
-		return
-	}
-	defer rows.Close()
-
-	dbMap := make(map[string]ServiceStatus)
-	for rows.Next() {
-		var s ServiceStatus
-		var dbStatus, insight string
-		rows.Scan(&s.Name, &s.Image, &s.CPU, &s.Memory, &dbStatus, &insight)
-		s.Status = dbStatus
-		s.AIInsight = insight
-		dbMap[s.Name] = s
-	}
-
-	var finalStatuses []ServiceStatus
-
-	// 3. Merge Live Docker data into Response
-	for _, c := range dockerContainers {
-		name := "unknown"
-		if len(c.Names) > 0 {
-			name = strings.TrimPrefix(c.Names[0], "/")
-		}
-
-		if dbInfo, exists := dbMap[name]; exists {
-			dbInfo.Status = "✅ " + c.Status // Real Docker status
-			finalStatuses = append(finalStatuses, dbInfo)
-			delete(dbMap, name) // Avoid duplicate
-		} else {
-			// Manual containers not in our DB
-			finalStatuses = append(finalStatuses, ServiceStatus{
-				Name:      name,
-				Image:     c.Image,
-				Status:    "⚪ " + c.Status,
-				AIInsight: "External Service",
-			})
-		}
-	}
-
-	// 4. Handle Offline/Crashed services (In DB but not in Docker)
-	for _, s := range dbMap {
-		s.Status = "🚨 DOWN"
-		finalStatuses = append(finalStatuses, s)
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(finalStatuses)
-}
-
-func handleDelete(w http.ResponseWriter, r *http.Request) {
-	name := r.URL.Query().Get("name")
-	if name == "" {
-		http.Error(w, "Missing name", 400)
-		return
-	}
-	deployLock.Lock()
-	defer deployLock.Unlock()
-
-	fmt.Printf(ColorRed+"[SYSTEM] Decommissioning service: %s\n"+ColorReset, name)
-	platform.DB.Exec("DELETE FROM deployments WHERE name = ?", name)
-	orchestrator.StopContainer(name)
-	w.Write([]byte("Service removed successfully"))
-}
-
-func handleAlerts(w http.ResponseWriter, r *http.Request) {
-	rows, err := platform.DB.Query("SELECT id, command, risk, source, identity, pid, timestamp FROM detections ORDER BY timestamp DESC LIMIT 50")
-	if err != nil {
-		http.Error(w, "DB Error", 500)
-		return
-	}
-	defer rows.Close()
-
-	var alerts []map[string]interface{}
-	for rows.Next() {
-		var id, pid int
-		var cmd, risk, src, identity, ts string
-		rows.Scan(&id, &cmd, &risk, &src, &identity, &pid, &ts)
-		alerts = append(alerts, map[string]interface{}{
-			"id": id, "command": cmd, "risk": risk, "source": src, "identity": identity, "pid": pid, "timestamp": ts,
-		})
-	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(alerts)
-}
-
-func handleHealth(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("AEGIS-ENGINE: UP"))
-}
-
-func main() {
-	dbConn, err := platform.InitDB()
-	if err != nil {
-		log.Fatalf("[CRITICAL] Storage Failure: %v", err)
-	}
-
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
-
-	guardian.InitGuardian(dbConn)
-	fmt.Println(ColorBlue + "[SYSTEM] AEGIS-V Engine v2.3 (Autonomous & AI-Driven) Initializing..." + ColorReset)
-
-	go security.StartSecurityMonitor()
-	go startReconciliationLoop()
-
-	mux := http.NewServeMux()
-	mux.HandleFunc("/deploy", handleDeploy)
-	mux.HandleFunc("/status", handleStatus)
-	mux.HandleFunc("/delete", handleDelete)
-	mux.HandleFunc("/alerts", handleAlerts)
-	mux.HandleFunc("/api/logs", handleApiLogs)
-	mux.HandleFunc("/health", handleHealth)
-
-	server := &http.Server{
-		Addr:    ":8080",
-		Handler: mux,
-	}
-
-	go func() {
-		fmt.Println(ColorGreen + "[SYSTEM] API Gateway listening on :8080" + ColorReset)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("listen: %s\n", err)
-		}
-	}()
-
-	<-ctx.Done()
-	fmt.Println(ColorYellow + "\n[SYSTEM] Graceful shutdown initiated..." + ColorReset)
-
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	server.Shutdown(shutdownCtx)
-	dbConn.Close()
-	fmt.Println(ColorGreen + "[SYSTEM] Engine offline. All security probes detached." + ColorReset)
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings" // Added for name cleaning
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/ai"
+	"github.com/Debasish-87/aegis-v/internal/guardian"
+	"github.com/Debasish-87/aegis-v/internal/orchestrator"
+	"github.com/Debasish-87/aegis-v/internal/platform"
+	"github.com/Debasish-87/aegis-v/internal/security"
+	"github.com/Debasish-87/aegis-v/internal/telemetry"
+	"github.com/docker/docker/api/types"
+	_ "github.com/glebarez/go-sqlite"
+)
+
+var (
+	deployLock sync.Mutex
+	advisor    = ai.Advisor{ModelPath: "ollama/llama3"}
+)
+
+// ANSI Color Codes
+const (
+	ColorRed    = "\033[31m"
+	ColorGreen  = "\033[32m"
+	ColorYellow = "\033[33m"
+	ColorBlue   = "\033[34m"
+	ColorPurple = "\033[35m"
+	ColorCyan   = "\033[36m"
+	ColorReset  = "\033[0m"
+)
+
+type DeployRequest struct {
+	Name          string  `json:"name"`
+	Version       string  `json:"version"`
+	Image         string  `json:"image"`
+	SecurityLevel string  `json:"security_level"`
+	CPU           float64 `json:"cpu"`
+	Memory        int64   `json:"memory"`
+	// Egress is the runtime outbound allowlist; nil means no policy is enforced
+	Egress []platform.EgressRule `json:"egress"`
+	// DenyExec names binaries that must never run in the service (blocked in-kernel where possible)
+	DenyExec []string `json:"deny_exec"`
+	// Learn is the behaviour learning window after deployment (e.g. "2h"); enforced afterwards
+	Learn string `json:"learn"`
+	// Spawn names the main processes allowed to start programs; nil keeps the default spawn profile
+	Spawn []string `json:"spawn"`
+}
+
+type ServiceStatus struct {
+	Name      string  `json:"name"`
+	Image     string  `json:"image"`
+	CPU       float64 `json:"cpu"`
+	Memory    int64   `json:"memory"`
+	Status    string  `json:"status"`
+	AIInsight string  `json:"ai_insight"`
+}
+
+// ---------------------------------------------------------
+// API Endpoint for aegis-ctl to fetch latest logs
+// ---------------------------------------------------------
+func handleApiLogs(w http.ResponseWriter, r *http.Request) {
+	rows, err := platform.DB.Query("SELECT timestamp, command, source, risk FROM detections ORDER BY id DESC LIMIT 5")
+	if err != nil {
+		http.Error(w, "DB Error", 500)
+		return
+	}
+	defer rows.Close()
+
+	var logs []map[string]string
+	for rows.Next() {
+		var ts, cmd, src, risk string
+		rows.Scan(&ts, &cmd, &src, &risk)
+		logs = append(logs, map[string]string{
+			"timestamp": ts,
+			"command":   cmd,
+			"source":    src,
+			"risk":      risk,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(logs)
+}
+
+func startReconciliationLoop() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[CRITICAL] Reconciliation Loop Recovered from panic: %v", r)
+			time.Sleep(2 * time.Second)
+			go startReconciliationLoop()
+		}
+	}()
+
+	for {
+		time.Sleep(15 * time.Second)
+
+		rows, err := platform.DB.Query("SELECT name, image, cpu, memory, COALESCE(security_level, 'medium') FROM deployments WHERE status != 'BLOCKED'")
+		if err != nil {
+			log.Printf("[ERROR] DB Query failed in loop: %v", err)
+			continue
+		}
+
+		for rows.Next() {
+			var name, image, level string
+			var cpu float64
+			var mem int64
+			rows.Scan(&name, &image, &cpu, &mem, &level)
+
+			go func(n, img, lvl string, c float64, m int64) {
+				if !orchestrator.IsContainerRunning(n) {
+					deployLock.Lock()
+					defer deployLock.Unlock()
+
+					if !orchestrator.IsContainerRunning(n) {
+						fmt.Printf(ColorYellow+"[SELF-HEALING] 🚨 Service '%s' is DOWN.\n"+ColorReset, n)
+						fmt.Printf(ColorPurple+"[AI-ADVISOR] 🧠 Analyzing root cause for %s...\n"+ColorReset, n)
+
+						alerts := getRecentAlerts(n)
+						insightChan := make(chan string, 1)
+
+						go func() {
+							contextMsg := fmt.Sprintf("Service %s failed. Recent suspicious activity found: %d alerts.", n, len(alerts))
+							res := advisor.AnalyzeState(n, contextMsg, alerts)
+							insightChan <- res
+						}()
+
+						var insight string
+						select {
+						case res := <-insightChan:
+							insight = res
+						case <-time.After(10 * time.Second):
+							insight = "TIMEOUT_AUTO_RECOVER"
+							fmt.Printf(ColorRed + "[SYSTEM] ⚠️ AI Advisor Timeout. Defaulting to Safety Recovery.\n" + ColorReset)
+						}
+
+						platform.DB.Exec("UPDATE deployments SET ai_insight = ? WHERE name = ?", insight, n)
+
+						if insight == "BLOCK" {
+							fmt.Printf(ColorRed+"[SECURITY] 🛡️ AI Blocked restart of %s due to threat detection.\n"+ColorReset, n)
+							platform.DB.Exec("UPDATE deployments SET status = 'QUARANTINED' WHERE name = ?", n)
+						} else {
+							fmt.Printf(ColorGreen+"[SYSTEM] 🛠️ Auto-recovery in progress for %s...\n"+ColorReset, n)
+							gatekeeper := security.NewGatekeeper()
+							spec := DeployRequest{Name: n, Image: img, SecurityLevel: lvl, CPU: c, Memory: m}
+							trace := &admissionTrace{Phase: security.PhasePrePull}
+							err := orchestrator.ProvisionContainer(img, n, c, m, imageAdmission(gatekeeper, n, lvl, trace))
+							if trace.Phase == security.PhasePostPull {
+								decision := DecisionAllowed
+								if errors.Is(err, orchestrator.ErrImageRejected) {
+									decision = DecisionDenied
+								}
+								recordAdmission(spec, gatekeeper, trace, decision, security.Summarize(trace.Findings), "aegis-engine/reconciler")
+							}
+							if errors.Is(err, orchestrator.ErrImageRejected) {
+								fmt.Printf(ColorRed+"[SECURITY] 🛡️ Recovery of %s blocked by image policy: %v\n"+ColorReset, n, err)
+								platform.DB.Exec("UPDATE deployments SET status = 'BLOCKED', ai_insight = ? WHERE name = ?", err.Error(), n)
+							} else if err == nil {
+								platform.DB.Exec("UPDATE deployments SET status = 'ACTIVE', ai_insight = 'Self-Healed via AEGIS' WHERE name = ?", n)
+								fmt.Printf(ColorGreen+"[SUCCESS] %s is back online.\n"+ColorReset, n)
+							}
+						}
+					}
+				}
+			}(name, image, level, cpu, mem)
+		}
+		rows.Close()
+	}
+}
+
+func getRecentAlerts(serviceName string) []string {
+	var alerts []string
+	rows, _ := platform.DB.Query("SELECT command || ' (Risk: ' || risk || ')' FROM detections WHERE service = ? ORDER BY timestamp DESC LIMIT 10", serviceName)
+	if rows != nil {
+		defer rows.Close()
+		for rows.Next() {
+			var cmd string
+			rows.Scan(&cmd)
+			alerts = append(alerts, cmd)
+		}
+	}
+	return alerts
+}
+
+func handleDeploy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+
+	var req DeployRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid Payload", 400)
+		return
+	}
+	gatekeeper := security.NewGatekeeper()
+	client := clientOf(r)
+
+	// An invalid spec is a pre-pull denial like any other and goes to the admission log too
+	invalid := func(prefix string, err error) {
+		trace := &admissionTrace{
+			Phase:    security.PhasePrePull,
+			Rules:    []string{security.RuleSpecInvalid},
+			Findings: []security.Finding{{Rule: security.RuleSpecInvalid, Severity: "CRITICAL", Detail: err.Error(), Action: security.ActionBlock}},
+		}
+		recordAdmission(req, gatekeeper, trace, DecisionDenied, err.Error(), client)
+		http.Error(w, prefix+err.Error(), 400)
+	}
+	if err := security.ValidateLevel(req.SecurityLevel); err != nil {
+		invalid("Invalid spec: ", err)
+		return
+	}
+	if err := security.ValidateEgress(req.Egress); err != nil {
+		invalid("Invalid egress policy: ", err)
+		return
+	}
+	if err := security.ValidateDenyExec(req.DenyExec); err != nil {
+		invalid("Invalid deny_exec: ", err)
+		return
+	}
+	if err := security.ValidateSpawn(req.Spawn); err != nil {
+		invalid("Invalid spawn profile: ", err)
+		return
+	}
+	learnWindow, err := security.ParseLearnWindow(req.Learn)
+	if err != nil {
+		invalid("Invalid spec: ", err)
+		return
+	}
+
+	fmt.Printf(ColorBlue+"[GATEKEEPER] 🛡️ Verifying image integrity for %s...\n"+ColorReset, req.Image)
+	findings := gatekeeper.ApplyExceptions(req.Name, gatekeeper.EvaluateSpec(req.Name, req.Image))
+	trace := &admissionTrace{Phase: security.PhasePrePull, Rules: gatekeeper.PhaseRules(security.PhasePrePull), Findings: findings}
+
+	recordExceptionUse(req.Name, findings)
+	if security.Blocked(findings) {
+		reason := security.Summarize(findings)
+		recordAdmission(req, gatekeeper, trace, DecisionDenied, reason, client)
+		fmt.Printf(ColorRed+"[SECURITY-VIOLATION] 🛡️ BLOCKING DEPLOYMENT: %s\n"+ColorReset, reason)
+		platform.DB.Exec("INSERT INTO security_alerts (service, alert_type, message) VALUES (?, ?, ?)",
+			req.Name, "POLICY_VIOLATION", reason)
+		http.Error(w, "Gatekeeper Blocked: "+reason, http.StatusForbidden)
+		return
+	}
+
+	deployLock.Lock()
+	defer deployLock.Unlock()
+
+	level := security.NormalizeLevel(req.SecurityLevel)
+	egress, _ := json.Marshal(req.Egress)
+	denyExec, _ := json.Marshal(req.DenyExec)
+	spawn, _ := json.Marshal(req.Spawn)
+	learnUntil := ""
+	if learnWindow > 0 {
+		learnUntil = time.Now().Add(learnWindow).UTC().Format(time.RFC3339)
+	}
+	// The deployments row (image, revision, runtime policy) is only written once the
+	// image passed post-pull admission; a rejected redeploy keeps the previous row.
+	admit := imageAdmission(gatekeeper, req.Name, level, trace)
+	admitAndSave := func(img types.ImageInspect) error {
+		if err := admit(img); err != nil {
+			return err
+		}
+		err := security.SyncProfiles(req.Name, func() error {
+			// Every deploy of a service is a new revision; detections record the one they happened in
+			var revision int
+			platform.DB.QueryRow("SELECT COALESCE(revision, 0) FROM deployments WHERE name = ?", req.Name).Scan(&revision)
+			_, err := platform.DB.Exec("INSERT OR REPLACE INTO deployments (name, image, cpu, memory, status, ai_insight, last_seen, version, security_level, egress, deny_exec, learn_until, revision, spawn) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+				req.Name, req.Image, req.CPU, req.Memory, "PROVISIONING", "Admission passed", time.Now(), req.Version, level, string(egress), string(denyExec), learnUntil, revision+1, string(spawn))
+			return err
+		})
+		if err != nil {
+			log.Printf("[DB-ERROR] Failed to save deployment %s: %v", req.Name, err)
+		}
+		return nil
+	}
+
+	fmt.Printf(ColorGreen+"[SYSTEM] Provisioning Container: %s...\n"+ColorReset, req.Name)
+	err = orchestrator.ProvisionContainer(req.Image, req.Name, req.CPU, req.Memory, admitAndSave)
+	if errors.Is(err, orchestrator.ErrImageRejected) {
+		// The previous container (if any) is still running under its own row; the
+		// rejection itself lives in the admission log and security_alerts
+		recordAdmission(req, gatekeeper, trace, DecisionDenied, security.Summarize(trace.Findings), client)
+		fmt.Printf(ColorRed+"[SECURITY-VIOLATION] 🛡️ BLOCKING DEPLOYMENT (post-pull): %v\n"+ColorReset, err)
+		http.Error(w, "Gatekeeper Blocked: "+err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		if trace.Phase == security.PhasePostPull {
+			recordAdmission(req, gatekeeper, trace, DecisionAllowed, "Admitted; provisioning failed: "+err.Error(), client)
+			platform.DB.Exec("UPDATE deployments SET status = 'FAILED', ai_insight = ? WHERE name = ?", err.Error(), req.Name)
+		} else {
+			// Nothing was replaced yet: leave the previous deployment untouched
+			recordAdmission(req, gatekeeper, trace, DecisionError, "Admission incomplete: "+err.Error(), client)
+		}
+		log.Printf("[ERROR] Provisioning failed: %v", err)
+		http.Error(w, "Provisioning Failed", 500)
+		return
+	}
+
+	recordAdmission(req, gatekeeper, trace, DecisionAllowed, "", client)
+	platform.DB.Exec("UPDATE deployments SET status = 'ACTIVE', ai_insight = 'Monitoring Started' WHERE name = ?", req.Name)
+	fmt.Printf(ColorPurple+"[AI-ADVISOR] Behavioral monitoring active for '%s'.\n"+ColorReset, req.Name)
+	if learnWindow > 0 {
+		fmt.Printf(ColorPurple+"[AI-ADVISOR] 🧬 Learning the behaviour profile of '%s' for %s, enforced afterwards.\n"+ColorReset, req.Name, learnWindow)
+	}
+	fmt.Printf(ColorGreen+"[SUCCESS] AEGIS-V: Workload '%s' is now shielded and live.\n\n"+ColorReset, req.Name)
+
+	msg := "AEGIS-V: Secure deployment successful"
+	if used := security.ExceptionsUsed(trace.Findings); len(used) > 0 {
+		msg += fmt.Sprintf(" (policy exceptions used: %v)", used)
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte(msg))
+}
+
+// imageAdmission builds the post-pull policy hook for a deployment.
+// Warnings are recorded as alerts; any BLOCK finding rejects the image.
+func imageAdmission(gatekeeper *security.Gatekeeper, service, level string, trace *admissionTrace) orchestrator.ImageAdmission {
+	return func(img types.ImageInspect) error {
+		fmt.Printf(ColorBlue+"[GATEKEEPER] 🔍 Inspecting image config of %s (level: %s)...\n"+ColorReset, img.ID, level)
+		findings, err := gatekeeper.EvaluatePulledImage(img, level, orchestrator.ExportImage)
+		if err != nil {
+			fmt.Printf(ColorYellow+"[GATEKEEPER] ⚠️ Filesystem scan failed: %v\n"+ColorReset, err)
+			platform.DB.Exec("INSERT INTO security_alerts (service, alert_type, message) VALUES (?, ?, ?)",
+				service, "IMAGE_SCAN_FAILED", err.Error())
+		}
+		findings = gatekeeper.ApplyExceptions(service, findings)
+		trace.Phase = security.PhasePostPull
+		trace.Rules = append(trace.Rules, gatekeeper.PhaseRules(security.PhasePostPull)...)
+		trace.Findings = append(trace.Findings, findings...)
+		trace.ImageDigest = img.ID
+		if len(img.RepoDigests) > 0 {
+			trace.ImageDigest = img.RepoDigests[0]
+		}
+		recordExceptionUse(service, findings)
+
+		for _, f := range findings {
+			if f.Action == security.ActionWarn {
+				fmt.Printf(ColorYellow+"[GATEKEEPER] ⚠️ %s: %s\n"+ColorReset, f.Rule, f.Detail)
+				platform.DB.Exec("INSERT INTO security_alerts (service, alert_type, message) VALUES (?, ?, ?)",
+					service, "IMAGE_POLICY_WARNING", fmt.Sprintf("[%s] %s", f.Rule, f.Detail))
+			}
+		}
+
+		if security.Blocked(findings) {
+			reason := security.Summarize(findings)
+			platform.DB.Exec("INSERT INTO security_alerts (service, alert_type, message) VALUES (?, ?, ?)",
+				service, "POLICY_VIOLATION", reason)
+			return errors.New(reason)
+		}
+		return nil
+	}
+}
+
+// handleStatus: (UPDATED) Combines DB and Real-time Docker Status
+func handleStatus(w http.ResponseWriter, r *http.Request) {
+	// 1. Get Live Docker List
+	dockerContainers, err := orchestrator.GetAllContainers()
+	if err != nil {
+		log.Printf("[ERROR] Failed to fetch live docker status: %v", err)
+	}
+
+	// 2. Get DB Deployments
+	rows, err := platform.DB.Query("SELECT name, image, cpu, memory, status, COALESCE(ai_insight, 'No Insights Available') FROM deployments")
+	if err != nil {
+		http.Error(w, "DB Error", 500)
+		return
+	}
+	defer rows.Close()
+
+	dbMap := make(map[string]ServiceStatus)
+	for rows.Next() {
+		var s ServiceStatus
+		var dbStatus, insight string
+		rows.Scan(&s.Name, &s.Image, &s.CPU, &s.Memory, &dbStatus, &insight)
+		s.Status = dbStatus
+		s.AIInsight = insight
+		dbMap[s.Name] = s
+	}
+
+	var finalStatuses []ServiceStatus
+
+	// 3. Merge Live Docker data into Response
+	for _, c := range dockerContainers {
+		name := "unknown"
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+
+		if dbInfo, exists := dbMap[name]; exists {
+			dbInfo.Status = "✅ " + c.Status // Real Docker status
+			finalStatuses = append(finalStatuses, dbInfo)
+			delete(dbMap, name) // Avoid duplicate
+		} else {
+			// Manual containers not in our DB
+			finalStatuses = append(finalStatuses, ServiceStatus{
+				Name:      name,
+				Image:     c.Image,
+				Status:    "⚪ " + c.Status,
+				AIInsight: "External Service",
+			})
+		}
+	}
+
+	// 4. Handle Offline/Crashed services (In DB but not in Docker)
+	for _, s := range dbMap {
+		s.Status = "🚨 DOWN"
+		finalStatuses = append(finalStatuses, s)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(finalStatuses)
+}
+
+func handleDelete(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		http.Error(w, "Missing name", 400)
+		return
+	}
+	deployLock.Lock()
+	defer deployLock.Unlock()
+
+	fmt.Printf(ColorRed+"[SYSTEM] Decommissioning service: %s\n"+ColorReset, name)
+	platform.DB.Exec("DELETE FROM deployments WHERE name = ?", name)
+	orchestrator.StopContainer(name)
+	w.Write([]byte("Service removed successfully"))
+}
+
+// handleAlerts: GET ?service=&container=&image=&revision=&level=&rule=&host=&type=&since=24h&limit=
+func handleAlerts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := platform.DetectionFilter{
+		Service:   q.Get("service"),
+		Container: q.Get("container"),
+		Image:     q.Get("image"),
+		Level:     q.Get("level"),
+		Rule:      q.Get("rule"),
+		Host:      q.Get("host"),
+		Type:      q.Get("type"),
+	}
+	if rev := q.Get("revision"); rev != "" {
+		n, err := strconv.Atoi(rev)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid revision", 400)
+			return
+		}
+		filter.Revision = n
+	}
+	if since := q.Get("since"); since != "" {
+		d, err := time.ParseDuration(since)
+		if err != nil {
+			http.Error(w, "Invalid since (use e.g. 24h)", 400)
+			return
+		}
+		filter.Since = time.Now().Add(-d)
+	}
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit <= 0 {
+		limit = 50
+	}
+	where, args := filter.Where()
+
+	rows, err := platform.DB.Query("SELECT id, command, risk, source, identity, pid, timestamp, COALESCE(filename, ''), COALESCE(argv, '[]'), COALESCE(argv_truncated, 0), COALESCE(cwd, ''), COALESCE(detection_type, 'EXEC'), COALESCE(dest_ip, ''), COALESCE(dest_port, 0), COALESCE(protocol, ''), COALESCE(open_flags, 0), COALESCE(old_uid, 0), COALESCE(new_uid, 0), COALESCE(old_euid, 0), COALESCE(new_euid, 0), COALESCE(caps_gained, '[]'), COALESCE(response, ''), COALESCE(ancestry, '[]'), COALESCE(drops_in_window, 0), COALESCE(rule_id, ''), COALESCE(mitre, ''), COALESCE(deviation, ''), COALESCE(sha256, ''), COALESCE(fileless, ''), COALESCE(sample, ''), "+
+		"COALESCE(service, ''), COALESCE(container_id, ''), COALESCE(image, ''), COALESCE(image_digest, ''), COALESCE(revision, 0), COALESCE(version, ''), COALESCE(security_level, ''), COALESCE(rules_version, ''), COALESCE(host, ''), COALESCE(backend, ''), COALESCE(ai_verdict, '') "+
+		"FROM detections "+where+" ORDER BY timestamp DESC LIMIT ?", append(args, limit)...)
+	if err != nil {
+		http.Error(w, "DB Error", 500)
+		return
+	}
+	defer rows.Close()
+
+	var alerts []map[string]interface{}
+	for rows.Next() {
+		var id, pid, destPort, openFlags, oldUid, newUid, oldEuid, newEuid int
+		var dc platform.DetectionContext
+		var cmd, risk, src, identity, ts, filename, argvJSON, cwd, detType, destIP, proto, capsJSON, response, ancestryJSON, ruleID, mitre, deviation, sha256, fileless, sample, aiVerdict string
+		var truncated, dropsInWindow bool
+		rows.Scan(&id, &cmd, &risk, &src, &identity, &pid, &ts, &filename, &argvJSON, &truncated, &cwd, &detType, &destIP, &destPort, &proto, &openFlags, &oldUid, &newUid, &oldEuid, &newEuid, &capsJSON, &response, &ancestryJSON, &dropsInWindow, &ruleID, &mitre, &deviation, &sha256, &fileless, &sample,
+			&dc.Service, &dc.ContainerID, &dc.Image, &dc.ImageDigest, &dc.Revision, &dc.Version, &dc.SecurityLevel, &dc.RulesVersion, &dc.Host, &dc.Backend, &aiVerdict)
+		var argv []string
+		json.Unmarshal([]byte(argvJSON), &argv)
+		var caps []string
+		json.Unmarshal([]byte(capsJSON), &caps)
+		var ancestry []map[string]interface{}
+		json.Unmarshal([]byte(ancestryJSON), &ancestry)
+		alerts = append(alerts, map[string]interface{}{
+			"id": id, "command": cmd, "risk": risk, "source": src, "identity": identity, "pid": pid, "timestamp": ts,
+			"filename": filename, "argv": argv, "argv_truncated": truncated, "cwd": cwd,
+			"detection_type": detType, "dest_ip": destIP, "dest_port": destPort, "protocol": proto, "open_flags": openFlags,
+			"old_uid": oldUid, "new_uid": newUid, "old_euid": oldEuid, "new_euid": newEuid, "caps_gained": caps, "response": response,
+			"ancestry": ancestry, "drops_in_window": dropsInWindow, "rule_id": ruleID, "mitre": mitre, "deviation": deviation, "sha256": sha256, "fileless": fileless, "sample": sample,
+			"ai_verdict": aiVerdict, "context": dc,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alerts)
+}
+
+// handleHealth reports the engine as up plus the state of the event pipeline
+// (status "degraded" while the kernel is dropping events)
+func handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(telemetry.Snapshot())
+}
+
+// handleMetrics serves the pipeline counters in the Prometheus text format
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	telemetry.WritePrometheus(w)
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		os.Exit(runReplay(os.Args[2:]))
+	}
+
+	dbConn, err := platform.InitDB()
+	if err != nil {
+		log.Fatalf("[CRITICAL] Storage Failure: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	guardian.InitGuardian(dbConn)
+	fmt.Println(ColorBlue + "[SYSTEM] AEGIS-V Engine v2.3 (Autonomous & AI-Driven) Initializing..." + ColorReset)
+
+	// The monitoring scope is built from the container cache: without this, the
+	// containers already running would be out of scope until the watcher caught up
+	loadCtx, cancelLoad := context.WithTimeout(ctx, 10*time.Second)
+	if err := orchestrator.LoadContainers(loadCtx); err != nil {
+		log.Printf("[WARN] Running containers not loaded before the monitor started: %v", err)
+	}
+	cancelLoad()
+	go orchestrator.WatchContainers(ctx)
+	monitor = security.NewMonitor(security.LoadMonitorConfig())
+	if err := monitor.Start(ctx); err != nil {
+		log.Printf("[ERROR] Security monitor not started, runtime detection is off: %v", err)
+	}
+	go reloadOnHangup(ctx)
+	go startReconciliationLoop()
+	go startExceptionExpiryWatch()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/deploy", handleDeploy)
+	mux.HandleFunc("/validate", handleValidate)
+	mux.HandleFunc("/policy/exceptions", handlePolicyExceptions)
+	mux.HandleFunc("/admissions", handleAdmissions)
+	mux.HandleFunc("/profiles", handleProfiles)
+	mux.HandleFunc("/profiles/learn", handleProfileLearn)
+	mux.HandleFunc("/profiles/promote", handleProfilePromote)
+	mux.HandleFunc("/status", handleStatus)
+	mux.HandleFunc("/delete", handleDelete)
+	mux.HandleFunc("/alerts", handleAlerts)
+	mux.HandleFunc("/api/logs", handleApiLogs)
+	mux.HandleFunc("/health", handleHealth)
+	mux.HandleFunc("/metrics", handleMetrics)
+	mux.HandleFunc("/monitor", handleMonitor)
+	mux.HandleFunc("/monitor/reload", handleMonitorReload)
+
+	server := &http.Server{
+		Addr:    ":8080",
+		Handler: mux,
+	}
+
+	go func() {
+		fmt.Println(ColorGreen + "[SYSTEM] API Gateway listening on :8080" + ColorReset)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("listen: %s\n", err)
+		}
+	}()
+
+	<-ctx.Done()
+	fmt.Println(ColorYellow + "\n[SYSTEM] Graceful shutdown initiated..." + ColorReset)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	server.Shutdown(shutdownCtx)
+	monitor.Stop()
+	dbConn.Close()
+	fmt.Println(ColorGreen + "[SYSTEM] Engine offline. All security probes detached." + ColorReset)
 }
\ No newline at end of file
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Debasish-87/aegis-v/internal/security"
+)
+
+// monitor is the runtime security monitor, started in main
+var monitor *security.Monitor
+
+// reloadMonitor re-reads the monitor configuration from the environment and applies it
+func reloadMonitor(trigger string) error {
+	fmt.Printf(ColorCyan+"[SYSTEM] 🔄 Reloading security monitor (%s)...\n"+ColorReset, trigger)
+	err := monitor.Reload(security.LoadMonitorConfig())
+	if err != nil {
+		fmt.Printf(ColorRed+"[SYSTEM] Monitor reload incomplete, previous settings kept: %v\n"+ColorReset, err)
+	}
+	return err
+}
+
+// reloadOnHangup reloads the monitor on every SIGHUP until ctx is done
+func reloadOnHangup(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			reloadMonitor("SIGHUP")
+		}
+	}
+}
+
+// handleMonitor: GET reports the attached probes, kernel features and configuration
+func handleMonitor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(monitor.Status())
+}
+
+// handleMonitorReload: POST re-reads rules, trusted binaries and probe settings
+func handleMonitorReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	if err := reloadMonitor("requested by " + clientOf(r)); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	st := monitor.Status()
+	w.Write([]byte(fmt.Sprintf("Monitor reloaded: %d rules (%s), scope %s", st.Rules, st.RulesVersion, st.Scope)))
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/platform"
+	"github.com/Debasish-87/aegis-v/internal/security"
+)
+
+// handlePolicyExceptions: GET lists (?all=1 includes expired), POST creates, DELETE ?id= revokes
+func handlePolicyExceptions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		exceptions, err := platform.ListExceptions(r.URL.Query().Get("all") == "1")
+		if err != nil {
+			http.Error(w, "DB Error", 500)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(exceptions)
+
+	case http.MethodPost:
+		var e platform.PolicyException
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			http.Error(w, "Invalid Payload", 400)
+			return
+		}
+		if err := security.ValidateException(e); err != nil {
+			http.Error(w, "Invalid exception: "+err.Error(), 400)
+			return
+		}
+		id, err := platform.CreateException(e)
+		if err != nil {
+			http.Error(w, "DB Error", 500)
+			return
+		}
+		e.ID = id
+
+		fmt.Printf(ColorYellow+"[POLICY] 📝 Exception #%d granted: %s may bypass %s until %s (owner: %s)\n"+ColorReset,
+			id, e.Service, e.Rule, e.ExpiresAt.Format(time.RFC3339), e.Owner)
+		platform.DB.Exec("INSERT INTO security_alerts (service, alert_type, message) VALUES (?, ?, ?)",
+			e.Service, "POLICY_EXCEPTION_GRANTED", fmt.Sprintf("#%d %s by %s: %s", id, e.Rule, e.Owner, e.Reason))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(e)
+
+	case http.MethodDelete:
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Missing id", 400)
+			return
+		}
+		if err := platform.RevokeException(id); err != nil {
+			http.Error(w, err.Error(), 404)
+			return
+		}
+		fmt.Printf(ColorYellow+"[POLICY] Exception #%d revoked.\n"+ColorReset, id)
+		w.Write([]byte(fmt.Sprintf("Exception #%d revoked", id)))
+
+	default:
+		http.Error(w, "Method not allowed", 405)
+	}
+}
+
+// recordExceptionUse flags an admission that relied on an exception
+func recordExceptionUse(service string, findings []security.Finding) {
+	for _, f := range findings {
+		if f.Action != security.ActionExcepted {
+			continue
+		}
+		fmt.Printf(ColorYellow+"[GATEKEEPER] 🎫 %s: %s\n"+ColorReset, f.Rule, f.Detail)
+		platform.DB.Exec("INSERT INTO security_alerts (service, alert_type, message) VALUES (?, ?, ?)",
+			service, "POLICY_EXCEPTION_USED", fmt.Sprintf("[%s] %s", f.Rule, f.Detail))
+	}
+}
+
+// startExceptionExpiryWatch announces each exception once before it expires
+func startExceptionExpiryWatch() {
+	for {
+		expiring, err := platform.ExpiringExceptions(security.ExceptionExpiryWarning)
+		if err == nil {
+			for _, e := range expiring {
+				msg := fmt.Sprintf("Exception #%d (%s, owner %s) expires at %s", e.ID, e.Rule, e.Owner, e.ExpiresAt.Format(time.RFC3339))
+				fmt.Printf(ColorYellow+"[POLICY] ⏳ %s for service '%s'.\n"+ColorReset, msg, e.Service)
+				platform.DB.Exec("INSERT INTO security_alerts (service, alert_type, message) VALUES (?, ?, ?)",
+					e.Service, "POLICY_EXCEPTION_EXPIRING", msg)
+				platform.MarkExceptionWarned(e.ID)
+			}
+		}
+		time.Sleep(time.Hour)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/platform"
+	"github.com/Debasish-87/aegis-v/internal/security"
+)
+
+// handleProfiles: GET lists behaviour profiles (?service= filters), PUT replaces the
+// learned lists of an existing profile (operator edits)
+func handleProfiles(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		list, err := platform.ListProfiles(r.URL.Query().Get("service"))
+		if err != nil {
+			http.Error(w, "DB Error", 500)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+
+	case http.MethodPut:
+		var p platform.BehaviourProfile
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			http.Error(w, "Invalid Payload", 400)
+			return
+		}
+		err := security.SyncProfiles(p.Service, func() error {
+			existing, err := platform.LoadProfile(p.Service, p.ImageID)
+			if err != nil {
+				return err
+			}
+			if existing == nil {
+				return fmt.Errorf("no profile for %s (image %s)", p.Service, p.ImageID)
+			}
+			p.Image = existing.Image
+			return platform.SaveProfile(p)
+		})
+		if err != nil {
+			http.Error(w, err.Error(), 404)
+			return
+		}
+		fmt.Printf(ColorYellow+"[PROFILE] ✏️ Behaviour profile of %s (%s) edited by %s\n"+ColorReset, p.Service, p.ImageID, clientOf(r))
+		platform.DB.Exec("INSERT INTO security_alerts (service, alert_type, message) VALUES (?, ?, ?)",
+			p.Service, "PROFILE_EDITED", fmt.Sprintf("image %s edited by %s", p.ImageID, clientOf(r)))
+		w.Write([]byte("Profile saved"))
+
+	default:
+		http.Error(w, "Method not allowed", 405)
+	}
+}
+
+// handleProfileLearn: POST ?service=&for=2h (re)starts the learning window
+func handleProfileLearn(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	service := r.URL.Query().Get("service")
+	window, err := security.ParseLearnWindow(r.URL.Query().Get("for"))
+	if err != nil || window == 0 {
+		http.Error(w, "Invalid window: use for=<duration> between 1m and 720h", 400)
+		return
+	}
+	until := time.Now().Add(window)
+	if err := security.SyncProfiles(service, func() error { return platform.SetLearnUntil(service, until) }); err != nil {
+		http.Error(w, err.Error(), 404)
+		return
+	}
+	fmt.Printf(ColorPurple+"[PROFILE] 🧬 Learning behaviour of %s until %s\n"+ColorReset, service, until.Format(time.RFC3339))
+	w.Write([]byte(fmt.Sprintf("Learning %s until %s", service, until.Format(time.RFC3339))))
+}
+
+// handleProfilePromote: POST ?service= ends learning now and enforces the learned profiles
+func handleProfilePromote(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	service := r.URL.Query().Get("service")
+	err := security.SyncProfiles(service, func() error {
+		list, err := platform.ListProfiles(service)
+		if err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			return fmt.Errorf("nothing learned for %s yet", service)
+		}
+		return platform.SetLearnUntil(service, time.Now())
+	})
+	if err != nil {
+		http.Error(w, err.Error(), 404)
+		return
+	}
+	fmt.Printf(ColorGreen+"[PROFILE] 🔒 Behaviour profile of %s promoted to enforce\n"+ColorReset, service)
+	platform.DB.Exec("INSERT INTO security_alerts (service, alert_type, message) VALUES (?, ?, ?)",
+		service, "PROFILE_ENFORCED", "promoted by "+clientOf(r))
+	w.Write([]byte(fmt.Sprintf("%s: profile enforced", service)))
+}
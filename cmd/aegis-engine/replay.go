@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Debasish-87/aegis-v/internal/platform"
+	"github.com/Debasish-87/aegis-v/internal/rules"
+	"github.com/Debasish-87/aegis-v/internal/security"
+)
+
+// runReplay is `aegis-engine replay [--rules <dir>] <recording>`: the recorded events
+// go through the runtime pipeline without probes, database or Docker, so it runs
+// unprivileged. Record with AEGIS_RECORD_FILE=<path> on the live engine; replaying
+// with a different rules directory shows what a rule change would have done.
+func runReplay(args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	rulesDir := fs.String("rules", platform.EnvString("AEGIS_RULES_DIR", "rules"), "detection rules directory")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: aegis-engine replay [--rules <dir>] <recording>")
+		return 2
+	}
+	if err := rules.Init(*rulesDir); err != nil {
+		fmt.Fprintf(os.Stderr, ColorRed+"[REPLAY] %v"+ColorReset+"\n", err)
+		return 1
+	}
+	recording := fs.Arg(0)
+	fmt.Println(ColorBlue + "[REPLAY] " + recording + " (responses stubbed, nothing is stored)" + ColorReset)
+	sum, err := security.Replay(recording)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, ColorRed+"[REPLAY] %v"+ColorReset+"\n", err)
+		return 1
+	}
+	fmt.Println(sum)
+	return 0
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Debasish-87/aegis-v/internal/orchestrator"
+	"github.com/Debasish-87/aegis-v/internal/security"
+	"github.com/docker/docker/api/types"
+)
+
+// ValidateRequest accepts either a single spec or a bundle ("services: [...]")
+type ValidateRequest struct {
+	DeployRequest
+	Services []DeployRequest `json:"services"`
+}
+
+type ValidationResult struct {
+	Name     string             `json:"name"`
+	Image    string             `json:"image"`
+	Allowed  bool               `json:"allowed"`
+	Findings []security.Finding `json:"findings"`
+	Notes    []string           `json:"notes,omitempty"`
+}
+
+type ValidationReport struct {
+	Allowed  bool               `json:"allowed"`
+	Services []ValidationResult `json:"services"`
+}
+
+// handleValidate is the admission dry-run: every Gatekeeper check, nothing provisioned
+func handleValidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+
+	var req ValidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid Payload", 400)
+		return
+	}
+
+	specs := req.Services
+	if len(specs) == 0 {
+		specs = []DeployRequest{req.DeployRequest}
+	}
+
+	gatekeeper := security.NewGatekeeper()
+	report := ValidationReport{Allowed: true}
+	for _, spec := range specs {
+		level := security.NormalizeLevel(spec.SecurityLevel)
+		var local *types.ImageInspect
+		if spec.Image != "" {
+			if img, err := orchestrator.InspectLocalImage(spec.Image); err == nil {
+				local = &img
+			}
+		}
+		findings, notes := gatekeeper.DryRun(spec.Name, spec.Image, level, local, orchestrator.ExportImage)
+		if err := security.ValidateLevel(spec.SecurityLevel); err != nil {
+			findings = append(findings, security.Finding{Rule: security.RuleSpecInvalid, Severity: "CRITICAL", Detail: err.Error(), Action: security.ActionBlock})
+		}
+		if err := security.ValidateEgress(spec.Egress); err != nil {
+			findings = append(findings, security.Finding{Rule: security.RuleSpecInvalid, Severity: "CRITICAL", Detail: err.Error(), Action: security.ActionBlock})
+		}
+		if err := security.ValidateDenyExec(spec.DenyExec); err != nil {
+			findings = append(findings, security.Finding{Rule: security.RuleSpecInvalid, Severity: "CRITICAL", Detail: err.Error(), Action: security.ActionBlock})
+		}
+		if err := security.ValidateSpawn(spec.Spawn); err != nil {
+			findings = append(findings, security.Finding{Rule: security.RuleSpecInvalid, Severity: "CRITICAL", Detail: err.Error(), Action: security.ActionBlock})
+		}
+		if _, err := security.ParseLearnWindow(spec.Learn); err != nil {
+			findings = append(findings, security.Finding{Rule: security.RuleSpecInvalid, Severity: "CRITICAL", Detail: err.Error(), Action: security.ActionBlock})
+		}
+		result := ValidationResult{
+			Name:     spec.Name,
+			Image:    spec.Image,
+			Allowed:  !security.Blocked(findings),
+			Findings: findings,
+			Notes:    notes,
+		}
+		if !result.Allowed {
+			report.Allowed = false
+		}
+		report.Services = append(report.Services, result)
+	}
+
+	fmt.Printf(ColorBlue+"[GATEKEEPER] 🧪 Dry-run validation of %d spec(s): allowed=%v\n"+ColorReset, len(specs), report.Allowed)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -60,6 +60,83 @@ func (a *Advisor) GetVerdict(cmd string, identity string, source string) string
 	return fmt.Sprintf("%s: %s", res.Severity, res.RootCause)
 }
 
+// miningPorts are the usual stratum mining pool ports
+var miningPorts = map[uint16]bool{3333: true, 4444: true, 5555: true, 7777: true, 9999: true, 14444: true, 45700: true}
+
+// IsMiningPort reports whether the destination port is a typical stratum pool port
+func IsMiningPort(port uint16) bool {
+	return miningPorts[port]
+}
+
+// GetNetworkVerdict: eBPF connect probe se aaye outbound connections ko classify karta hai
+func (a *Advisor) GetNetworkVerdict(cmd string, port uint16, policyViolation bool) string {
+	if IsMiningPort(port) {
+		return "CRITICAL: Crypto-mining (Stratum Protocol) Detected"
+	}
+	// A shell or netcat dialing out is a reverse shell, not just unexpected egress
+	if res := a.processIntelligence("", "", []string{cmd}); res.Severity == "CRITICAL" && policyViolation {
+		return fmt.Sprintf("CRITICAL: %s (outbound connection)", res.RootCause)
+	}
+	return "HIGH: Unexpected Egress (outside service policy)"
+}
+
+// sensitiveFiles maps path fragments to the threat they indicate (first match wins)
+var sensitiveFiles = []struct{ fragment, threat string }{
+	{"docker.sock", "Container Escape via Docker Socket"},
+	{"shadow", "Credential Access (Etc/Shadow) Attempt"},
+	{"sudoers", "Privilege Escalation via Sudoers"},
+	{"serviceaccount", "Service Account Token Theft"},
+	{".ssh", "SSH Key Access"},
+	{"ssh_host_", "SSH Host Key Access"},
+}
+
+// GetFileVerdict: eBPF openat probe se aaye sensitive file access ko classify karta hai
+func (a *Advisor) GetFileVerdict(cmd string, path string, write bool) string {
+	threat := "Sensitive File Access"
+	for _, f := range sensitiveFiles {
+		if strings.Contains(path, f.fragment) {
+			threat = f.threat
+			break
+		}
+	}
+
+	// Writes and the docker socket (full host control) are always critical
+	if write {
+		return fmt.Sprintf("CRITICAL: %s (write)", threat)
+	}
+	if strings.Contains(path, "docker.sock") {
+		return "CRITICAL: " + threat
+	}
+	return "HIGH: " + threat
+}
+
+// rootEquivalentCaps are capabilities that amount to root (or a container escape) on their own
+var rootEquivalentCaps = map[string]bool{
+	"CAP_SYS_ADMIN": true, "CAP_SYS_MODULE": true, "CAP_SYS_PTRACE": true, "CAP_SYS_RAWIO": true,
+	"CAP_DAC_READ_SEARCH": true, "CAP_DAC_OVERRIDE": true, "CAP_SETUID": true, "CAP_SETGID": true,
+	"CAP_BPF": true,
+}
+
+// ClassifyEscalation grades a credential change. Becoming root or gaining a
+// root-equivalent capability is CRITICAL; other capability gains are MEDIUM.
+func ClassifyEscalation(toRoot bool, gained []string) (severity, threat string) {
+	if toRoot {
+		return "CRITICAL", "Privilege Escalation to root"
+	}
+	for _, c := range gained {
+		if rootEquivalentCaps[c] {
+			return "CRITICAL", fmt.Sprintf("Privilege Escalation via %s", c)
+		}
+	}
+	return "MEDIUM", fmt.Sprintf("Capability Gained (%s)", strings.Join(gained, ", "))
+}
+
+// GetEscalationVerdict: eBPF commit_creds probe se aaye privilege escalation ko classify karta hai
+func (a *Advisor) GetEscalationVerdict(toRoot bool, gained []string) string {
+	severity, threat := ClassifyEscalation(toRoot, gained)
+	return fmt.Sprintf("%s: %s", severity, threat)
+}
+
 func (a *Advisor) processIntelligence(serviceName string, lastError string, alerts []string) AnalysisResult {
 
 	// 1. PHASE: CYBER-THREAT CORRELATION (Security Vector)
@@ -4,21 +4,41 @@ import (
 	"encoding/json"
 	"net/http"
 	"github.com/Debasish-87/aegis-v/internal/platform"
+	"github.com/Debasish-87/aegis-v/internal/proctree"
 )
 
 type Detection struct {
-	ID        int    `json:"id"`
-	Command   string `json:"command"`
-	Risk      string `json:"risk"`
-	Identity  string `json:"identity"`
-	Source    string `json:"source"`
-	PID       int    `json:"pid"`
-	Timestamp string `json:"timestamp"`
+	ID            int      `json:"id"`
+	Command       string   `json:"command"`
+	Risk          string   `json:"risk"`
+	Identity      string   `json:"identity"`
+	Source        string   `json:"source"`
+	PID           int      `json:"pid"`
+	Timestamp     string   `json:"timestamp"`
+	Filename      string   `json:"filename"`
+	Argv          []string `json:"argv"`
+	ArgvTruncated bool     `json:"argv_truncated"`
+	Cwd           string   `json:"cwd"`
+	DetectionType string   `json:"detection_type"`
+	DestIP        string   `json:"dest_ip"`
+	DestPort      int      `json:"dest_port"`
+	Protocol      string   `json:"protocol"`
+	OpenFlags     int      `json:"open_flags"`
+	OldUid        int      `json:"old_uid"`
+	NewUid        int      `json:"new_uid"`
+	OldEuid       int      `json:"old_euid"`
+	NewEuid       int      `json:"new_euid"`
+	CapsGained    []string `json:"caps_gained"`
+	Response      string   `json:"response"`
+	// Ancestry is the full parent chain at detection time, nearest first
+	Ancestry []proctree.Process `json:"ancestry"`
+	// DropsInWindow: the kernel lost events around this detection, its context may be incomplete
+	DropsInWindow bool `json:"drops_in_window"`
 }
 
 // GetAlertsHandler fetches all security detections from DB
 func GetAlertsHandler(w http.ResponseWriter, r *http.Request) {
-	rows, err := platform.DB.Query("SELECT id, command, risk, identity, source, pid, timestamp FROM detections ORDER BY id DESC LIMIT 50")
+	rows, err := platform.DB.Query("SELECT id, command, risk, identity, source, pid, timestamp, COALESCE(filename, ''), COALESCE(argv, '[]'), COALESCE(argv_truncated, 0), COALESCE(cwd, ''), COALESCE(detection_type, 'EXEC'), COALESCE(dest_ip, ''), COALESCE(dest_port, 0), COALESCE(protocol, ''), COALESCE(open_flags, 0), COALESCE(old_uid, 0), COALESCE(new_uid, 0), COALESCE(old_euid, 0), COALESCE(new_euid, 0), COALESCE(caps_gained, '[]'), COALESCE(response, ''), COALESCE(ancestry, '[]'), COALESCE(drops_in_window, 0) FROM detections ORDER BY id DESC LIMIT 50")
 	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
@@ -28,9 +48,13 @@ func GetAlertsHandler(w http.ResponseWriter, r *http.Request) {
 	var alerts []Detection
 	for rows.Next() {
 		var a Detection
-		if err := rows.Scan(&a.ID, &a.Command, &a.Risk, &a.Identity, &a.Source, &a.PID, &a.Timestamp); err != nil {
+		var argv, caps, ancestry string
+		if err := rows.Scan(&a.ID, &a.Command, &a.Risk, &a.Identity, &a.Source, &a.PID, &a.Timestamp, &a.Filename, &argv, &a.ArgvTruncated, &a.Cwd, &a.DetectionType, &a.DestIP, &a.DestPort, &a.Protocol, &a.OpenFlags, &a.OldUid, &a.NewUid, &a.OldEuid, &a.NewEuid, &caps, &a.Response, &ancestry, &a.DropsInWindow); err != nil {
 			continue
 		}
+		json.Unmarshal([]byte(argv), &a.Argv)
+		json.Unmarshal([]byte(caps), &a.CapsGained)
+		json.Unmarshal([]byte(ancestry), &a.Ancestry)
 		alerts = append(alerts, a)
 	}
 
@@ -3,24 +3,11 @@ package guardian
 import (
 	"fmt"
 	"os"
-	"strings"
 	"syscall"
-)
 
-// List of process names and substrings that should NEVER be killed
-var protectedProcesses = []string{
-	"aegis-engine",
-	"aegis-ctl",
-	"aegis-viz",
-	"systemd",
-	"dockerd",
-	"containerd",
-	"fuser", // 👈 Add this
-    "lsof",
-	"sshd",
-	"go",
-	"sudo",
-}
+	"github.com/Debasish-87/aegis-v/internal/proctree"
+	"github.com/Debasish-87/aegis-v/internal/trust"
+)
 
 // KillProcess sends a SIGKILL signal with Parent-Aware Safety logic
 func KillProcess(pid int, comm string) error {
@@ -36,18 +23,16 @@ func KillProcess(pid int, comm string) error {
 		return fmt.Errorf("self-protection: prevented engine suicide")
 	}
 
-	// 3. Command Whitelist Check: (Case-insensitive)
-	cleanComm := strings.ToLower(comm)
-	for _, protected := range protectedProcesses {
-		if strings.Contains(cleanComm, protected) {
-			return fmt.Errorf("policy protection: %s is whitelisted", comm)
-		}
+	// 3. Protected binaries: decided on the executable's path + hash, never on comm
+	// (comm sirf display ke liye hai; koi bhi process apna naam "sshd" rakh sakta hai)
+	if e := trust.ProcessEntry(uint32(pid)); e != nil && e.Protected {
+		return fmt.Errorf("policy protection: %s (PID %d) runs protected binary %s", comm, pid, e.Name)
 	}
 
 	// 4. PPID Context Check (The Fix for 'Killed' status)
 	// Agar is process ka Parent hamara Engine hai, toh ye valid request ho sakti hai
-	ppid := getParentPid(pid)
-	if ppid == selfPid {
+	ppid, _ := proctree.Parent(uint32(pid))
+	if int(ppid) == selfPid {
 		return fmt.Errorf("context check: skipping child process of engine")
 	}
 
@@ -65,16 +50,4 @@ func KillProcess(pid int, comm string) error {
 	fmt.Printf("\033[32m   └─ [REASON]: Unauthorized sensitive execution in shielded zone.\033[0m\n")
 
 	return nil
-}
-
-// Helper function to find Parent PID from /proc
-func getParentPid(pid int) int {
-	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
-	if err != nil {
-		return 0
-	}
-	// /proc/[pid]/stat format: pid (comm) state ppid ...
-	var ppid int
-	fmt.Sscanf(string(data), "%d %*s %*s %d", &ppid, &ppid)
-	return ppid
 }
\ No newline at end of file
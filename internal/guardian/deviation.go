@@ -0,0 +1,51 @@
+package guardian
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/platform"
+	"github.com/Debasish-87/aegis-v/internal/proctree"
+	"github.com/Debasish-87/aegis-v/internal/telemetry"
+)
+
+// DeviationAlert is one observation the enforced behaviour profile has never seen
+type DeviationAlert struct {
+	Command  string
+	PID      int
+	Source   string
+	Service  string
+	Identity string
+	Kind     string // binary, spawn, user or destination
+	Value    string // e.g. /usr/bin/curl, nginx > sh, 0, 10.0.0.5:5432/tcp
+	Ancestry []proctree.Process
+	Context  platform.DetectionContext
+}
+
+// LogDeviation terminal pe dikhayega aur DB mein save karega
+func LogDeviation(alert DeviationAlert) {
+	risk := "MEDIUM"
+	if alert.Kind == "user" && alert.Value == "0" {
+		risk = "HIGH"
+	}
+	verdict := fmt.Sprintf("%s: %s %s not in the learned profile of %s", risk, alert.Kind, alert.Value, alert.Service)
+
+	fmt.Printf("\n[EBPF ALERT] 🧬 Behaviour Deviation Detected!\n")
+	fmt.Printf("   ├─ Command:    %s\n", alert.Command)
+	fmt.Printf("   ├─ Deviation:  new %s %s\n", alert.Kind, alert.Value)
+	fmt.Printf("   ├─ Source:     %s\n", alert.Source)
+	fmt.Printf("   ├─ Identity:   %s\n", alert.Identity)
+	fmt.Printf("   ├─ Lineage:    %s\n", proctree.FormatLineage(alert.Ancestry))
+	fmt.Printf("   └─ PID:        %d\n", alert.PID)
+	fmt.Println("--------------------------------------------")
+
+	if globalDB != nil {
+		columns := "command, risk, source, identity, pid, timestamp, detection_type, deviation, ancestry, drops_in_window"
+		err := saveDetection(alert.Context, columns, alert.Command, verdict, alert.Source, alert.Identity, alert.PID, time.Now().Format(time.RFC3339Nano),
+			DetectionDeviation, alert.Kind+" "+alert.Value, ancestryJSON(alert.Ancestry), telemetry.DropsNear(time.Now()))
+		if err != nil {
+			log.Printf("[DB ERROR] Failed to save deviation: %v", err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package guardian
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/platform"
+	"github.com/Debasish-87/aegis-v/internal/proctree"
+	"github.com/Debasish-87/aegis-v/internal/telemetry"
+)
+
+// DriftAlert is a container exec of a file that did not ship with the image
+type DriftAlert struct {
+	Command  string
+	Filename string // path inside the container
+	SHA256   string
+	Kind     string // added or modified
+	Deleted  bool   // removed again after the exec
+	PID      int
+	Source   string
+	Identity string
+	Ancestry []proctree.Process
+	Context  platform.DetectionContext
+}
+
+// LogDrift terminal pe dikhayega aur DB mein save karega
+func LogDrift(alert DriftAlert) {
+	what := "added to the container after start"
+	if alert.Kind == "modified" {
+		what = "differs from the image"
+	}
+	if alert.Deleted {
+		what += ", deleted after exec"
+	}
+	verdict := fmt.Sprintf("HIGH: drifted executable %s (%s)", alert.Filename, what)
+
+	fmt.Printf("\n[EBPF ALERT] 🧪 Drifted Executable Detected!\n")
+	fmt.Printf("   ├─ Command:    %s\n", alert.Command)
+	fmt.Printf("   ├─ Exe:        %s (%s)\n", alert.Filename, what)
+	fmt.Printf("   ├─ SHA-256:    %s\n", alert.SHA256)
+	fmt.Printf("   ├─ Source:     %s\n", alert.Source)
+	fmt.Printf("   ├─ Identity:   %s\n", alert.Identity)
+	fmt.Printf("   ├─ Lineage:    %s\n", proctree.FormatLineage(alert.Ancestry))
+	fmt.Printf("   └─ PID:        %d\n", alert.PID)
+	fmt.Println("--------------------------------------------")
+
+	if globalDB != nil {
+		columns := "command, risk, source, identity, pid, timestamp, detection_type, filename, sha256, ancestry, drops_in_window"
+		err := saveDetection(alert.Context, columns, alert.Command, verdict, alert.Source, alert.Identity, alert.PID, time.Now().Format(time.RFC3339Nano),
+			DetectionDrift, alert.Filename, alert.SHA256, ancestryJSON(alert.Ancestry), telemetry.DropsNear(time.Now()))
+		if err != nil {
+			log.Printf("[DB ERROR] Failed to save drift: %v", err)
+		}
+	}
+}
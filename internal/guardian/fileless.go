@@ -0,0 +1,66 @@
+package guardian
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/platform"
+	"github.com/Debasish-87/aegis-v/internal/proctree"
+	"github.com/Debasish-87/aegis-v/internal/telemetry"
+)
+
+// FilelessAlert is an exec of a file that cannot be inspected on disk afterwards
+type FilelessAlert struct {
+	Command  string
+	Filename string   // as invoked, e.g. /dev/fd/3
+	Name     string   // executed file, e.g. memfd:payload
+	Reasons  []string // memfd, unlinked, tmpfs, tmpdir
+	SHA256   string   // empty when the process was gone before it could be hashed
+	Size     int64
+	Sample   string // preserved copy in the forensics directory
+	PID      int
+	Source   string
+	Identity string
+	Ancestry []proctree.Process
+	Risk     string
+	Rule     string
+	Mitre    string
+	Response string // KILLED or KILL_FAILED (empty: alert only)
+	Context  platform.DetectionContext
+}
+
+// LogFileless terminal pe dikhayega aur DB mein save karega
+func LogFileless(alert FilelessAlert) {
+	reasons := strings.Join(alert.Reasons, ",")
+	verdict := fmt.Sprintf("%s: fileless execution of %s (%s)", alert.Risk, alert.Name, reasons)
+
+	fmt.Printf("\n[EBPF ALERT] 👻 Fileless Execution Detected!\n")
+	fmt.Printf("   ├─ Command:    %s\n", alert.Command)
+	fmt.Printf("   ├─ Exe:        %s → %s (%s)\n", alert.Filename, alert.Name, reasons)
+	if alert.SHA256 != "" {
+		fmt.Printf("   ├─ SHA-256:    %s (%d bytes)\n", alert.SHA256, alert.Size)
+	}
+	if alert.Sample != "" {
+		fmt.Printf("   ├─ Sample:     %s\n", alert.Sample)
+	}
+	fmt.Printf("   ├─ Rule:       %s (%s)\n", alert.Rule, alert.Mitre)
+	fmt.Printf("   ├─ Source:     %s\n", alert.Source)
+	fmt.Printf("   ├─ Identity:   %s\n", alert.Identity)
+	fmt.Printf("   ├─ Lineage:    %s\n", proctree.FormatLineage(alert.Ancestry))
+	fmt.Printf("   └─ PID:        %d\n", alert.PID)
+	if alert.Response == "KILLED" {
+		fmt.Printf("   └─ [DEFENDER]: PID %d Neutralized. 🛡️\n", alert.PID)
+	}
+	fmt.Println("--------------------------------------------")
+
+	if globalDB != nil {
+		columns := "command, risk, source, identity, pid, timestamp, detection_type, filename, sha256, fileless, sample, ancestry, drops_in_window, rule_id, mitre, response"
+		err := saveDetection(alert.Context, columns, alert.Command, verdict, alert.Source, alert.Identity, alert.PID, time.Now().Format(time.RFC3339Nano),
+			DetectionFileless, alert.Name, alert.SHA256, reasons, alert.Sample, ancestryJSON(alert.Ancestry), telemetry.DropsNear(time.Now()), alert.Rule, alert.Mitre, alert.Response)
+		if err != nil {
+			log.Printf("[DB ERROR] Failed to save fileless execution: %v", err)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package guardian
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/platform"
+	"github.com/Debasish-87/aegis-v/internal/proctree"
+	"github.com/Debasish-87/aegis-v/internal/telemetry"
+)
+
+// FileAlert is an open of a sensitive path inside a container
+type FileAlert struct {
+	Command  string
+	PID      int
+	Source   string
+	Identity string
+	Path     string
+	Flags    int
+	Write    bool
+	Ancestry []proctree.Process
+	Rule     string // set when a detection rule matched the open
+	Mitre    string
+	Response string // KILLED or KILL_FAILED (empty: alert only)
+	Context  platform.DetectionContext
+}
+
+// LogFileAccess terminal pe dikhayega aur DB mein save karega
+func LogFileAccess(alert FileAlert) {
+	verdict := advisor.GetFileVerdict(alert.Command, alert.Path, alert.Write)
+	mode := "read"
+	if alert.Write {
+		mode = "write"
+	}
+
+	fmt.Printf("\n[EBPF ALERT] 🔑 Sensitive File Access Detected!\n")
+	fmt.Printf("   ├─ Command:    %s\n", alert.Command)
+	fmt.Printf("   ├─ Path:       %s (%s, flags 0x%x)\n", alert.Path, mode, alert.Flags)
+	fmt.Printf("   ├─ AI Verdict: %s\n", verdict)
+	fmt.Printf("   ├─ Source:     %s\n", alert.Source)
+	fmt.Printf("   ├─ Identity:   %s\n", alert.Identity)
+	fmt.Printf("   ├─ Lineage:    %s\n", proctree.FormatLineage(alert.Ancestry))
+	fmt.Printf("   └─ PID:        %d\n", alert.PID)
+	fmt.Println("--------------------------------------------")
+
+	if globalDB != nil {
+		columns := "command, risk, source, identity, pid, timestamp, detection_type, filename, open_flags, ancestry, drops_in_window, rule_id, mitre, response"
+		err := saveDetection(alert.Context, columns, alert.Command, verdict, alert.Source, alert.Identity, alert.PID, time.Now().Format(time.RFC3339Nano),
+			DetectionFileAccess, alert.Path, alert.Flags, ancestryJSON(alert.Ancestry), telemetry.DropsNear(time.Now()), alert.Rule, alert.Mitre, alert.Response)
+		if err != nil {
+			log.Printf("[DB ERROR] Failed to save file access detection: %v", err)
+		}
+	}
+}
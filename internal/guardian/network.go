@@ -0,0 +1,53 @@
+package guardian
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/platform"
+	"github.com/Debasish-87/aegis-v/internal/proctree"
+	"github.com/Debasish-87/aegis-v/internal/telemetry"
+)
+
+// EgressAlert is an outbound connection from a container that the runtime policy flagged
+type EgressAlert struct {
+	Command         string
+	PID             int
+	Source          string
+	Identity        string
+	DestIP          string
+	DestPort        int
+	Protocol        string
+	PolicyViolation bool // false: no policy declared, flagged on heuristics only
+	Ancestry        []proctree.Process
+	Rule            string // set when a detection rule flagged the connection
+	Mitre           string
+	Response        string // KILLED or KILL_FAILED (empty: alert only)
+	Context         platform.DetectionContext
+}
+
+// LogEgress terminal pe dikhayega aur DB mein save karega
+func LogEgress(alert EgressAlert) {
+	verdict := advisor.GetNetworkVerdict(alert.Command, uint16(alert.DestPort), alert.PolicyViolation)
+	dest := fmt.Sprintf("%s:%d/%s", alert.DestIP, alert.DestPort, alert.Protocol)
+
+	fmt.Printf("\n[EBPF ALERT] 🌐 Unexpected Egress Detected!\n")
+	fmt.Printf("   ├─ Command:    %s\n", alert.Command)
+	fmt.Printf("   ├─ Dest:       %s\n", dest)
+	fmt.Printf("   ├─ AI Verdict: %s\n", verdict)
+	fmt.Printf("   ├─ Source:     %s\n", alert.Source)
+	fmt.Printf("   ├─ Identity:   %s\n", alert.Identity)
+	fmt.Printf("   ├─ Lineage:    %s\n", proctree.FormatLineage(alert.Ancestry))
+	fmt.Printf("   └─ PID:        %d\n", alert.PID)
+	fmt.Println("--------------------------------------------")
+
+	if globalDB != nil {
+		columns := "command, risk, source, identity, pid, timestamp, detection_type, dest_ip, dest_port, protocol, ancestry, drops_in_window, rule_id, mitre, response"
+		err := saveDetection(alert.Context, columns, alert.Command, verdict, alert.Source, alert.Identity, alert.PID, time.Now().Format(time.RFC3339Nano),
+			DetectionEgress, alert.DestIP, alert.DestPort, alert.Protocol, ancestryJSON(alert.Ancestry), telemetry.DropsNear(time.Now()), alert.Rule, alert.Mitre, alert.Response)
+		if err != nil {
+			log.Printf("[DB ERROR] Failed to save egress detection: %v", err)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package guardian
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/platform"
+	"github.com/Debasish-87/aegis-v/internal/proctree"
+	"github.com/Debasish-87/aegis-v/internal/telemetry"
+)
+
+// PrivEscAlert is a process inside a container that raised its privileges
+type PrivEscAlert struct {
+	Command    string
+	PID        int
+	Source     string
+	OldUid     int
+	NewUid     int
+	OldEuid    int
+	NewEuid    int
+	ToRoot     bool
+	GainedCaps []string
+	Response   string // KILLED, ALERTED, or KILL_FAILED
+	Ancestry   []proctree.Process
+	Rule       string // set when a detection rule matched the escalation
+	Mitre      string
+	Context    platform.DetectionContext
+}
+
+// LogPrivEscalation terminal pe dikhayega aur DB mein save karega
+func LogPrivEscalation(alert PrivEscAlert) {
+	verdict := advisor.GetEscalationVerdict(alert.ToRoot, alert.GainedCaps)
+	caps := "-"
+	if len(alert.GainedCaps) > 0 {
+		caps = "+" + strings.Join(alert.GainedCaps, " +")
+	}
+
+	fmt.Printf("\n[EBPF ALERT] 🔓 Privilege Escalation Detected!\n")
+	fmt.Printf("   ├─ Command:    %s\n", alert.Command)
+	fmt.Printf("   ├─ Uid:        %d → %d (euid %d → %d)\n", alert.OldUid, alert.NewUid, alert.OldEuid, alert.NewEuid)
+	fmt.Printf("   ├─ Caps:       %s\n", caps)
+	fmt.Printf("   ├─ AI Verdict: %s\n", verdict)
+	fmt.Printf("   ├─ Source:     %s\n", alert.Source)
+	fmt.Printf("   ├─ Response:   %s\n", alert.Response)
+	fmt.Printf("   ├─ Lineage:    %s\n", proctree.FormatLineage(alert.Ancestry))
+	fmt.Printf("   └─ PID:        %d\n", alert.PID)
+	fmt.Println("--------------------------------------------")
+
+	if globalDB != nil {
+		gained, _ := json.Marshal(alert.GainedCaps)
+		identity := fmt.Sprintf("uid %d → %d", alert.OldEuid, alert.NewEuid)
+		columns := "command, risk, source, identity, pid, timestamp, detection_type, old_uid, new_uid, old_euid, new_euid, caps_gained, response, ancestry, drops_in_window, rule_id, mitre"
+		err := saveDetection(alert.Context, columns, alert.Command, verdict, alert.Source, identity, alert.PID, time.Now().Format(time.RFC3339Nano),
+			DetectionPrivEsc, alert.OldUid, alert.NewUid, alert.OldEuid, alert.NewEuid, string(gained), alert.Response, ancestryJSON(alert.Ancestry), telemetry.DropsNear(time.Now()), alert.Rule, alert.Mitre)
+		if err != nil {
+			log.Printf("[DB ERROR] Failed to save privilege escalation: %v", err)
+		}
+	}
+}
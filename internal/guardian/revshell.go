@@ -0,0 +1,47 @@
+package guardian
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/proctree"
+	"github.com/Debasish-87/aegis-v/internal/telemetry"
+)
+
+// ReverseShellAlert is an exec whose stdin, stdout or stderr is a network socket
+type ReverseShellAlert struct {
+	ExecAlert
+	Streams    string // e.g. stdin,stdout,stderr
+	Protocol   string // tcp or udp
+	RemoteIP   string
+	RemotePort int
+}
+
+// LogReverseShell terminal pe dikhayega aur DB mein save karega
+func LogReverseShell(alert ReverseShellAlert) {
+	remote := fmt.Sprintf("%s %s:%d", alert.Protocol, alert.RemoteIP, alert.RemotePort)
+	verdict := fmt.Sprintf("%s: %s driven over the network (%s → %s)", alert.Risk, alert.Command, alert.Streams, remote)
+
+	fmt.Printf("\n[EBPF ALERT] 🐚 Reverse Shell Detected!\n")
+	fmt.Printf("   ├─ Command:    %s\n", alert.Command)
+	fmt.Printf("   ├─ Args:       %s\n", FormatArgv(alert.Argv, alert.ArgvTruncated))
+	fmt.Printf("   ├─ Stdio:      %s → %s\n", alert.Streams, remote)
+	fmt.Printf("   ├─ Source:     %s\n", alert.Source)
+	fmt.Printf("   ├─ Identity:   %s\n", alert.Identity)
+	fmt.Printf("   ├─ Lineage:    %s\n", proctree.FormatLineage(alert.Ancestry))
+	fmt.Printf("   └─ PID:        %d\n", alert.PID)
+	fmt.Println("--------------------------------------------")
+
+	if globalDB != nil {
+		argv, _ := json.Marshal(alert.Argv)
+		columns := "command, risk, source, identity, pid, timestamp, detection_type, filename, argv, argv_truncated, cwd, dest_ip, dest_port, protocol, response, ancestry, drops_in_window, rule_id, mitre"
+		err := saveDetection(alert.Context, columns, alert.Command, verdict, alert.Source, alert.Identity, alert.PID, time.Now().Format(time.RFC3339Nano),
+			DetectionReverseShell, alert.Filename, string(argv), alert.ArgvTruncated, alert.Cwd, alert.RemoteIP, alert.RemotePort, alert.Protocol,
+			alert.Response, ancestryJSON(alert.Ancestry), telemetry.DropsNear(time.Now()), alert.Rule, alert.Mitre)
+		if err != nil {
+			log.Printf("[DB ERROR] Failed to save reverse shell: %v", err)
+		}
+	}
+}
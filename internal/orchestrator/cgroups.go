@@ -0,0 +1,350 @@
+package orchestrator
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+)
+
+// Labels stamped on every container the engine provisions
+const (
+	LabelService = "aegis.service"
+	LabelManaged = "aegis.managed"
+)
+
+// cgroupGracePeriod keeps a dead container's cgroups resolvable, so events
+// that were still queued in the ring buffer are attributed correctly.
+const cgroupGracePeriod = 5 * time.Minute
+
+// ContainerInfo is what the runtime monitor needs to attribute an event
+type ContainerInfo struct {
+	ID      string
+	Name    string
+	Service string
+	Image   string
+	ImageID string
+	// ImageDigest is the repository digest when the image has one, else the image ID
+	ImageDigest string
+	Managed     bool
+	// InitPid is the host pid of the container's init process (the image's main process)
+	InitPid uint32
+	// Entrypoint is the program the image is configured to start (ENTRYPOINT, else CMD)
+	Entrypoint string
+	// overlay2 layers of the container filesystem ("" with other storage drivers)
+	UpperDir string // writable layer: everything written since the container started
+	LowerDir string // image layers, colon-separated, topmost first
+}
+
+type cgroupEntry struct {
+	info   ContainerInfo
+	goneAt time.Time
+}
+
+// cgroupCache maps cgroup v2 IDs (the cgroup directory inode, as returned by
+// bpf_get_current_cgroup_id) to containers. It is fed by Docker events.
+type cgroupCache struct {
+	mu          sync.RWMutex
+	byCgroup    map[uint64]*cgroupEntry
+	byContainer map[string][]uint64
+	changed     chan struct{} // signalled when a container is added or gone
+}
+
+var containers = &cgroupCache{
+	byCgroup:    map[uint64]*cgroupEntry{},
+	byContainer: map[string][]uint64{},
+	changed:     make(chan struct{}, 1),
+}
+
+// ContainerByCgroup resolves a kernel cgroup ID without touching /proc or Docker
+func ContainerByCgroup(id uint64) (ContainerInfo, bool) {
+	containers.mu.RLock()
+	defer containers.mu.RUnlock()
+	e, ok := containers.byCgroup[id]
+	if !ok {
+		return ContainerInfo{}, false
+	}
+	return e.info, true
+}
+
+// ContainersChanged fires (coalesced) after the set of live containers changed.
+// It has a single consumer: the kernel monitoring scope sync.
+func ContainersChanged() <-chan struct{} {
+	return containers.changed
+}
+
+// LiveContainers returns every running container with its cgroup IDs
+func LiveContainers() map[ContainerInfo][]uint64 {
+	containers.mu.RLock()
+	defer containers.mu.RUnlock()
+	out := map[ContainerInfo][]uint64{}
+	for _, ids := range containers.byContainer {
+		for _, id := range ids {
+			e := containers.byCgroup[id]
+			if e.goneAt.IsZero() {
+				out[e.info] = append(out[e.info], id)
+			}
+		}
+	}
+	return out
+}
+
+// CgroupsAvailable reports whether processes can be attributed to containers by
+// cgroup ID: the cache is only filled on a cgroup v2 hierarchy
+func CgroupsAvailable() bool {
+	return cgroupV2Root() != ""
+}
+
+// WatchContainers keeps the cgroup cache in sync with Docker until ctx is cancelled.
+// The event stream is re-opened (with a full resync) whenever it breaks.
+func WatchContainers(ctx context.Context) {
+	root := cgroupV2Root()
+	if root == "" {
+		fmt.Println("[ORCHESTRATOR] ⚠️ No cgroup v2 hierarchy found; falling back to namespace lookups.")
+		return
+	}
+
+	for ctx.Err() == nil {
+		if err := watchOnce(ctx, root); err != nil && ctx.Err() == nil {
+			fmt.Printf("[ORCHESTRATOR] Docker event stream lost: %v (retrying)\n", err)
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// LoadContainers fills the cgroup cache with the running containers once.
+// WatchContainers fills it in the background; the monitoring scope is built
+// from the cache, so load it first when the scope must cover them from the start.
+func LoadContainers(ctx context.Context) error {
+	root := cgroupV2Root()
+	if root == "" {
+		return fmt.Errorf("no cgroup v2 hierarchy")
+	}
+	cli, err := getDockerClient()
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+	return registerRunning(ctx, cli, root)
+}
+
+func watchOnce(ctx context.Context, root string) error {
+	cli, err := getDockerClient()
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	// Subscribe first so nothing started during the resync is missed
+	msgs, errs := cli.Events(ctx, types.EventsOptions{
+		Filters: filters.NewArgs(filters.Arg("type", string(events.ContainerEventType))),
+	})
+
+	if err := registerRunning(ctx, cli, root); err != nil {
+		return err
+	}
+
+	prune := time.NewTicker(time.Minute)
+	defer prune.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errs:
+			return err
+		case <-prune.C:
+			containers.prune(time.Now())
+		case msg := <-msgs:
+			switch msg.Action {
+			case "start":
+				registerContainer(ctx, cli, root, msg.Actor.ID)
+			case "die", "destroy":
+				containers.markGone(msg.Actor.ID, time.Now())
+			}
+		}
+	}
+}
+
+// registerRunning records every running container
+func registerRunning(ctx context.Context, cli *client.Client, root string) error {
+	running, err := cli.ContainerList(ctx, types.ContainerListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, c := range running {
+		registerContainer(ctx, cli, root, c.ID)
+	}
+	return nil
+}
+
+// registerContainer records every cgroup of the container (its own plus nested ones)
+func registerContainer(ctx context.Context, cli *client.Client, root, id string) {
+	inspect, err := cli.ContainerInspect(ctx, id)
+	if err != nil || inspect.State == nil || inspect.State.Pid == 0 {
+		return
+	}
+
+	rel, err := cgroupPathOf(inspect.State.Pid)
+	if err != nil {
+		return
+	}
+
+	info := ContainerInfo{
+		ID:      inspect.ID,
+		Name:    strings.TrimPrefix(inspect.Name, "/"),
+		ImageID: inspect.Image,
+		InitPid: uint32(inspect.State.Pid),
+	}
+	if inspect.Config != nil {
+		info.Image = inspect.Config.Image
+		info.Service = inspect.Config.Labels[LabelService]
+		info.Managed = inspect.Config.Labels[LabelManaged] == "true"
+		if len(inspect.Config.Entrypoint) > 0 {
+			info.Entrypoint = inspect.Config.Entrypoint[0]
+		} else if len(inspect.Config.Cmd) > 0 {
+			info.Entrypoint = inspect.Config.Cmd[0]
+		}
+	}
+	if info.Service == "" {
+		info.Service = info.Name
+	}
+	info.ImageDigest = info.ImageID
+	if img, _, err := cli.ImageInspectWithRaw(ctx, inspect.Image); err == nil && len(img.RepoDigests) > 0 {
+		info.ImageDigest = img.RepoDigests[0]
+	}
+	if inspect.GraphDriver.Name == "overlay2" {
+		info.UpperDir = inspect.GraphDriver.Data["UpperDir"]
+		info.LowerDir = inspect.GraphDriver.Data["LowerDir"]
+	}
+
+	var ids []uint64
+	_ = filepath.WalkDir(filepath.Join(root, rel), func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if ino, ok := inodeOf(p); ok {
+			ids = append(ids, ino)
+		}
+		return nil
+	})
+	containers.add(info, ids)
+}
+
+func (c *cgroupCache) add(info ContainerInfo, ids []uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range ids {
+		if _, known := c.byCgroup[id]; !known {
+			c.byContainer[info.ID] = append(c.byContainer[info.ID], id)
+		}
+		c.byCgroup[id] = &cgroupEntry{info: info}
+	}
+	c.notify()
+}
+
+func (c *cgroupCache) markGone(containerID string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range c.byContainer[containerID] {
+		if e, ok := c.byCgroup[id]; ok && e.goneAt.IsZero() {
+			e.goneAt = now
+		}
+	}
+	c.notify()
+}
+
+func (c *cgroupCache) notify() {
+	select {
+	case c.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (c *cgroupCache) prune(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for containerID, ids := range c.byContainer {
+		kept := ids[:0]
+		for _, id := range ids {
+			e := c.byCgroup[id]
+			if !e.goneAt.IsZero() && now.Sub(e.goneAt) > cgroupGracePeriod {
+				delete(c.byCgroup, id)
+				continue
+			}
+			kept = append(kept, id)
+		}
+		if len(kept) == 0 {
+			delete(c.byContainer, containerID)
+		} else {
+			c.byContainer[containerID] = kept
+		}
+	}
+}
+
+// CgroupIDOf returns the cgroup v2 ID of a running process (what the probes report)
+func CgroupIDOf(pid int) (uint64, bool) {
+	root := cgroupV2Root()
+	if root == "" {
+		return 0, false
+	}
+	rel, err := cgroupPathOf(pid)
+	if err != nil {
+		return 0, false
+	}
+	return inodeOf(filepath.Join(root, rel))
+}
+
+// cgroupV2Root finds the unified hierarchy (pure v2, or hybrid mode)
+func cgroupV2Root() string {
+	for _, root := range []string{"/sys/fs/cgroup", "/sys/fs/cgroup/unified"} {
+		if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err == nil {
+			return root
+		}
+	}
+	return ""
+}
+
+// cgroupPathOf returns the v2 cgroup path ("0::/...") of a process
+func cgroupPathOf(pid int) (string, error) {
+	f, err := os.Open(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if rel, ok := strings.CutPrefix(sc.Text(), "0::"); ok {
+			return rel, nil
+		}
+	}
+	return "", fmt.Errorf("pid %d has no cgroup v2 entry", pid)
+}
+
+// inodeOf returns the inode of a cgroup directory, which is its kernel cgroup ID
+func inodeOf(p string) (uint64, bool) {
+	st, err := os.Stat(p)
+	if err != nil {
+		return 0, false
+	}
+	sys, ok := st.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return sys.Ino, true
+}
@@ -0,0 +1,89 @@
+package orchestrator
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func newTestCache(t *testing.T) *cgroupCache {
+	t.Helper()
+	c := &cgroupCache{byCgroup: map[uint64]*cgroupEntry{}, byContainer: map[string][]uint64{}, changed: make(chan struct{}, 1)}
+	saved := containers
+	containers = c
+	t.Cleanup(func() { containers = saved })
+	return c
+}
+
+func TestCgroupCacheLifecycle(t *testing.T) {
+	c := newTestCache(t)
+	web := ContainerInfo{ID: "aaa", Name: "web-1", Service: "web", Managed: true}
+	db := ContainerInfo{ID: "bbb", Name: "db-1", Service: "db"}
+
+	c.add(web, []uint64{10, 11})
+	c.add(db, []uint64{20})
+	select {
+	case <-ContainersChanged():
+	default:
+		t.Errorf("add did not signal a change")
+	}
+
+	if info, ok := ContainerByCgroup(11); !ok || info.Service != "web" {
+		t.Errorf("cgroup 11 = %+v, %v; want web", info, ok)
+	}
+	if _, ok := ContainerByCgroup(99); ok {
+		t.Errorf("unknown cgroup resolved")
+	}
+	// Re-registering (resync after a lost event stream) does not duplicate IDs
+	c.add(web, []uint64{10, 11})
+	if ids := c.byContainer["aaa"]; len(ids) != 2 {
+		t.Errorf("web cgroups after resync = %v", ids)
+	}
+
+	now := time.Now()
+	c.markGone("aaa", now)
+	if live := LiveContainers(); len(live) != 1 || live[db] == nil {
+		t.Errorf("live after web died = %v, want db only", live)
+	}
+	// Queued events of a dead container are still attributed during the grace period
+	c.prune(now.Add(cgroupGracePeriod / 2))
+	if _, ok := ContainerByCgroup(10); !ok {
+		t.Errorf("dead container forgotten inside the grace period")
+	}
+	c.prune(now.Add(cgroupGracePeriod + time.Second))
+	if _, ok := ContainerByCgroup(10); ok {
+		t.Errorf("dead container kept after the grace period")
+	}
+	if _, ok := c.byContainer["aaa"]; ok {
+		t.Errorf("dead container still indexed")
+	}
+	if info, ok := ContainerByCgroup(20); !ok || info.Name != "db-1" {
+		t.Errorf("live container pruned")
+	}
+}
+
+func TestExtractIDFromCgroup(t *testing.T) {
+	id := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	tests := []struct {
+		content, want string
+	}{
+		{"0::/system.slice/docker-" + id + ".scope\n", id},
+		{"12:pids:/docker/" + id + "\n0::/docker/" + id + "\n", id},
+		{"0::/user.slice/user-1000.slice/session-2.scope\n", ""},
+		{"0::/docker/short\n", ""},
+	}
+	for _, tt := range tests {
+		if got := extractIDFromCgroup(tt.content); got != tt.want {
+			t.Errorf("extractIDFromCgroup(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestCgroupIDOfSelf(t *testing.T) {
+	if cgroupV2Root() == "" {
+		t.Skip("no cgroup v2 hierarchy")
+	}
+	if _, ok := CgroupIDOf(os.Getpid()); !ok {
+		t.Errorf("CgroupIDOf(self) failed on a cgroup v2 host")
+	}
+}
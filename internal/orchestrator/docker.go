@@ -2,6 +2,7 @@ package orchestrator
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -41,7 +42,8 @@ func GetAllContainers() ([]types.Container, error) {
 	return containers, nil
 }
 
-// GetContainerNameByNamespace: maps eBPF MntNS to a human-readable Docker Name
+// GetContainerNameByNamespace: maps eBPF MntNS to a human-readable Docker Name.
+// Slow path: the monitor prefers ContainerByCgroup and only falls back to this.
 func GetContainerNameByNamespace(mntNs uint32) string {
 	// Bypass host/system namespaces
 	if mntNs == 0 || mntNs == 4026531832 || mntNs == 4026531840 {
@@ -122,8 +124,15 @@ func resolveContainerName(shortID string) string {
 	return strings.TrimPrefix(inspect.Name, "/")
 }
 
+// ErrImageRejected is returned by ProvisionContainer when the post-pull admission refuses the image
+var ErrImageRejected = errors.New("image rejected by post-pull admission")
+
+// ImageAdmission inspects a freshly pulled image before any container exists.
+// Returning an error rejects the image; it is removed again unless it was already local.
+type ImageAdmission func(image types.ImageInspect) error
+
 // ProvisionContainer: Deployment logic for launching new services
-func ProvisionContainer(imageName string, serviceName string, cpu float64, mem int64) error {
+func ProvisionContainer(imageName string, serviceName string, cpu float64, mem int64, admit ImageAdmission) error {
 	ctx := context.Background()
 	cli, err := getDockerClient()
 	if err != nil {
@@ -133,8 +142,9 @@ func ProvisionContainer(imageName string, serviceName string, cpu float64, mem i
 
 	fmt.Printf("[ORCHESTRATOR] 🚀 Provisioning %s...\n", serviceName)
 
-	// Remove old instance if exists
-	_ = cli.ContainerRemove(ctx, serviceName, types.ContainerRemoveOptions{Force: true})
+	// An image that was already local may back other containers: a rejection must not delete it
+	_, _, inspectErr := cli.ImageInspectWithRaw(ctx, imageName)
+	preexisting := inspectErr == nil
 
 	// Pull image
 	fmt.Printf("[ORCHESTRATOR] Pulling image: %s\n", imageName)
@@ -145,12 +155,31 @@ func ProvisionContainer(imageName string, serviceName string, cpu float64, mem i
 	defer reader.Close()
 	_, _ = io.Copy(io.Discard, reader) // Discard output to keep engine logs clean
 
+	// Post-pull admission: the image config is only visible once the image is local
+	if admit != nil {
+		inspect, _, err := cli.ImageInspectWithRaw(ctx, imageName)
+		if err != nil {
+			return fmt.Errorf("Inspect failed: %v", err)
+		}
+		if err := admit(inspect); err != nil {
+			if !preexisting {
+				removeImage(ctx, cli, imageName)
+			}
+			return fmt.Errorf("%w: %v", ErrImageRejected, err)
+		}
+	}
+
+	// Remove old instance if exists (only after the new image was admitted)
+	_ = cli.ContainerRemove(ctx, serviceName, types.ContainerRemoveOptions{Force: true})
+
 	// Resource limits & Ports
 	hostBinding := nat.PortBinding{HostIP: "0.0.0.0", HostPort: "8081"}
 	containerPort, _ := nat.NewPort("tcp", "80")
 
 	resp, err := cli.ContainerCreate(ctx, &container.Config{
 		Image: imageName,
+		// Labels let the runtime monitor map kernel events back to the service
+		Labels: map[string]string{LabelService: serviceName, LabelManaged: "true"},
 	}, &container.HostConfig{
 		PortBindings: nat.PortMap{containerPort: []nat.PortBinding{hostBinding}},
 		Resources: container.Resources{
@@ -166,6 +195,54 @@ func ProvisionContainer(imageName string, serviceName string, cpu float64, mem i
 	return cli.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{})
 }
 
+// InspectLocalImage returns the config of an image already in the local cache (never pulls)
+func InspectLocalImage(imageName string) (types.ImageInspect, error) {
+	cli, err := getDockerClient()
+	if err != nil {
+		return types.ImageInspect{}, err
+	}
+	defer cli.Close()
+
+	inspect, _, err := cli.ImageInspectWithRaw(context.Background(), imageName)
+	return inspect, err
+}
+
+// ExportImage streams the image as a `docker save` archive (manifest + layer tarballs)
+func ExportImage(imageName string) (io.ReadCloser, error) {
+	cli, err := getDockerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := cli.ImageSave(context.Background(), []string{imageName})
+	if err != nil {
+		cli.Close()
+		return nil, fmt.Errorf("Export failed: %v", err)
+	}
+	return &exportReader{ReadCloser: rc, cli: cli}, nil
+}
+
+// exportReader keeps the Docker client alive until the archive stream is closed
+type exportReader struct {
+	io.ReadCloser
+	cli *client.Client
+}
+
+func (e *exportReader) Close() error {
+	err := e.ReadCloser.Close()
+	e.cli.Close()
+	return err
+}
+
+// removeImage cleans up a rejected image that this pull brought in; images still used by other containers are left alone
+func removeImage(ctx context.Context, cli *client.Client, imageName string) {
+	if _, err := cli.ImageRemove(ctx, imageName, types.ImageRemoveOptions{PruneChildren: true}); err != nil {
+		fmt.Printf("[ORCHESTRATOR] Could not remove rejected image %s: %v\n", imageName, err)
+		return
+	}
+	fmt.Printf("[ORCHESTRATOR] 🧹 Rejected image %s removed.\n", imageName)
+}
+
 func IsContainerRunning(serviceName string) bool {
 	cli, err := getDockerClient()
 	if err != nil {
@@ -0,0 +1,126 @@
+package platform
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AdmissionRecord is one Gatekeeper decision, allowed or denied
+type AdmissionRecord struct {
+	ID            int64           `json:"id"`
+	Timestamp     time.Time       `json:"timestamp"`
+	Service       string          `json:"service"`
+	Image         string          `json:"image"`
+	ImageDigest   string          `json:"image_digest"`
+	SpecHash      string          `json:"spec_hash"`
+	Decision      string          `json:"decision"`
+	Phase         string          `json:"phase"`
+	Reason        string          `json:"reason"`
+	Rules         json.RawMessage `json:"rules"`
+	PolicyVersion string          `json:"policy_version"`
+	Exceptions    []int64         `json:"exceptions"`
+	Client        string          `json:"client"`
+}
+
+// AdmissionFilter narrows QueryAdmissions; zero values match everything
+type AdmissionFilter struct {
+	Service  string
+	Decision string
+	Rule     string
+	Since    time.Time
+	Limit    int
+}
+
+// RecordAdmission appends a decision to the admission log
+func RecordAdmission(a AdmissionRecord) (int64, error) {
+	if DB == nil {
+		return 0, fmt.Errorf("DB not ready")
+	}
+	exceptions, _ := json.Marshal(a.Exceptions)
+	rules := a.Rules
+	if len(rules) == 0 {
+		rules = json.RawMessage("[]") // keep the column valid JSON for the rule filter
+	}
+	res, err := DB.Exec(`INSERT INTO admissions (timestamp, service, image, image_digest, spec_hash, decision, phase, reason, rules, policy_version, exceptions, client)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		dbTime(time.Now()), a.Service, a.Image, a.ImageDigest, a.SpecHash, a.Decision, a.Phase, a.Reason,
+		string(rules), a.PolicyVersion, string(exceptions), a.Client)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// QueryAdmissions returns the newest decisions matching the filter
+func QueryAdmissions(f AdmissionFilter) ([]AdmissionRecord, error) {
+	var where []string
+	var args []interface{}
+	if f.Service != "" {
+		where = append(where, "service = ?")
+		args = append(args, f.Service)
+	}
+	if f.Decision != "" {
+		where = append(where, "decision = ?")
+		args = append(args, strings.ToUpper(f.Decision))
+	}
+	if f.Rule != "" {
+		// Every decision evaluates every rule, so only match decisions where the rule fired
+		where = append(where, `EXISTS (SELECT 1 FROM json_each(admissions.rules)
+            WHERE json_extract(value, '$.rule') = ? AND json_extract(value, '$.result') != 'PASS')`)
+		args = append(args, f.Rule)
+	}
+	if !f.Since.IsZero() {
+		where = append(where, "timestamp >= ?")
+		args = append(args, dbTime(f.Since))
+	}
+	if f.Limit <= 0 {
+		f.Limit = 50
+	}
+
+	clause := ""
+	if len(where) > 0 {
+		clause = "WHERE " + strings.Join(where, " AND ")
+	}
+	return queryAdmissions(clause+" ORDER BY id DESC LIMIT ?", append(args, f.Limit)...)
+}
+
+// GetAdmission returns a single decision by ID
+func GetAdmission(id int64) (AdmissionRecord, error) {
+	records, err := queryAdmissions("WHERE id = ?", id)
+	if err != nil {
+		return AdmissionRecord{}, err
+	}
+	if len(records) == 0 {
+		return AdmissionRecord{}, fmt.Errorf("admission %d not found", id)
+	}
+	return records[0], nil
+}
+
+func queryAdmissions(clause string, args ...interface{}) ([]AdmissionRecord, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("DB not ready")
+	}
+	rows, err := DB.Query(`SELECT id, timestamp, service, image, image_digest, spec_hash, decision, phase, reason, rules, policy_version, exceptions, client
+        FROM admissions `+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []AdmissionRecord
+	for rows.Next() {
+		var a AdmissionRecord
+		var ts, rules, exceptions string
+		if err := rows.Scan(&a.ID, &ts, &a.Service, &a.Image, &a.ImageDigest, &a.SpecHash, &a.Decision, &a.Phase,
+			&a.Reason, &rules, &a.PolicyVersion, &exceptions, &a.Client); err != nil {
+			return nil, err
+		}
+		a.Timestamp, _ = time.Parse(time.RFC3339, ts)
+		a.Rules = json.RawMessage(rules)
+		json.Unmarshal([]byte(exceptions), &a.Exceptions)
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,112 @@
+package platform
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueryAdmissionsRuleFilter(t *testing.T) {
+	OpenTestDB(t)
+
+	records := []AdmissionRecord{
+		{Service: "web", Decision: "DENIED", Rules: []byte(`[{"rule":"config.user.root","result":"BLOCK","findings":[{"rule":"config.user.root","severity":"MEDIUM","detail":"root","action":"BLOCK"}]},{"rule":"fs.shell","result":"PASS"}]`)},
+		{Service: "api", Decision: "ALLOWED", Rules: []byte(`[{"rule":"config.user.root","result":"PASS"},{"rule":"fs.shell","result":"WARN","findings":[{"detail":"sh","action":"WARN","severity":"MEDIUM","rule":"fs.shell"}]}]`)},
+		// LIKE wildcards in a rule name must not match other rules
+		{Service: "db", Decision: "DENIED", Rules: []byte(`[{"rule":"fs.setuid","result":"BLOCK"}]`)},
+	}
+	for _, r := range records {
+		if _, err := RecordAdmission(r); err != nil {
+			t.Fatalf("RecordAdmission: %v", err)
+		}
+	}
+
+	tests := []struct {
+		filter AdmissionFilter
+		want   []string
+	}{
+		{AdmissionFilter{Rule: "config.user.root"}, []string{"web"}},
+		{AdmissionFilter{Rule: "fs.shell"}, []string{"api"}},
+		{AdmissionFilter{Rule: "fs.%"}, nil},
+		{AdmissionFilter{Rule: "fs._etuid"}, nil},
+		{AdmissionFilter{Decision: "denied"}, []string{"db", "web"}},
+		{AdmissionFilter{Service: "api", Rule: "config.user.root"}, nil},
+		{AdmissionFilter{}, []string{"db", "api", "web"}},
+	}
+	for _, tt := range tests {
+		got, err := QueryAdmissions(tt.filter)
+		if err != nil {
+			t.Fatalf("QueryAdmissions(%+v): %v", tt.filter, err)
+		}
+		var services []string
+		for _, r := range got {
+			services = append(services, r.Service)
+		}
+		if len(services) != len(tt.want) {
+			t.Errorf("QueryAdmissions(%+v) = %v, want %v", tt.filter, services, tt.want)
+			continue
+		}
+		for i := range services {
+			if services[i] != tt.want[i] {
+				t.Errorf("QueryAdmissions(%+v) = %v, want %v", tt.filter, services, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestQueryAdmissionsSince(t *testing.T) {
+	OpenTestDB(t)
+
+	DB.Exec(`INSERT INTO admissions (timestamp, service, decision) VALUES (?, 'old', 'ALLOWED')`, dbTime(time.Now().Add(-48*time.Hour)))
+	if _, err := RecordAdmission(AdmissionRecord{Service: "new", Decision: "ALLOWED"}); err != nil {
+		t.Fatalf("RecordAdmission: %v", err)
+	}
+
+	got, err := QueryAdmissions(AdmissionFilter{Since: time.Now().Add(-24 * time.Hour)})
+	if err != nil {
+		t.Fatalf("QueryAdmissions: %v", err)
+	}
+	if len(got) != 1 || got[0].Service != "new" {
+		t.Fatalf("since 24h = %+v, want only 'new'", got)
+	}
+	if got[0].Timestamp.IsZero() {
+		t.Errorf("timestamp not parsed back")
+	}
+}
+
+func TestQueryAdmissionsRuleFilterWithoutRules(t *testing.T) {
+	OpenTestDB(t)
+
+	if _, err := RecordAdmission(AdmissionRecord{Service: "web", Decision: "ERROR"}); err != nil {
+		t.Fatalf("RecordAdmission: %v", err)
+	}
+	got, err := QueryAdmissions(AdmissionFilter{Rule: "config.user.root"})
+	if err != nil || len(got) != 0 {
+		t.Fatalf("QueryAdmissions = %+v, %v; want no records and no error", got, err)
+	}
+}
+
+func TestGetAdmissionRoundTrip(t *testing.T) {
+	OpenTestDB(t)
+
+	in := AdmissionRecord{
+		Service: "web", Image: "nginx:1.25", ImageDigest: "nginx@sha256:abc", SpecHash: "f00",
+		Decision: "ALLOWED", Phase: "post-pull", Rules: []byte(`[{"rule":"config.user.root","result":"PASS"}]`),
+		PolicyVersion: "gk-1", Exceptions: []int64{3, 7}, Client: "aegis-ctl",
+	}
+	id, err := RecordAdmission(in)
+	if err != nil {
+		t.Fatalf("RecordAdmission: %v", err)
+	}
+	got, err := GetAdmission(id)
+	if err != nil {
+		t.Fatalf("GetAdmission: %v", err)
+	}
+	if got.ID != id || got.Service != in.Service || got.ImageDigest != in.ImageDigest || got.Phase != in.Phase ||
+		string(got.Rules) != string(in.Rules) || len(got.Exceptions) != 2 || got.Exceptions[1] != 7 || got.Client != in.Client {
+		t.Errorf("round trip = %+v, want %+v", got, in)
+	}
+	if _, err := GetAdmission(id + 1); err == nil {
+		t.Errorf("GetAdmission of a missing id: want an error")
+	}
+}
@@ -0,0 +1,57 @@
+package platform
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// All engine tunables are read from AEGIS_* environment variables so the
+// engine keeps working with zero configuration.
+
+// EnvString returns the variable or def when unset
+func EnvString(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok && strings.TrimSpace(v) != "" {
+		return strings.TrimSpace(v)
+	}
+	return def
+}
+
+// EnvBool accepts 1/true/yes/on (case-insensitive)
+func EnvBool(key string, def bool) bool {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return def
+	}
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "1", "true", "yes", "on":
+		return true
+	case "0", "false", "no", "off":
+		return false
+	}
+	return def
+}
+
+// EnvInt returns def when the variable is unset or not a number
+func EnvInt(key string, def int) int {
+	n, err := strconv.Atoi(strings.TrimSpace(os.Getenv(key)))
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// EnvList splits a comma-separated variable, dropping empty items
+func EnvList(key string, def []string) []string {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
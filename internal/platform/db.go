@@ -1,110 +1,192 @@
-package platform
-
-import (
-	"database/sql"
-	"fmt"
-	"log"
-	"os"
-	"path/filepath"
-
-	_ "github.com/glebarez/go-sqlite"
-)
-
-var DB *sql.DB
-
-func InitDB() (*sql.DB, error) {
-	// FIXED: Hamesha Project Root ki DB use karein, bhale hi engine kisi bhi folder se chale
-	cwd, _ := os.Getwd()
-	// Agar hum cmd/aegis-engine mein hain, toh root par jane ke liye path fix karein
-	dbPath := filepath.Join(cwd, "aegis.db")
-	
-	dsn := fmt.Sprintf("%s?_journal_mode=WAL&_busy_timeout=5000", dbPath)
-	db, err := sql.Open("sqlite", dsn)
-	if err != nil {
-		return nil, err
-	}
-
-	// Stability Settings
-	db.SetMaxOpenConns(1)
-
-	// --- FULL SYNCED SCHEMA ---
-	const schema = `
-    CREATE TABLE IF NOT EXISTS deployments (
-        id INTEGER PRIMARY KEY AUTOINCREMENT,
-        name TEXT UNIQUE, 
-        image TEXT NOT NULL,
-        cpu REAL DEFAULT 0.5,
-        memory INTEGER DEFAULT 128,
-        status TEXT,
-        ai_insight TEXT DEFAULT 'Initial validation passed',
-        last_seen DATETIME DEFAULT CURRENT_TIMESTAMP
-    );
-
-    CREATE TABLE IF NOT EXISTS detections (
-        id INTEGER PRIMARY KEY AUTOINCREMENT,
-        command TEXT,
-        risk TEXT,
-        source TEXT,
-        identity TEXT,
-        pid INTEGER,
-        timestamp DATETIME DEFAULT CURRENT_TIMESTAMP
-    );
-
-    CREATE TABLE IF NOT EXISTS security_alerts (
-        id INTEGER PRIMARY KEY AUTOINCREMENT,
-        service TEXT,
-        alert_type TEXT,
-        message TEXT,
-        timestamp DATETIME DEFAULT CURRENT_TIMESTAMP
-    );`
-
-	if _, err := db.Exec(schema); err != nil {
-		return nil, fmt.Errorf("schema error: %v", err)
-	}
-
-	// Column Migration (Double Check)
-	_, _ = db.Exec("ALTER TABLE deployments ADD COLUMN ai_insight TEXT DEFAULT 'Monitoring active'")
-
-	DB = db
-	log.Printf("[DATABASE] ✅ Connected to: %s (WAL Mode)", dbPath)
-	return db, nil
-}
-
-// LogDetection persists eBPF security events with Aggressive Filtering
-func LogDetection(command, risk, source, identity string, pid int) error {
-	if DB == nil {
-		return fmt.Errorf("DB not ready")
-	}
-	
-	// NOISE FILTER: In commands ko database mein mat daalo, ye engine/system ke hain
-	noiseCommands := map[string]bool{
-		"dockerd":         true,
-		"containerd":      true,
-		"containerd-shim": true,
-		"go":              true,
-		"sudo":            true,
-		"runc":            true,
-		"sh":              true, // Engine pulls ke waqt sh use karta hai
-	}
-
-	if noiseCommands[command] {
-		return nil 
-	}
-
-	query := `INSERT INTO detections (command, risk, source, identity, pid) VALUES (?, ?, ?, ?, ?)`
-	_, err := DB.Exec(query, command, risk, source, identity, pid)
-	if err != nil {
-		log.Printf("[DB-ERROR] LogDetection: %v", err)
-	}
-	return err
-}
-
-// UpdateDeploymentStatus updates both status and AI insights
-func UpdateDeploymentStatus(name, status, insight string) error {
-	if DB == nil {
-		return fmt.Errorf("DB not ready")
-	}
-	query := `UPDATE deployments SET status = ?, ai_insight = ?, last_seen = CURRENT_TIMESTAMP WHERE name = ?`
-	_, err := DB.Exec(query, status, insight, name)
-	return err
+package platform
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	_ "github.com/glebarez/go-sqlite"
+)
+
+var DB *sql.DB
+
+func InitDB() (*sql.DB, error) {
+	// FIXED: Hamesha Project Root ki DB use karein, bhale hi engine kisi bhi folder se chale
+	cwd, _ := os.Getwd()
+	// Agar hum cmd/aegis-engine mein hain, toh root par jane ke liye path fix karein
+	dbPath := filepath.Join(cwd, "aegis.db")
+	
+	dsn := fmt.Sprintf("%s?_journal_mode=WAL&_busy_timeout=5000", dbPath)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stability Settings
+	db.SetMaxOpenConns(1)
+
+	// --- FULL SYNCED SCHEMA ---
+	const schema = `
+    CREATE TABLE IF NOT EXISTS deployments (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        name TEXT UNIQUE, 
+        image TEXT NOT NULL,
+        cpu REAL DEFAULT 0.5,
+        memory INTEGER DEFAULT 128,
+        status TEXT,
+        ai_insight TEXT DEFAULT 'Initial validation passed',
+        last_seen DATETIME DEFAULT CURRENT_TIMESTAMP
+    );
+
+    CREATE TABLE IF NOT EXISTS detections (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        command TEXT,
+        risk TEXT,
+        source TEXT,
+        identity TEXT,
+        pid INTEGER,
+        timestamp DATETIME DEFAULT CURRENT_TIMESTAMP
+    );
+
+    CREATE TABLE IF NOT EXISTS security_alerts (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        service TEXT,
+        alert_type TEXT,
+        message TEXT,
+        timestamp DATETIME DEFAULT CURRENT_TIMESTAMP
+    );
+
+    -- Filesystem inventory (executables + flagged files) per image digest
+    CREATE TABLE IF NOT EXISTS image_scans (
+        digest TEXT PRIMARY KEY,
+        image TEXT,
+        file_count INTEGER,
+        scanned_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    );
+
+    CREATE TABLE IF NOT EXISTS image_inventory (
+        digest TEXT NOT NULL,
+        path TEXT NOT NULL,
+        sha256 TEXT,
+        mode INTEGER,
+        category TEXT,
+        PRIMARY KEY (digest, path)
+    );
+
+    -- Time-boxed Gatekeeper exceptions (one service, one rule)
+    CREATE TABLE IF NOT EXISTS policy_exceptions (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        service TEXT NOT NULL,
+        rule TEXT NOT NULL,
+        owner TEXT NOT NULL,
+        reason TEXT NOT NULL,
+        expires_at TEXT NOT NULL,
+        created_at TEXT NOT NULL,
+        expiry_warned INTEGER DEFAULT 0
+    );
+
+    -- Learned behaviour per service and image revision (see security/baseline.go)
+    CREATE TABLE IF NOT EXISTS behaviour_profiles (
+        service TEXT NOT NULL,
+        image_id TEXT NOT NULL,
+        image TEXT,
+        binaries TEXT DEFAULT '[]',
+        spawns TEXT DEFAULT '[]',
+        users TEXT DEFAULT '[]',
+        destinations TEXT DEFAULT '[]',
+        updated_at TEXT NOT NULL,
+        PRIMARY KEY (service, image_id)
+    );
+
+    -- Every admission decision, allowed or denied, with per-rule results
+    CREATE TABLE IF NOT EXISTS admissions (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        timestamp TEXT NOT NULL,
+        service TEXT,
+        image TEXT,
+        image_digest TEXT DEFAULT '',
+        spec_hash TEXT,
+        decision TEXT,
+        phase TEXT,
+        reason TEXT DEFAULT '',
+        rules TEXT DEFAULT '[]',
+        policy_version TEXT,
+        exceptions TEXT DEFAULT '[]',
+        client TEXT DEFAULT ''
+    );
+    CREATE INDEX IF NOT EXISTS idx_admissions_service ON admissions (service, timestamp);`
+
+	if _, err := db.Exec(schema); err != nil {
+		return nil, fmt.Errorf("schema error: %v", err)
+	}
+
+	// Column Migration (Double Check)
+	_, _ = db.Exec("ALTER TABLE deployments ADD COLUMN ai_insight TEXT DEFAULT 'Monitoring active'")
+	_, _ = db.Exec("ALTER TABLE deployments ADD COLUMN version TEXT DEFAULT ''")
+	_, _ = db.Exec("ALTER TABLE deployments ADD COLUMN security_level TEXT DEFAULT 'medium'")
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN filename TEXT DEFAULT ''")
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN argv TEXT DEFAULT '[]'")
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN argv_truncated INTEGER DEFAULT 0")
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN cwd TEXT DEFAULT ''")
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN detection_type TEXT DEFAULT 'EXEC'")
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN dest_ip TEXT DEFAULT ''")
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN dest_port INTEGER DEFAULT 0")
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN protocol TEXT DEFAULT ''")
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN open_flags INTEGER DEFAULT 0")
+	_, _ = db.Exec("ALTER TABLE deployments ADD COLUMN egress TEXT DEFAULT ''")
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN old_uid INTEGER DEFAULT 0")
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN new_uid INTEGER DEFAULT 0")
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN old_euid INTEGER DEFAULT 0")
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN new_euid INTEGER DEFAULT 0")
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN caps_gained TEXT DEFAULT '[]'")
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN response TEXT DEFAULT ''")
+	_, _ = db.Exec("ALTER TABLE deployments ADD COLUMN deny_exec TEXT DEFAULT ''")
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN ancestry TEXT DEFAULT '[]'")
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN drops_in_window INTEGER DEFAULT 0")
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN rule_id TEXT DEFAULT ''")
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN mitre TEXT DEFAULT ''")
+	_, _ = db.Exec("ALTER TABLE deployments ADD COLUMN learn_until TEXT DEFAULT ''")
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN deviation TEXT DEFAULT ''")
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN sha256 TEXT DEFAULT ''")
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN fileless TEXT DEFAULT ''")
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN sample TEXT DEFAULT ''")
+	_, _ = db.Exec("ALTER TABLE deployments ADD COLUMN revision INTEGER DEFAULT 0")
+	_, _ = db.Exec("ALTER TABLE deployments ADD COLUMN spawn TEXT DEFAULT ''")
+	for _, col := range []string{"service", "container_id", "image", "image_digest", "version", "security_level", "rules_version", "host", "backend"} {
+		_, _ = db.Exec("ALTER TABLE detections ADD COLUMN " + col + " TEXT DEFAULT ''")
+	}
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN revision INTEGER DEFAULT 0")
+	_, _ = db.Exec("ALTER TABLE detections ADD COLUMN ai_verdict TEXT DEFAULT ''")
+	_, _ = db.Exec("CREATE INDEX IF NOT EXISTS idx_detections_service ON detections (service, timestamp)")
+
+	DB = db
+	log.Printf("[DATABASE] ✅ Connected to: %s (WAL Mode)", dbPath)
+	return db, nil
+}
+
+// LogDetection persists a detection as is; what counts as noise is decided by the
+// detection rules (internal/rules) before anything gets here
+func LogDetection(command, risk, source, identity string, pid int) error {
+	if DB == nil {
+		return fmt.Errorf("DB not ready")
+	}
+	
+	query := `INSERT INTO detections (command, risk, source, identity, pid) VALUES (?, ?, ?, ?, ?)`
+	_, err := DB.Exec(query, command, risk, source, identity, pid)
+	if err != nil {
+		log.Printf("[DB-ERROR] LogDetection: %v", err)
+	}
+	return err
+}
+
+// UpdateDeploymentStatus updates both status and AI insights
+func UpdateDeploymentStatus(name, status, insight string) error {
+	if DB == nil {
+		return fmt.Errorf("DB not ready")
+	}
+	query := `UPDATE deployments SET status = ?, ai_insight = ?, last_seen = CURRENT_TIMESTAMP WHERE name = ?`
+	_, err := DB.Exec(query, status, insight, name)
+	return err
 }
\ No newline at end of file
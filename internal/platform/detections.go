@@ -0,0 +1,86 @@
+package platform
+
+import (
+	"strings"
+	"time"
+)
+
+// DetectionContext is the deployment a detection happened in, captured with it so
+// a detection can be triaged after the service was redeployed or removed
+type DetectionContext struct {
+	Service       string `json:"service"`
+	ContainerID   string `json:"container_id"`
+	Image         string `json:"image"`
+	ImageDigest   string `json:"image_digest"`
+	Revision      int    `json:"revision"` // deployment revision (0: not deployed by AEGIS-V)
+	Version       string `json:"version"`  // version from the deployment spec
+	SecurityLevel string `json:"security_level"`
+	RulesVersion  string `json:"rules_version"` // fingerprint of the active detection rules
+	Host          string `json:"host"`
+	Backend       string `json:"backend"` // monitor that saw the event: ebpf, or proc_connector (exec only)
+}
+
+// DetectionContextColumns are the detections columns holding a DetectionContext, in Values order
+const DetectionContextColumns = "service, container_id, image, image_digest, revision, version, security_level, rules_version, host, backend"
+
+// Values returns the context in DetectionContextColumns order
+func (c DetectionContext) Values() []interface{} {
+	return []interface{}{c.Service, c.ContainerID, c.Image, c.ImageDigest, c.Revision, c.Version, c.SecurityLevel, c.RulesVersion, c.Host, c.Backend}
+}
+
+// DetectionFilter narrows detection queries; zero values match everything
+type DetectionFilter struct {
+	Service   string
+	Container string // container ID or a prefix of it
+	Image     string // image reference or digest
+	Revision  int
+	Level     string
+	Rule      string
+	Host      string
+	Type      string
+	Since     time.Time
+	Limit     int
+}
+
+// Where renders the filter as a WHERE clause ("" when it matches everything)
+func (f DetectionFilter) Where() (string, []interface{}) {
+	var where []string
+	var args []interface{}
+	add := func(cond string, a ...interface{}) {
+		where = append(where, cond)
+		args = append(args, a...)
+	}
+	if f.Service != "" {
+		add("service = ?", f.Service)
+	}
+	if f.Container != "" {
+		add("container_id LIKE ?", f.Container+"%")
+	}
+	if f.Image != "" {
+		add("(image = ? OR image_digest = ?)", f.Image, f.Image)
+	}
+	if f.Revision > 0 {
+		add("revision = ?", f.Revision)
+	}
+	if f.Level != "" {
+		add("security_level = ?", strings.ToLower(f.Level))
+	}
+	if f.Rule != "" {
+		add("rule_id = ?", f.Rule)
+	}
+	if f.Host != "" {
+		add("host = ?", f.Host)
+	}
+	if f.Type != "" {
+		add("detection_type = ?", strings.ToUpper(f.Type))
+	}
+	if !f.Since.IsZero() {
+		// Rows carry time.Now() in any zone or a legacy CURRENT_TIMESTAMP, so compare
+		// them as instants (julianday normalizes the offset), never as strings
+		add("julianday(timestamp) >= julianday(?)", f.Since.UTC().Format(time.RFC3339Nano))
+	}
+	if len(where) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(where, " AND "), args
+}
@@ -0,0 +1,121 @@
+package platform
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDetectionFilterSince(t *testing.T) {
+	OpenTestDB(t)
+
+	now := time.Now()
+	east := time.FixedZone("UTC+5:30", 5*3600+1800)
+	west := time.FixedZone("UTC-8", -8*3600)
+	rows := []struct {
+		command string
+		ts      interface{} // nil: the column default (CURRENT_TIMESTAMP)
+	}{
+		{"legacy-now", nil},
+		{"east-recent", now.Add(-10 * time.Minute).In(east)},
+		{"west-recent", now.Add(-10 * time.Minute).In(west)},
+		{"east-old", now.Add(-3 * time.Hour).In(east)},
+		{"west-old", now.Add(-3 * time.Hour).In(west)},
+		{"utc-old", now.Add(-3 * time.Hour).UTC()},
+	}
+	for _, r := range rows {
+		var err error
+		if r.ts == nil {
+			_, err = DB.Exec("INSERT INTO detections (command) VALUES (?)", r.command)
+		} else {
+			_, err = DB.Exec("INSERT INTO detections (command, timestamp) VALUES (?, ?)", r.command, r.ts)
+		}
+		if err != nil {
+			t.Fatalf("insert %s: %v", r.command, err)
+		}
+	}
+
+	for _, since := range []time.Time{now.Add(-time.Hour), now.Add(-time.Hour).In(west)} {
+		where, args := DetectionFilter{Since: since}.Where()
+		got := map[string]bool{}
+		res, err := DB.Query("SELECT command FROM detections "+where, args...)
+		if err != nil {
+			t.Fatalf("query: %v", err)
+		}
+		for res.Next() {
+			var c string
+			res.Scan(&c)
+			got[c] = true
+		}
+		res.Close()
+
+		want := map[string]bool{"legacy-now": true, "east-recent": true, "west-recent": true}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("since %s: got %v, want %v", since, got, want)
+		}
+	}
+}
+
+func TestDetectionFilterWhere(t *testing.T) {
+	OpenTestDB(t)
+
+	seed := []struct {
+		command string
+		ctx     DetectionContext
+		kind    string
+		rule    string
+	}{
+		{"web-v1", DetectionContext{Service: "web", ContainerID: "abc123", Image: "nginx:1.27", ImageDigest: "sha256:n1", Revision: 1, SecurityLevel: "high", Host: "node-a"}, "EXEC", "exec.tool-transfer"},
+		{"web-v2", DetectionContext{Service: "web", ContainerID: "abd456", Image: "nginx:1.28", ImageDigest: "sha256:n2", Revision: 2, SecurityLevel: "high", Host: "node-b"}, "EGRESS", ""},
+		{"db", DetectionContext{Service: "db", ContainerID: "fff000", Image: "postgres:16", ImageDigest: "sha256:p1", Revision: 1, SecurityLevel: "medium", Host: "node-a"}, "FILE_ACCESS", "file.shadow"},
+	}
+	for _, r := range seed {
+		_, err := DB.Exec("INSERT INTO detections (command, detection_type, rule_id, "+DetectionContextColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			append([]interface{}{r.command, r.kind, r.rule}, r.ctx.Values()...)...)
+		if err != nil {
+			t.Fatalf("insert %s: %v", r.command, err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter DetectionFilter
+		want   []string
+	}{
+		{"everything", DetectionFilter{}, []string{"db", "web-v1", "web-v2"}},
+		{"service", DetectionFilter{Service: "web"}, []string{"web-v1", "web-v2"}},
+		{"container prefix", DetectionFilter{Container: "ab"}, []string{"web-v1", "web-v2"}},
+		{"full container id", DetectionFilter{Container: "abd456"}, []string{"web-v2"}},
+		{"image reference", DetectionFilter{Image: "nginx:1.27"}, []string{"web-v1"}},
+		{"image digest", DetectionFilter{Image: "sha256:n2"}, []string{"web-v2"}},
+		{"revision", DetectionFilter{Service: "web", Revision: 2}, []string{"web-v2"}},
+		{"level, any case", DetectionFilter{Level: "HIGH"}, []string{"web-v1", "web-v2"}},
+		{"rule", DetectionFilter{Rule: "file.shadow"}, []string{"db"}},
+		{"host", DetectionFilter{Host: "node-a"}, []string{"db", "web-v1"}},
+		{"type, any case", DetectionFilter{Type: "egress"}, []string{"web-v2"}},
+		{"combined", DetectionFilter{Host: "node-a", Revision: 1, Service: "db"}, []string{"db"}},
+		{"no match", DetectionFilter{Service: "web", Host: "node-c"}, nil},
+	}
+	for _, tt := range tests {
+		where, args := tt.filter.Where()
+		rows, err := DB.Query("SELECT command FROM detections "+where+" ORDER BY command", args...)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var got []string
+		for rows.Next() {
+			var c string
+			rows.Scan(&c)
+			got = append(got, c)
+		}
+		rows.Close()
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if where, args := (DetectionFilter{Limit: 10}).Where(); where != "" || args != nil {
+		t.Errorf("limit alone rendered %q %v", where, args)
+	}
+}
@@ -0,0 +1,72 @@
+package platform
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// EgressRule allows outbound connections from a service to a destination range
+type EgressRule struct {
+	CIDR     string `json:"cidr"`
+	Ports    []int  `json:"ports,omitempty"`
+	Protocol string `json:"protocol,omitempty"` // tcp, udp, or empty for both
+}
+
+// RuntimePolicy is the part of a deployment the runtime monitor enforces
+type RuntimePolicy struct {
+	Managed       bool // false: no deployment row, the container was not started by the engine
+	SecurityLevel string
+	Egress        []EgressRule
+	// EgressDefined=false means the spec declared no egress section (nothing is enforced);
+	// an empty but declared list denies all non-loopback egress.
+	EgressDefined bool
+	DenyExec      []string // binary names refused at exec time
+	// Spawn names the main processes allowed to start other programs; with
+	// SpawnDefined=false the default spawn profile applies
+	Spawn        []string
+	SpawnDefined bool
+	// LearnUntil ends the behaviour learning window; afterwards learned profiles are enforced
+	LearnUntil time.Time
+	Revision   int    // bumped on every deploy of the service
+	Version    string // version from the deployment spec
+}
+
+// LoadRuntimePolicy returns the runtime policy stored with a deployment
+func LoadRuntimePolicy(service string) (RuntimePolicy, error) {
+	if DB == nil {
+		return RuntimePolicy{}, fmt.Errorf("DB not ready")
+	}
+
+	var p RuntimePolicy
+	var raw, deny, learn, spawn string
+	err := DB.QueryRow("SELECT COALESCE(security_level, 'medium'), COALESCE(egress, ''), COALESCE(deny_exec, ''), COALESCE(learn_until, ''), COALESCE(revision, 0), COALESCE(version, ''), COALESCE(spawn, '') FROM deployments WHERE name = ?", service).Scan(&p.SecurityLevel, &raw, &deny, &learn, &p.Revision, &p.Version, &spawn)
+	if err == sql.ErrNoRows {
+		return RuntimePolicy{}, nil
+	}
+	if err != nil {
+		return RuntimePolicy{}, err
+	}
+	p.Managed = true
+	p.LearnUntil, _ = time.Parse(time.RFC3339, learn)
+	if deny != "" {
+		if err := json.Unmarshal([]byte(deny), &p.DenyExec); err != nil {
+			return p, fmt.Errorf("corrupt deny_exec for %s: %v", service, err)
+		}
+	}
+	if spawn != "" && spawn != "null" {
+		if err := json.Unmarshal([]byte(spawn), &p.Spawn); err != nil {
+			return p, fmt.Errorf("corrupt spawn profile for %s: %v", service, err)
+		}
+		p.SpawnDefined = true
+	}
+	if raw == "" || raw == "null" {
+		return p, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &p.Egress); err != nil {
+		return p, fmt.Errorf("corrupt egress policy for %s: %v", service, err)
+	}
+	p.EgressDefined = true
+	return p, nil
+}
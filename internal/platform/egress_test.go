@@ -0,0 +1,44 @@
+package platform
+
+import "testing"
+
+func TestLoadRuntimePolicyEgress(t *testing.T) {
+	OpenTestDB(t)
+
+	for _, d := range []struct{ name, egress string }{
+		{"none", ""},
+		{"null", "null"},
+		{"deny-all", "[]"},
+		{"db-only", `[{"cidr":"10.0.0.0/24","ports":[5432],"protocol":"tcp"}]`},
+		{"corrupt", `[{"cidr":`},
+	} {
+		if _, err := DB.Exec("INSERT INTO deployments (name, image, egress) VALUES (?, 'nginx:1.25', ?)", d.name, d.egress); err != nil {
+			t.Fatalf("insert %s: %v", d.name, err)
+		}
+	}
+
+	tests := []struct {
+		service string
+		managed bool
+		defined bool
+		rules   int
+		wantErr bool
+	}{
+		{"unknown", false, false, 0, false},
+		{"none", true, false, 0, false},
+		{"null", true, false, 0, false},
+		{"deny-all", true, true, 0, false},
+		{"db-only", true, true, 1, false},
+		{"corrupt", true, false, 0, true},
+	}
+	for _, tt := range tests {
+		p, err := LoadRuntimePolicy(tt.service)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.service, err, tt.wantErr)
+			continue
+		}
+		if p.Managed != tt.managed || p.EgressDefined != tt.defined || len(p.Egress) != tt.rules {
+			t.Errorf("%s: managed=%v defined=%v rules=%d, want %v %v %d", tt.service, p.Managed, p.EgressDefined, len(p.Egress), tt.managed, tt.defined, tt.rules)
+		}
+	}
+}
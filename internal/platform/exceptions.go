@@ -0,0 +1,102 @@
+package platform
+
+import (
+	"fmt"
+	"time"
+)
+
+// PolicyException lets one service bypass one Gatekeeper rule until it expires
+type PolicyException struct {
+	ID        int64     `json:"id"`
+	Service   string    `json:"service"`
+	Rule      string    `json:"rule"`
+	Owner     string    `json:"owner"`
+	Reason    string    `json:"reason"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Timestamps are stored as RFC3339 UTC so they compare correctly as text
+func dbTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// CreateException stores a new exception and returns its ID
+func CreateException(e PolicyException) (int64, error) {
+	if DB == nil {
+		return 0, fmt.Errorf("DB not ready")
+	}
+	res, err := DB.Exec(`INSERT INTO policy_exceptions (service, rule, owner, reason, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		e.Service, e.Rule, e.Owner, e.Reason, dbTime(e.ExpiresAt), dbTime(time.Now()))
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// RevokeException deletes an exception before it expires
+func RevokeException(id int64) error {
+	if DB == nil {
+		return fmt.Errorf("DB not ready")
+	}
+	res, err := DB.Exec("DELETE FROM policy_exceptions WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("exception %d not found", id)
+	}
+	return nil
+}
+
+// ActiveExceptions returns the unexpired exceptions of a service
+func ActiveExceptions(service string) ([]PolicyException, error) {
+	return queryExceptions("WHERE service = ? AND expires_at > ?", service, dbTime(time.Now()))
+}
+
+// ListExceptions returns every exception, optionally including expired ones
+func ListExceptions(includeExpired bool) ([]PolicyException, error) {
+	if includeExpired {
+		return queryExceptions("")
+	}
+	return queryExceptions("WHERE expires_at > ?", dbTime(time.Now()))
+}
+
+// ExpiringExceptions returns active exceptions expiring within the window that were not yet announced
+func ExpiringExceptions(within time.Duration) ([]PolicyException, error) {
+	now := time.Now()
+	return queryExceptions("WHERE expires_at > ? AND expires_at <= ? AND expiry_warned = 0", dbTime(now), dbTime(now.Add(within)))
+}
+
+// MarkExceptionWarned records that the expiry warning was emitted once
+func MarkExceptionWarned(id int64) error {
+	if DB == nil {
+		return fmt.Errorf("DB not ready")
+	}
+	_, err := DB.Exec("UPDATE policy_exceptions SET expiry_warned = 1 WHERE id = ?", id)
+	return err
+}
+
+func queryExceptions(where string, args ...interface{}) ([]PolicyException, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("DB not ready")
+	}
+	rows, err := DB.Query("SELECT id, service, rule, owner, reason, expires_at, created_at FROM policy_exceptions "+where+" ORDER BY expires_at ASC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []PolicyException
+	for rows.Next() {
+		var e PolicyException
+		var expires, created string
+		if err := rows.Scan(&e.ID, &e.Service, &e.Rule, &e.Owner, &e.Reason, &expires, &created); err != nil {
+			return nil, err
+		}
+		e.ExpiresAt, _ = time.Parse(time.RFC3339, expires)
+		e.CreatedAt, _ = time.Parse(time.RFC3339, created)
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
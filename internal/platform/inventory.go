@@ -0,0 +1,97 @@
+package platform
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// ImageFile is one entry of an image's filesystem inventory
+type ImageFile struct {
+	Path     string `json:"path"`
+	SHA256   string `json:"sha256,omitempty"`
+	Mode     int64  `json:"mode"`
+	Category string `json:"category"`
+}
+
+// SaveImageInventory replaces the stored inventory of an image digest in one transaction
+func SaveImageInventory(digest, image string, files []ImageFile) error {
+	if DB == nil {
+		return fmt.Errorf("DB not ready")
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM image_inventory WHERE digest = ?", digest); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare("INSERT INTO image_inventory (digest, path, sha256, mode, category) VALUES (?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, f := range files {
+		if _, err := stmt.Exec(digest, f.Path, f.SHA256, f.Mode, f.Category); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("INSERT OR REPLACE INTO image_scans (digest, image, file_count, scanned_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)",
+		digest, image, len(files)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// LoadImageInventory returns the stored inventory, or ok=false if the digest was never scanned
+func LoadImageInventory(digest string) ([]ImageFile, bool, error) {
+	if DB == nil {
+		return nil, false, fmt.Errorf("DB not ready")
+	}
+
+	var count int
+	err := DB.QueryRow("SELECT file_count FROM image_scans WHERE digest = ?", digest).Scan(&count)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	rows, err := DB.Query("SELECT path, COALESCE(sha256, ''), mode, category FROM image_inventory WHERE digest = ?", digest)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	files := make([]ImageFile, 0, count)
+	for rows.Next() {
+		var f ImageFile
+		if err := rows.Scan(&f.Path, &f.SHA256, &f.Mode, &f.Category); err != nil {
+			return nil, false, err
+		}
+		files = append(files, f)
+	}
+	return files, true, rows.Err()
+}
+
+// LookupImageFile answers "did this path ship with the image?" for runtime detections.
+// scanned=false means no inventory exists for the digest, so the answer is unknown.
+func LookupImageFile(digest, path string) (file ImageFile, found bool, scanned bool) {
+	if DB == nil || digest == "" {
+		return ImageFile{}, false, false
+	}
+
+	var n int
+	if err := DB.QueryRow("SELECT COUNT(*) FROM image_scans WHERE digest = ?", digest).Scan(&n); err != nil || n == 0 {
+		return ImageFile{}, false, false
+	}
+
+	err := DB.QueryRow("SELECT path, COALESCE(sha256, ''), mode, category FROM image_inventory WHERE digest = ? AND path = ?",
+		digest, path).Scan(&file.Path, &file.SHA256, &file.Mode, &file.Category)
+	return file, err == nil, true
+}
@@ -0,0 +1,122 @@
+package platform
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Spawn is one parent → child exec pair (parent comm, child binary name)
+type Spawn struct {
+	Parent string `json:"parent" yaml:"parent"`
+	Child  string `json:"child" yaml:"child"`
+}
+
+// BehaviourProfile is what a service normally does, learned per image revision
+type BehaviourProfile struct {
+	Service      string    `json:"service" yaml:"service"`
+	ImageID      string    `json:"image_id" yaml:"image_id"`
+	Image        string    `json:"image" yaml:"image"`
+	Binaries     []string  `json:"binaries" yaml:"binaries"`
+	Spawns       []Spawn   `json:"spawns" yaml:"spawns"`
+	Users        []uint32  `json:"users" yaml:"users"`
+	Destinations []string  `json:"destinations" yaml:"destinations"` // ip:port/protocol
+	UpdatedAt    time.Time `json:"updated_at" yaml:"updated_at"`
+
+	// Set on read from the deployment: learning until LearnUntil, enforce afterwards
+	Mode       string    `json:"mode" yaml:"mode,omitempty"`
+	LearnUntil time.Time `json:"learn_until" yaml:"learn_until,omitempty"`
+}
+
+// Profile modes
+const (
+	ProfileLearning = "learning"
+	ProfileEnforce  = "enforce"
+)
+
+// SaveProfile creates or replaces the profile of one service and image revision
+func SaveProfile(p BehaviourProfile) error {
+	if DB == nil {
+		return fmt.Errorf("DB not ready")
+	}
+	binaries, _ := json.Marshal(nonNil(p.Binaries))
+	spawns, _ := json.Marshal(nonNil(p.Spawns))
+	users, _ := json.Marshal(nonNil(p.Users))
+	dests, _ := json.Marshal(nonNil(p.Destinations))
+	_, err := DB.Exec(`INSERT OR REPLACE INTO behaviour_profiles (service, image_id, image, binaries, spawns, users, destinations, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.Service, p.ImageID, p.Image, string(binaries), string(spawns), string(users), string(dests), dbTime(time.Now()))
+	return err
+}
+
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+// LoadProfile returns the profile of one image revision, nil when none was learned
+func LoadProfile(service, imageID string) (*BehaviourProfile, error) {
+	list, err := queryProfiles("WHERE p.service = ? AND p.image_id = ?", service, imageID)
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+	return &list[0], nil
+}
+
+// ListProfiles returns the profiles of a service ("" for all), newest first
+func ListProfiles(service string) ([]BehaviourProfile, error) {
+	if service == "" {
+		return queryProfiles("")
+	}
+	return queryProfiles("WHERE p.service = ?", service)
+}
+
+// SetLearnUntil starts (or, with a past time, ends) the learning window of a deployment
+func SetLearnUntil(service string, until time.Time) error {
+	if DB == nil {
+		return fmt.Errorf("DB not ready")
+	}
+	res, err := DB.Exec("UPDATE deployments SET learn_until = ? WHERE name = ?", dbTime(until), service)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("service %s not found", service)
+	}
+	return nil
+}
+
+func queryProfiles(where string, args ...interface{}) ([]BehaviourProfile, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("DB not ready")
+	}
+	rows, err := DB.Query(`SELECT p.service, p.image_id, COALESCE(p.image, ''), p.binaries, p.spawns, p.users, p.destinations, p.updated_at, COALESCE(d.learn_until, '')
+		FROM behaviour_profiles p LEFT JOIN deployments d ON d.name = p.service `+where+` ORDER BY p.updated_at DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	var out []BehaviourProfile
+	for rows.Next() {
+		var p BehaviourProfile
+		var binaries, spawns, users, dests, updated, learnUntil string
+		if err := rows.Scan(&p.Service, &p.ImageID, &p.Image, &binaries, &spawns, &users, &dests, &updated, &learnUntil); err != nil {
+			return nil, err
+		}
+		json.Unmarshal([]byte(binaries), &p.Binaries)
+		json.Unmarshal([]byte(spawns), &p.Spawns)
+		json.Unmarshal([]byte(users), &p.Users)
+		json.Unmarshal([]byte(dests), &p.Destinations)
+		p.UpdatedAt, _ = time.Parse(time.RFC3339, updated)
+		p.LearnUntil, _ = time.Parse(time.RFC3339, learnUntil)
+		p.Mode = ProfileEnforce
+		if now.Before(p.LearnUntil) {
+			p.Mode = ProfileLearning
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,86 @@
+package platform
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProfileRoundTrip(t *testing.T) {
+	OpenTestDB(t)
+	if _, err := DB.Exec("INSERT INTO deployments (name, image, status) VALUES ('web', 'nginx:1.27', 'RUNNING')"); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := BehaviourProfile{
+		Service:      "web",
+		ImageID:      "sha256:aaa",
+		Image:        "nginx:1.27",
+		Binaries:     []string{"/usr/sbin/nginx"},
+		Spawns:       []Spawn{{Parent: "sh", Child: "nginx"}},
+		Users:        []uint32{0, 101},
+		Destinations: []string{"10.0.0.5:5432/tcp"},
+	}
+	if err := SaveProfile(saved); err != nil {
+		t.Fatalf("SaveProfile: %v", err)
+	}
+	// Lists learned nothing of are stored empty, not null
+	if err := SaveProfile(BehaviourProfile{Service: "web", ImageID: "sha256:bbb"}); err != nil {
+		t.Fatalf("SaveProfile: %v", err)
+	}
+
+	p, err := LoadProfile("web", "sha256:aaa")
+	if err != nil || p == nil {
+		t.Fatalf("LoadProfile = %v, %v", p, err)
+	}
+	if !reflect.DeepEqual(p.Binaries, saved.Binaries) || !reflect.DeepEqual(p.Spawns, saved.Spawns) ||
+		!reflect.DeepEqual(p.Users, saved.Users) || !reflect.DeepEqual(p.Destinations, saved.Destinations) {
+		t.Errorf("loaded profile = %+v", p)
+	}
+	if p.UpdatedAt.IsZero() {
+		t.Error("UpdatedAt not set")
+	}
+	empty, _ := LoadProfile("web", "sha256:bbb")
+	if empty == nil || empty.Binaries == nil || len(empty.Binaries) != 0 {
+		t.Errorf("empty profile = %+v", empty)
+	}
+	if p, err := LoadProfile("web", "sha256:ccc"); p != nil || err != nil {
+		t.Errorf("unknown revision = %v, %v", p, err)
+	}
+	if list, _ := ListProfiles("web"); len(list) != 2 {
+		t.Errorf("ListProfiles(web) = %d profiles", len(list))
+	}
+	if list, _ := ListProfiles("api"); len(list) != 0 {
+		t.Errorf("ListProfiles(api) = %d profiles", len(list))
+	}
+}
+
+func TestProfileMode(t *testing.T) {
+	OpenTestDB(t)
+	DB.Exec("INSERT INTO deployments (name, image, status) VALUES ('web', 'nginx:1.27', 'RUNNING')")
+	SaveProfile(BehaviourProfile{Service: "web", ImageID: "sha256:aaa"})
+
+	mode := func() string {
+		p, _ := LoadProfile("web", "sha256:aaa")
+		return p.Mode
+	}
+	// No learning window was ever started
+	if m := mode(); m != ProfileEnforce {
+		t.Errorf("without a window: mode %s", m)
+	}
+	if err := SetLearnUntil("web", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("SetLearnUntil: %v", err)
+	}
+	if m := mode(); m != ProfileLearning {
+		t.Errorf("within the window: mode %s", m)
+	}
+	// Promoting ends the window
+	SetLearnUntil("web", time.Now().Add(-time.Second))
+	if m := mode(); m != ProfileEnforce {
+		t.Errorf("after the window: mode %s", m)
+	}
+
+	if err := SetLearnUntil("missing", time.Now()); err == nil {
+		t.Error("SetLearnUntil on an unknown service: want an error")
+	}
+}
@@ -0,0 +1,22 @@
+package platform
+
+import (
+	"database/sql"
+	"testing"
+)
+
+// OpenTestDB points DB at a fresh database in a temporary working directory, for
+// the tests of every package that stores through it. It is closed at cleanup.
+func OpenTestDB(t testing.TB) *sql.DB {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	db, err := InitDB()
+	if err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		DB = nil
+	})
+	return db
+}
@@ -0,0 +1,315 @@
+// Package proctree keeps an in-memory process table fed by the kernel probes
+// (fork / exec / exit), so lineage is still known after ancestors have exited.
+package proctree
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/orchestrator"
+)
+
+const (
+	// exitedGrace keeps exited processes around for late events and lineage lookups
+	exitedGrace = 10 * time.Minute
+	// maxDepth guards ancestor walks against cycles from pid reuse
+	maxDepth = 64
+	// clockTicks is USER_HZ, the unit of starttime in /proc/<pid>/stat
+	clockTicks = 100
+	// startSlack absorbs the tick resolution of /proc start times
+	startSlack = 2 * uint64(time.Second/clockTicks)
+)
+
+// Process is one entry of the table
+type Process struct {
+	Pid       uint32    `json:"pid"`
+	Ppid      uint32    `json:"ppid"`
+	Comm      string    `json:"comm"`
+	Exe       string    `json:"exe,omitempty"`
+	Start     time.Time `json:"start"`
+	Container string    `json:"container,omitempty"`
+	Exited    bool      `json:"exited,omitempty"`
+	CgroupID  uint64    `json:"-"`
+	Trust     string    `json:"trust,omitempty"` // allowlist name of the executable, set on captured lineage
+	Roles     []string  `json:"roles,omitempty"` // role in its container (main, entrypoint), set on captured lineage
+
+	startNs  uint64 // ns since boot, identifies the process across pid reuse
+	exitedAt time.Time
+}
+
+var (
+	mu       sync.RWMutex
+	procs    = map[uint32]*Process{}
+	fed      atomic.Bool
+	bootTime = loadBootTime()
+)
+
+// SetKernelFed switches lookups to the table only. Without the kernel feed the
+// table is a cache in front of /proc.
+func SetKernelFed(on bool) {
+	fed.Store(on)
+}
+
+// Seed loads every running process from /proc; called once before the kernel feed starts
+func Seed() {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		p, ok := readProc(uint32(pid))
+		if !ok {
+			continue
+		}
+		mu.Lock()
+		if _, known := procs[p.Pid]; !known {
+			procs[p.Pid] = p
+		}
+		mu.Unlock()
+	}
+}
+
+// Fork records a new process; it inherits the parent's executable until it execs
+func Fork(parent, child uint32, childStart uint64, comm string, cgroupID uint64) {
+	mu.Lock()
+	defer mu.Unlock()
+	p := &Process{Pid: child, Ppid: parent, Comm: comm, CgroupID: cgroupID, startNs: childStart, Start: bootTime.Add(time.Duration(childStart))}
+	if pp, ok := procs[parent]; ok {
+		p.Exe = pp.Exe
+	}
+	procs[child] = p
+}
+
+// Exec records the new image of a process
+func Exec(pid, ppid uint32, start uint64, comm, exe string, cgroupID uint64) {
+	mu.Lock()
+	defer mu.Unlock()
+	p, ok := procs[pid]
+	if !ok || !sameStart(p.startNs, start) {
+		p = &Process{Pid: pid, Ppid: ppid, startNs: start, Start: bootTime.Add(time.Duration(start))}
+		procs[pid] = p
+	}
+	p.Comm, p.Exe, p.CgroupID = comm, exe, cgroupID
+	p.Container = "" // runc moves the process into the container cgroup before exec
+}
+
+// Exit marks a process as gone; it stays in the table for lineage lookups
+func Exit(pid uint32, start uint64, now time.Time) {
+	mu.Lock()
+	defer mu.Unlock()
+	if p, ok := procs[pid]; ok && sameStart(p.startNs, start) {
+		p.Exited, p.exitedAt = true, now
+	}
+}
+
+// Lookup returns a process by pid
+func Lookup(pid uint32) (Process, bool) {
+	if !fed.Load() {
+		// No kernel feed: /proc is authoritative for live processes
+		if p, ok := readProc(pid); ok {
+			mu.Lock()
+			procs[pid] = p
+			mu.Unlock()
+			return resolve(*p), true
+		}
+	}
+	mu.RLock()
+	p, ok := procs[pid]
+	mu.RUnlock()
+	if !ok {
+		return Process{}, false
+	}
+	return resolve(*p), true
+}
+
+// Read returns a live process straight from /proc, bypassing the table
+func Read(pid uint32) (Process, bool) {
+	p, ok := readProc(pid)
+	if !ok {
+		return Process{}, false
+	}
+	return *p, true
+}
+
+// StartNs is the start time in ns since boot, as the kernel probes report it
+func (p Process) StartNs() uint64 {
+	return p.startNs
+}
+
+// Parent returns the parent pid of a process
+func Parent(pid uint32) (uint32, bool) {
+	p, ok := Lookup(pid)
+	if !ok {
+		return 0, false
+	}
+	return p.Ppid, true
+}
+
+// Ancestors returns the parent chain of pid, nearest first, up to init
+func Ancestors(pid uint32) []Process {
+	child, ok := Lookup(pid)
+	if !ok {
+		return nil
+	}
+	var chain []Process
+	for i := 0; i < maxDepth && child.Ppid != 0; i++ {
+		parent, ok := Lookup(child.Ppid)
+		// A parent younger than its child is a reused pid, not the real ancestor
+		if !ok || (parent.startNs != 0 && child.startNs != 0 && parent.startNs > child.startNs+startSlack) {
+			break
+		}
+		chain = append(chain, parent)
+		if parent.Pid == child.Pid {
+			break
+		}
+		child = parent
+	}
+	return chain
+}
+
+// Lineage renders the ancestor chain for humans: "bash(812) ← sshd(640) ← systemd(1)"
+func Lineage(pid uint32) string {
+	return FormatLineage(Ancestors(pid))
+}
+
+// FormatLineage renders an ancestor chain captured earlier (see Lineage)
+func FormatLineage(chain []Process) string {
+	parts := make([]string, 0, len(chain))
+	for _, p := range chain {
+		parts = append(parts, fmt.Sprintf("%s(%d)", p.Comm, p.Pid))
+	}
+	return strings.Join(parts, " ← ")
+}
+
+// Run prunes exited processes until done is closed
+func Run(done <-chan struct{}) {
+	tick := time.NewTicker(time.Minute)
+	defer tick.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-tick.C:
+			prune(now)
+		}
+	}
+}
+
+// prune drops exited processes past the grace period unless a live process descends from them
+func prune(now time.Time) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	keep := map[uint32]bool{}
+	for _, p := range procs {
+		if p.Exited {
+			continue
+		}
+		for pid, i := p.Ppid, 0; pid != 0 && i < maxDepth; i++ {
+			anc, ok := procs[pid]
+			if !ok || !anc.Exited || keep[pid] {
+				break
+			}
+			keep[pid] = true
+			pid = anc.Ppid
+		}
+	}
+	for pid, p := range procs {
+		if p.Exited && now.Sub(p.exitedAt) > exitedGrace && !keep[pid] {
+			delete(procs, pid)
+		}
+	}
+}
+
+// resolve attaches the container name; done late because the cgroup cache may
+// learn about a container after its first processes were recorded
+func resolve(p Process) Process {
+	if p.Container == "" && p.CgroupID != 0 {
+		if info, ok := orchestrator.ContainerByCgroup(p.CgroupID); ok {
+			p.Container = info.Name
+		}
+	}
+	return p
+}
+
+// Running reports whether p is still the process under its pid (not exited, pid not reused)
+func Running(p Process) bool {
+	if p.Exited || p.startNs == 0 {
+		return false
+	}
+	_, _, start, ok := readStat(p.Pid)
+	return ok && sameStart(start, p.startNs)
+}
+
+func sameStart(a, b uint64) bool {
+	if a > b {
+		a, b = b, a
+	}
+	return b-a <= startSlack
+}
+
+// readProc builds an entry from /proc/<pid>
+func readProc(pid uint32) (*Process, bool) {
+	ppid, comm, start, ok := readStat(pid)
+	if !ok {
+		return nil, false
+	}
+	p := &Process{
+		Pid:     pid,
+		Ppid:    ppid,
+		Comm:    comm,
+		startNs: start,
+		Start:   bootTime.Add(time.Duration(start)),
+	}
+	p.Exe, _ = os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	p.CgroupID, _ = orchestrator.CgroupIDOf(int(pid))
+	return p, true
+}
+
+// readStat parses /proc/<pid>/stat: parent, comm and start time (ns since boot)
+func readStat(pid uint32) (ppid uint32, comm string, start uint64, ok bool) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, "", 0, false
+	}
+	// Format: pid (comm) state ppid ... starttime(22) ...; comm may contain spaces and ')'
+	s := string(data)
+	lp, rp := strings.IndexByte(s, '('), strings.LastIndexByte(s, ')')
+	if lp < 0 || rp < lp {
+		return 0, "", 0, false
+	}
+	fields := strings.Fields(s[rp+1:])
+	if len(fields) < 20 {
+		return 0, "", 0, false
+	}
+	parent, _ := strconv.ParseUint(fields[1], 10, 32)
+	ticks, _ := strconv.ParseUint(fields[19], 10, 64)
+	return uint32(parent), s[lp+1 : rp], ticks * uint64(time.Second/clockTicks), true
+}
+
+// loadBootTime reads btime from /proc/stat (process start times are relative to it)
+func loadBootTime() time.Time {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return time.Time{}
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if v, ok := strings.CutPrefix(sc.Text(), "btime "); ok {
+			secs, _ := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			return time.Unix(secs, 0)
+		}
+	}
+	return time.Time{}
+}
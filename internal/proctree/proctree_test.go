@@ -0,0 +1,120 @@
+package proctree
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// kernelFed runs a test against an empty table fed only by Fork/Exec/Exit
+func kernelFed(t *testing.T) {
+	t.Helper()
+	mu.Lock()
+	saved := procs
+	procs = map[uint32]*Process{}
+	mu.Unlock()
+	SetKernelFed(true)
+	t.Cleanup(func() {
+		SetKernelFed(false)
+		mu.Lock()
+		procs = saved
+		mu.Unlock()
+	})
+}
+
+const sec = uint64(time.Second)
+
+func TestForkExec(t *testing.T) {
+	kernelFed(t)
+	Exec(100, 1, 10*sec, "bash", "/usr/bin/bash", 7)
+	Fork(100, 101, 20*sec, "bash", 7)
+
+	p, ok := Lookup(101)
+	if !ok || p.Ppid != 100 || p.Exe != "/usr/bin/bash" || p.Comm != "bash" {
+		t.Fatalf("forked child = %+v, %v", p, ok)
+	}
+	Exec(101, 100, 20*sec, "curl", "/usr/bin/curl", 7)
+	if p, _ = Lookup(101); p.Comm != "curl" || p.Exe != "/usr/bin/curl" || p.StartNs() != 20*sec {
+		t.Errorf("after exec = %+v", p)
+	}
+
+	// An exec under a reused pid starts a new entry rather than rewriting the old one
+	Exec(101, 1, 90*sec, "nginx", "/usr/sbin/nginx", 8)
+	if p, _ = Lookup(101); p.Ppid != 1 || p.StartNs() != 90*sec {
+		t.Errorf("reused pid = %+v", p)
+	}
+	if _, ok := Lookup(4242); ok {
+		t.Error("unknown pid found")
+	}
+}
+
+func TestAncestors(t *testing.T) {
+	kernelFed(t)
+	Exec(1, 0, 1*sec, "systemd", "/sbin/init", 0)
+	Exec(640, 1, 5*sec, "sshd", "/usr/sbin/sshd", 0)
+	Exec(812, 640, 50*sec, "bash", "/usr/bin/bash", 0)
+	Exec(900, 812, 60*sec, "nc", "/usr/bin/nc", 0)
+
+	chain := Ancestors(900)
+	if got := FormatLineage(chain); got != "bash(812) ← sshd(640) ← systemd(1)" {
+		t.Errorf("lineage = %q", got)
+	}
+
+	// Ancestors that exited are still reported
+	Exit(812, 50*sec, time.Now())
+	if chain = Ancestors(900); len(chain) != 3 || !chain[0].Exited {
+		t.Errorf("chain after parent exit = %+v", chain)
+	}
+	// Exit of an older process under the same pid does not touch the current one
+	Exit(640, 999*sec, time.Now())
+	if p, _ := Lookup(640); p.Exited {
+		t.Error("exit with another start time marked the process exited")
+	}
+
+	// A parent younger than its child is a reused pid: the walk stops there
+	Exec(640, 1, 55*sec, "cron", "/usr/sbin/cron", 0)
+	if got := Lineage(900); got != "bash(812)" {
+		t.Errorf("lineage across a reused pid = %q", got)
+	}
+	if FormatLineage(nil) != "" {
+		t.Error("empty chain rendered")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	kernelFed(t)
+	now := time.Now()
+	Exec(200, 1, 1*sec, "sh", "/bin/sh", 0)
+	Fork(200, 201, 2*sec, "sh", 0)
+	Exec(300, 1, 3*sec, "curl", "/usr/bin/curl", 0)
+	Exit(200, 1*sec, now)
+	Exit(300, 3*sec, now)
+
+	prune(now.Add(exitedGrace / 2))
+	if _, ok := Lookup(300); !ok {
+		t.Fatal("exited process pruned within the grace period")
+	}
+	prune(now.Add(exitedGrace + time.Minute))
+	if _, ok := Lookup(300); ok {
+		t.Error("exited process kept past the grace period")
+	}
+	// 200 exited long ago but its child still runs
+	if _, ok := Lookup(200); !ok {
+		t.Error("ancestor of a live process pruned")
+	}
+}
+
+func TestReadStat(t *testing.T) {
+	pid := uint32(os.Getpid())
+	ppid, comm, start, ok := readStat(pid)
+	if !ok || ppid != uint32(os.Getppid()) || comm == "" || start == 0 {
+		t.Fatalf("readStat(self) = %d, %q, %d, %v", ppid, comm, start, ok)
+	}
+	p, ok := Read(pid)
+	if !ok || !Running(p) {
+		t.Errorf("Read(self) = %+v, running %v", p, Running(p))
+	}
+	if p.Exited = true; Running(p) {
+		t.Error("exited process reported running")
+	}
+}
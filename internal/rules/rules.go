@@ -0,0 +1,341 @@
+// Package rules is the declarative detection engine: YAML rules matched against
+// runtime events decide what is ignored, alerted on or killed.
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Action is what happens to an event a rule matches
+type Action string
+
+const (
+	ActionIgnore Action = "ignore" // allow list / noise: the event is dropped
+	ActionAlert  Action = "alert"
+	ActionKill   Action = "kill"
+)
+
+// Where a rule applies: host processes or container processes (empty: both)
+const (
+	ScopeHost       = "host"
+	ScopeContainers = "containers"
+)
+
+// Event types a rule can be limited to (same names as the telemetry labels)
+var eventTypes = map[string]bool{"exec": true, "connect": true, "open": true, "cred": true, "blocked": true, "fileless": true}
+
+var severities = map[string]bool{"LOW": true, "MEDIUM": true, "HIGH": true, "CRITICAL": true}
+
+// Roles a process can have in its container (see Ancestor.Roles)
+const (
+	RoleMain       = "main"       // the image's main process: the container's init process and its workers
+	RoleEntrypoint = "entrypoint" // the program the image is configured to start
+)
+
+// Spawn verdicts of an exec started by a service's main process
+const (
+	SpawnAllowed = "allowed"
+	SpawnDenied  = "denied"
+)
+
+// Ancestor is one link of the parent chain of an event
+type Ancestor struct {
+	Comm    string   `yaml:"comm" json:"comm"`
+	Exe     string   `yaml:"exe,omitempty" json:"exe,omitempty"`
+	Trust   string   `yaml:"trust,omitempty" json:"trust,omitempty"`     // allowlist name, "" when untrusted
+	Roles   []string `yaml:"roles,omitempty" json:"roles,omitempty"`     // main, entrypoint (container processes only)
+	Classes []string `yaml:"classes,omitempty" json:"classes,omitempty"` // classes of Comm, filled in by Set.Evaluate
+}
+
+// Input is the view of an event that rules match on
+type Input struct {
+	Event       string     `yaml:"event" json:"event"`
+	Comm        string     `yaml:"comm" json:"comm"`
+	Exe         string     `yaml:"exe,omitempty" json:"exe,omitempty"`     // executed file (exec) or process image
+	Trust       string     `yaml:"trust,omitempty" json:"trust,omitempty"` // allowlist name of Exe (see internal/trust)
+	Argv        []string   `yaml:"argv,omitempty" json:"argv,omitempty"`
+	Cwd         string     `yaml:"cwd,omitempty" json:"cwd,omitempty"`
+	Uid         uint32     `yaml:"uid" json:"uid"`
+	TTY         bool       `yaml:"tty" json:"tty"`
+	Container   string     `yaml:"container,omitempty" json:"container,omitempty"`
+	Service     string     `yaml:"service,omitempty" json:"service,omitempty"` // "" for host processes
+	Image       string     `yaml:"image,omitempty" json:"image,omitempty"`
+	Ancestors   []Ancestor `yaml:"ancestors,omitempty" json:"ancestors,omitempty"` // nearest first
+	Path        string     `yaml:"path,omitempty" json:"path,omitempty"`           // open and blocked events
+	DestIP      string     `yaml:"dest_ip,omitempty" json:"dest_ip,omitempty"`
+	DestPort    uint16     `yaml:"dest_port,omitempty" json:"dest_port,omitempty"`
+	Fileless    []string   `yaml:"fileless,omitempty" json:"fileless,omitempty"`         // memfd, unlinked, tmpfs, tmpdir
+	StdioRemote string     `yaml:"stdio_remote,omitempty" json:"stdio_remote,omitempty"` // peer (host:port) of a network socket on stdin/stdout/stderr (exec)
+	Classes     []string   `yaml:"classes,omitempty" json:"classes,omitempty"`           // classes of Comm, filled in by Set.Evaluate
+	Spawn       string     `yaml:"spawn,omitempty" json:"spawn,omitempty"`               // exec by a main process: allowed or denied by the service's spawn profile
+}
+
+// fields maps every matchable field to its values on an Input. Multi-valued
+// fields (argv, ancestor, ...) match when any value does.
+var fields = map[string]func(in *Input) []string{
+	"event":     func(in *Input) []string { return []string{in.Event} },
+	"comm":      func(in *Input) []string { return []string{in.Comm} },
+	"exe":       func(in *Input) []string { return []string{in.Exe} },
+	"argv":      func(in *Input) []string { return in.Argv },
+	"cmdline":   func(in *Input) []string { return []string{strings.Join(in.Argv, " ")} },
+	"args":      func(in *Input) []string { return []string{strings.Join(tail(in.Argv), " ")} },
+	"cwd":       func(in *Input) []string { return []string{in.Cwd} },
+	"uid":       func(in *Input) []string { return []string{strconv.FormatUint(uint64(in.Uid), 10)} },
+	"tty":       func(in *Input) []string { return []string{strconv.FormatBool(in.TTY)} },
+	"container": func(in *Input) []string { return []string{in.Container} },
+	"service":   func(in *Input) []string { return []string{in.Service} },
+	"image":     func(in *Input) []string { return []string{in.Image} },
+	"trust":     func(in *Input) []string { return []string{in.Trust} },
+	"parent": func(in *Input) []string {
+		if len(in.Ancestors) == 0 {
+			return []string{""}
+		}
+		return []string{in.Ancestors[0].Comm}
+	},
+	"parent_exe": func(in *Input) []string {
+		if len(in.Ancestors) == 0 {
+			return []string{""}
+		}
+		return []string{in.Ancestors[0].Exe}
+	},
+	"parent_trust": func(in *Input) []string {
+		if len(in.Ancestors) == 0 {
+			return []string{""}
+		}
+		return []string{in.Ancestors[0].Trust}
+	},
+	"parent_role": func(in *Input) []string {
+		if len(in.Ancestors) == 0 || len(in.Ancestors[0].Roles) == 0 {
+			return []string{""}
+		}
+		return in.Ancestors[0].Roles
+	},
+	"parent_class": func(in *Input) []string {
+		if len(in.Ancestors) == 0 {
+			return nil
+		}
+		return in.Ancestors[0].Classes
+	},
+	"ancestor": func(in *Input) []string {
+		out := make([]string, len(in.Ancestors))
+		for i, a := range in.Ancestors {
+			out[i] = a.Comm
+		}
+		return out
+	},
+	"ancestor_exe": func(in *Input) []string {
+		out := make([]string, len(in.Ancestors))
+		for i, a := range in.Ancestors {
+			out[i] = a.Exe
+		}
+		return out
+	},
+	"ancestor_trust": func(in *Input) []string {
+		out := make([]string, len(in.Ancestors))
+		for i, a := range in.Ancestors {
+			out[i] = a.Trust
+		}
+		return out
+	},
+	"ancestor_role": func(in *Input) []string {
+		var out []string
+		for _, a := range in.Ancestors {
+			out = append(out, a.Roles...)
+		}
+		return out
+	},
+	"ancestor_class": func(in *Input) []string {
+		var out []string
+		for _, a := range in.Ancestors {
+			out = append(out, a.Classes...)
+		}
+		return out
+	},
+	"class":        func(in *Input) []string { return in.Classes },
+	"spawn":        func(in *Input) []string { return []string{in.Spawn} },
+	"path":         func(in *Input) []string { return []string{in.Path} },
+	"dest_ip":      func(in *Input) []string { return []string{in.DestIP} },
+	"dest_port":    func(in *Input) []string { return []string{strconv.FormatUint(uint64(in.DestPort), 10)} },
+	"fileless":     func(in *Input) []string { return in.Fileless },
+	"stdio_remote": func(in *Input) []string { return []string{in.StdioRemote} },
+}
+
+func tail(argv []string) []string {
+	if len(argv) < 2 {
+		return nil
+	}
+	return argv[1:]
+}
+
+// Condition is one field test; exactly one of exact, glob or regex is set and
+// any of its values may match
+type Condition struct {
+	Field string   `yaml:"field" json:"field"`
+	Exact []string `yaml:"exact,omitempty" json:"exact,omitempty"`
+	Glob  []string `yaml:"glob,omitempty" json:"glob,omitempty"` // '*' matches any run of characters (including '/'), '?' one
+	Regex []string `yaml:"regex,omitempty" json:"regex,omitempty"`
+	Not   bool     `yaml:"not,omitempty" json:"not,omitempty"`
+
+	patterns []*regexp.Regexp // glob and regex, compiled
+}
+
+func (c *Condition) compile() error {
+	get, ok := fields[c.Field]
+	if !ok || get == nil {
+		return fmt.Errorf("unknown field '%s'", c.Field)
+	}
+	set := 0
+	for _, vals := range [][]string{c.Exact, c.Glob, c.Regex} {
+		if len(vals) > 0 {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("field '%s': exactly one of exact, glob or regex is required", c.Field)
+	}
+	c.patterns = nil
+	for _, g := range c.Glob {
+		c.patterns = append(c.patterns, globRegexp(g))
+	}
+	for _, r := range c.Regex {
+		re, err := regexp.Compile(r)
+		if err != nil {
+			return fmt.Errorf("field '%s': %v", c.Field, err)
+		}
+		c.patterns = append(c.patterns, re)
+	}
+	return nil
+}
+
+func (c *Condition) matches(in *Input) bool {
+	hit := false
+	for _, v := range fields[c.Field](in) {
+		if c.matchValue(v) {
+			hit = true
+			break
+		}
+	}
+	return hit != c.Not
+}
+
+func (c *Condition) matchValue(v string) bool {
+	for _, e := range c.Exact {
+		if v == e {
+			return true
+		}
+	}
+	for _, re := range c.patterns {
+		if re.MatchString(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// globRegexp anchors a glob; '*' and '?' are the only wildcards
+func globRegexp(g string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range g {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// Rule is one detection rule
+type Rule struct {
+	ID          string      `yaml:"id" json:"id"`
+	Description string      `yaml:"description,omitempty" json:"description,omitempty"`
+	Events      []string    `yaml:"events,omitempty" json:"events,omitempty"` // empty: every event type
+	Match       []Condition `yaml:"match" json:"match"`                       // all must hold
+	Action      Action      `yaml:"action" json:"action"`
+	Severity    string      `yaml:"severity,omitempty" json:"severity,omitempty"`
+	Mitre       string      `yaml:"mitre,omitempty" json:"mitre,omitempty"`       // ATT&CK technique, e.g. T1059.004
+	Services    []string    `yaml:"services,omitempty" json:"services,omitempty"` // scope; empty: everywhere incl. host
+	Scope       string      `yaml:"scope,omitempty" json:"scope,omitempty"`       // host or containers; empty: both
+	Disabled    bool        `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+
+	File string `yaml:"-" json:"file"` // where the rule was loaded from
+}
+
+func (r *Rule) validate() error {
+	if r.ID == "" {
+		return fmt.Errorf("rule without id")
+	}
+	if r.Disabled {
+		return nil // only overrides a rule of the same id
+	}
+	switch r.Action {
+	case ActionIgnore:
+	case ActionAlert, ActionKill:
+		r.Severity = strings.ToUpper(r.Severity)
+		if !severities[r.Severity] {
+			return fmt.Errorf("rule %s: severity must be LOW, MEDIUM, HIGH or CRITICAL", r.ID)
+		}
+	default:
+		return fmt.Errorf("rule %s: action must be ignore, alert or kill", r.ID)
+	}
+	switch {
+	case r.Scope != "" && r.Scope != ScopeHost && r.Scope != ScopeContainers:
+		return fmt.Errorf("rule %s: scope must be host or containers", r.ID)
+	case r.Scope == ScopeHost && len(r.Services) > 0:
+		return fmt.Errorf("rule %s: services never match host processes", r.ID)
+	}
+	for _, e := range r.Events {
+		if !eventTypes[e] {
+			return fmt.Errorf("rule %s: unknown event type '%s'", r.ID, e)
+		}
+	}
+	if len(r.Match) == 0 {
+		return fmt.Errorf("rule %s: empty match (would match every event)", r.ID)
+	}
+	for i := range r.Match {
+		if err := r.Match[i].compile(); err != nil {
+			return fmt.Errorf("rule %s: %v", r.ID, err)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the rule applies to the event
+func (r *Rule) Matches(in *Input) bool {
+	if r.Disabled {
+		return false
+	}
+	if len(r.Events) > 0 && !contains(r.Events, in.Event) {
+		return false
+	}
+	if len(r.Services) > 0 && !contains(r.Services, in.Service) {
+		return false
+	}
+	host := in.Container == ""
+	if (r.Scope == ScopeHost && !host) || (r.Scope == ScopeContainers && host) {
+		return false
+	}
+	for i := range r.Match {
+		if !r.Match[i].matches(in) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
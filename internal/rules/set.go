@@ -0,0 +1,345 @@
+package rules
+
+import (
+	"bytes"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ReloadInterval is how often the rules directory is checked for changes
+const ReloadInterval = 5 * time.Second
+
+//go:embed default.yaml
+var defaultRules []byte
+
+// DefaultFile names the built-in rules in Rule.File
+const DefaultFile = "<built-in>"
+
+type ruleFile struct {
+	Rules   []Rule              `yaml:"rules"`
+	Classes map[string][]string `yaml:"classes"`
+}
+
+// File is one parsed rules file
+type File struct {
+	Rules []*Rule
+	// Classes name groups of processes by comm (exact names or globs), e.g.
+	// web_server: [nginx, httpd]; rules match them with class, parent_class, ancestor_class
+	Classes map[string][]string
+}
+
+// Set is one loaded generation of rules. Ignore rules are checked first, then
+// alert/kill rules in load order: the built-in rules, then files sorted by name.
+// A file rule with the id of an earlier rule replaces it in place; a class with
+// the name of an earlier class replaces it.
+type Set struct {
+	Rules   []*Rule
+	Classes map[string][]string
+	Files   []string
+	Loaded  time.Time
+	Version string // fingerprint of the rules and classes, stored with every detection
+
+	classes map[string][]*regexp.Regexp // compiled Classes
+}
+
+// className keeps class names plain identifiers, usable as rule values
+var className = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Parse reads one rules file
+func Parse(data []byte, file string) (*File, error) {
+	var rf ruleFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&rf); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	seen := map[string]bool{}
+	out := &File{Rules: make([]*Rule, 0, len(rf.Rules)), Classes: rf.Classes}
+	for i := range rf.Rules {
+		r := &rf.Rules[i]
+		r.File = file
+		if err := r.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		if seen[r.ID] {
+			return nil, fmt.Errorf("%s: duplicate rule id '%s'", file, r.ID)
+		}
+		seen[r.ID] = true
+		out.Rules = append(out.Rules, r)
+	}
+	for name, comms := range rf.Classes {
+		if !className.MatchString(name) {
+			return nil, fmt.Errorf("%s: class name '%s' must be lower case letters, digits and '_'", file, name)
+		}
+		for _, c := range comms {
+			if c == "" {
+				return nil, fmt.Errorf("%s: class %s: empty process name", file, name)
+			}
+		}
+	}
+	return out, nil
+}
+
+// Builtin parses the built-in rules
+func Builtin() (*File, error) {
+	return Parse(defaultRules, DefaultFile)
+}
+
+// NewSet builds a set from rules files, later files overriding earlier ones by
+// rule id and class name
+func NewSet(files ...*File) *Set {
+	s := &Set{Loaded: time.Now(), Classes: map[string][]string{}, classes: map[string][]*regexp.Regexp{}}
+	index := map[string]int{}
+	for _, f := range files {
+		for _, r := range f.Rules {
+			if i, ok := index[r.ID]; ok {
+				s.Rules[i] = r
+				continue
+			}
+			index[r.ID] = len(s.Rules)
+			s.Rules = append(s.Rules, r)
+		}
+		for name, comms := range f.Classes {
+			s.Classes[name] = comms
+		}
+	}
+	for name, comms := range s.Classes {
+		for _, c := range comms {
+			s.classes[name] = append(s.classes[name], globRegexp(c))
+		}
+	}
+	data, _ := json.Marshal(struct {
+		Rules   []*Rule
+		Classes map[string][]string
+	}{s.Rules, s.Classes})
+	sum := sha256.Sum256(data)
+	s.Version = "rules-" + hex.EncodeToString(sum[:6])
+	return s
+}
+
+// Load reads the built-in rules plus every *.yaml / *.yml in dir. A missing
+// directory is not an error: the built-in rules apply alone.
+func Load(dir string) (*Set, error) {
+	builtin, err := Builtin()
+	if err != nil {
+		return nil, err
+	}
+	lists := []*File{builtin}
+	files := []string{DefaultFile}
+
+	paths, err := ruleFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		list, err := Parse(data, p)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+		files = append(files, p)
+	}
+	s := NewSet(lists...)
+	s.Files = files
+	return s, nil
+}
+
+func ruleFiles(dir string) ([]string, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if !e.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			paths = append(paths, filepath.Join(dir, e.Name()))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// ClassesOf returns the classes a process name belongs to, sorted
+func (s *Set) ClassesOf(comm string) []string {
+	var out []string
+	for name, patterns := range s.classes {
+		for _, re := range patterns {
+			if re.MatchString(comm) {
+				out = append(out, name)
+				break
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// classify fills in the classes of the event and its ancestors, unless the
+// input already carries them (rule test samples)
+func (s *Set) classify(in *Input) {
+	if in.Classes == nil {
+		in.Classes = s.ClassesOf(in.Comm)
+	}
+	for i := range in.Ancestors {
+		if in.Ancestors[i].Classes == nil {
+			in.Ancestors[i].Classes = s.ClassesOf(in.Ancestors[i].Comm)
+		}
+	}
+}
+
+// Evaluate returns the rule deciding the event, or nil when none matches
+func (s *Set) Evaluate(in *Input) *Rule {
+	s.classify(in)
+	for _, r := range s.Rules {
+		if r.Action == ActionIgnore && r.Matches(in) {
+			return r
+		}
+	}
+	for _, r := range s.Rules {
+		if r.Action != ActionIgnore && r.Matches(in) {
+			return r
+		}
+	}
+	return nil
+}
+
+// CommPrefilter returns the comm patterns whose execs can be dropped in the kernel:
+// ignore rules covering exec, without service or host/container scope, whose only
+// condition is a comm exact match or "prefix*" glob. Exact names are returned with
+// a trailing NUL.
+// Other event types keep going through the rule in userspace.
+func (s *Set) CommPrefilter() []string {
+	var out []string
+	for _, r := range s.Rules {
+		if r.Disabled || r.Action != ActionIgnore || len(r.Services) > 0 || r.Scope != "" || len(r.Match) != 1 {
+			continue
+		}
+		if len(r.Events) > 0 && !contains(r.Events, "exec") {
+			continue
+		}
+		c := r.Match[0]
+		if c.Field != "comm" || c.Not || len(c.Regex) > 0 {
+			continue
+		}
+		var pats []string
+		for _, e := range c.Exact {
+			pats = append(pats, e+"\x00")
+		}
+		for _, g := range c.Glob {
+			prefix, ok := strings.CutSuffix(g, "*")
+			if !ok || prefix == "" || strings.ContainsAny(prefix, "*?") {
+				pats = nil
+				break
+			}
+			pats = append(pats, prefix)
+		}
+		if len(pats) == len(c.Exact)+len(c.Glob) {
+			out = append(out, pats...)
+		}
+	}
+	return out
+}
+
+var (
+	current     atomic.Pointer[Set]
+	builtinOnce sync.Once
+)
+
+// Current is the active rule set (the built-in rules until Init succeeds)
+func Current() *Set {
+	if s := current.Load(); s != nil {
+		return s
+	}
+	builtinOnce.Do(func() {
+		if current.Load() != nil {
+			return
+		}
+		s, err := Load("")
+		if err != nil {
+			log.Fatalf("[CRITICAL] Built-in rules are invalid: %v", err)
+		}
+		current.CompareAndSwap(nil, s)
+	})
+	return current.Load()
+}
+
+// Evaluate checks an event against the active rule set
+func Evaluate(in *Input) *Rule {
+	return Current().Evaluate(in)
+}
+
+// Init loads the rules directory; on error the built-in rules stay active
+func Init(dir string) error {
+	s, err := Load(dir)
+	if err != nil {
+		Current()
+		return err
+	}
+	current.Store(s)
+	return nil
+}
+
+// Watch reloads the rules whenever a file in dir changes, until done is closed.
+// A broken edit is reported and the previous rules stay active.
+func Watch(dir string, done <-chan struct{}) {
+	last := signature(dir)
+	tick := time.NewTicker(ReloadInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-tick.C:
+		}
+		sig := signature(dir)
+		if sig == last {
+			continue
+		}
+		last = sig
+		s, err := Load(dir)
+		if err != nil {
+			log.Printf("[RULES] ⚠️ Reload failed, keeping previous rules: %v", err)
+			continue
+		}
+		current.Store(s)
+		log.Printf("[RULES] 🔄 Reloaded %d rules from %d file(s) (%s)", len(s.Rules), len(s.Files), s.Version)
+	}
+}
+
+// signature changes whenever a rule file is added, removed or modified
+func signature(dir string) string {
+	paths, _ := ruleFiles(dir)
+	var b strings.Builder
+	for _, p := range paths {
+		if st, err := os.Stat(p); err == nil {
+			fmt.Fprintf(&b, "%s|%d|%d;", p, st.Size(), st.ModTime().UnixNano())
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,258 @@
+package rules
+
+import (
+	"slices"
+	"testing"
+)
+
+func builtinSet(t *testing.T, extra ...string) *Set {
+	t.Helper()
+	b, err := Builtin()
+	if err != nil {
+		t.Fatalf("built-in rules: %v", err)
+	}
+	files := []*File{b}
+	for _, data := range extra {
+		f, err := Parse([]byte(data), "test.yaml")
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		files = append(files, f)
+	}
+	return NewSet(files...)
+}
+
+func TestEvaluateBuiltin(t *testing.T) {
+	nginx := Ancestor{Comm: "nginx", Exe: "/usr/sbin/nginx", Roles: []string{RoleMain}}
+	tests := []struct {
+		name string
+		in   Input
+		want string // rule id, "" for no match
+	}{
+		{"trusted binary", Input{Event: "exec", Comm: "runc", Trust: "runc"}, "allow.platform-tools"},
+		{"child of a trusted binary", Input{Event: "exec", Comm: "ls", Ancestors: []Ancestor{{Comm: "sshd", Trust: "sshd"}}}, "allow.platform-children"},
+		{"shell running a command", Input{Event: "exec", Comm: "sh", Argv: []string{"sh", "-c", "date"}, Container: "web-1"}, "noise.shell-command"},
+		{"interactive shell", Input{Event: "exec", Comm: "bash", Argv: []string{"bash"}, TTY: true, Container: "web-1"}, "exec.interactive-shell"},
+		{"reverse shell", Input{Event: "exec", Comm: "sh", Argv: []string{"sh", "-c", "id"}, StdioRemote: "10.0.0.9:4444", Container: "web-1"}, "exec.reverse-shell"},
+		{"versioned python reverse shell", Input{Event: "exec", Comm: "python3.11", StdioRemote: "10.0.0.9:4444", Container: "web-1"}, "exec.reverse-shell"},
+		{"versioned php reverse shell", Input{Event: "exec", Comm: "php8.2", StdioRemote: "10.0.0.9:4444", Container: "web-1"}, "exec.reverse-shell"},
+		{"versioned lua reverse shell", Input{Event: "exec", Comm: "lua5.4", StdioRemote: "10.0.0.9:4444", Container: "web-1"}, "exec.reverse-shell"},
+		{"java reverse shell", Input{Event: "exec", Comm: "java", StdioRemote: "10.0.0.9:4444", Container: "web-1"}, "exec.reverse-shell"},
+		{"interpreter after fork", Input{Event: "exec", Comm: "bash", Exe: "/usr/bin/python3.12", Classes: []string{"interpreter"}, StdioRemote: "10.0.0.9:4444", Container: "web-1"}, "exec.reverse-shell"},
+		{"download tool", Input{Event: "exec", Comm: "curl", Container: "web-1"}, "exec.tool-transfer"},
+		{"unremarkable exec", Input{Event: "exec", Comm: "ls", Container: "web-1"}, ""},
+		{"host noise", Input{Event: "exec", Comm: "gopls"}, "noise.desktop"},
+		{"host noise rule is host-only", Input{Event: "exec", Comm: "gopls", Container: "web-1"}, ""},
+
+		// Fork then exec: comm is still the caller's, Exe and Classes are the new image's
+		{"web shell after fork", Input{Event: "exec", Comm: "nginx", Exe: "/bin/sh", Argv: []string{"sh", "-c", "id"}, Classes: []string{"shell"}, Spawn: SpawnDenied,
+			Container: "web-1", Ancestors: []Ancestor{nginx}}, "lineage.server-spawn"},
+		{"web shell with a shell comm", Input{Event: "exec", Comm: "sh", Argv: []string{"sh", "-c", "id"}, Spawn: SpawnDenied,
+			Container: "web-1", Ancestors: []Ancestor{nginx}}, "lineage.server-spawn"},
+		{"spawn allowed by the service", Input{Event: "exec", Comm: "nginx", Exe: "/bin/sh", Argv: []string{"sh", "-c", "id"}, Classes: []string{"shell"}, Spawn: SpawnAllowed,
+			Container: "web-1", Ancestors: []Ancestor{nginx}}, ""},
+		{"spawn profile", Input{Event: "exec", Comm: "php-fpm", Exe: "/usr/bin/convert", Classes: []string{}, Spawn: SpawnDenied,
+			Container: "app-1", Ancestors: []Ancestor{{Comm: "php-fpm", Roles: []string{RoleMain}}}}, "lineage.spawn-profile"},
+		{"shell from the entrypoint", Input{Event: "exec", Comm: "sh", Argv: []string{"sh", "-c", "exec nginx"}, Classes: []string{"shell"},
+			Container: "web-1", Ancestors: []Ancestor{{Comm: "docker-entrypoi", Roles: []string{RoleEntrypoint}}}}, "noise.shell-command"},
+
+		{"fileless exec", Input{Event: "fileless", Comm: "3", Fileless: []string{"memfd"}}, "exec.fileless"},
+		{"go run binary", Input{Event: "fileless", Comm: "main", Fileless: []string{"tmpdir"}, Ancestors: []Ancestor{{Comm: "go", Trust: "go"}}}, "allow.fileless-go-run"},
+	}
+	s := builtinSet(t)
+	for _, tt := range tests {
+		in := tt.in
+		got := ""
+		if r := s.Evaluate(&in); r != nil {
+			got = r.ID
+		}
+		if got != tt.want {
+			t.Errorf("%s: rule %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestClasses(t *testing.T) {
+	s := builtinSet(t, `
+classes:
+  web_server: [myserver, "gunicorn*"]
+  batch: [worker]
+`)
+	tests := []struct {
+		comm string
+		want []string
+	}{
+		{"myserver", []string{"web_server"}},
+		{"gunicorn: work", []string{"web_server"}},
+		{"nginx", nil}, // the file's web_server replaces the built-in one
+		{"bash", []string{"shell"}},
+		{"worker", []string{"batch"}},
+		{"python3.12", []string{"interpreter"}},
+	}
+	for _, tt := range tests {
+		if got := s.ClassesOf(tt.comm); !slices.Equal(got, tt.want) {
+			t.Errorf("ClassesOf(%q) = %v, want %v", tt.comm, got, tt.want)
+		}
+	}
+
+	// Evaluate classifies the event and its ancestors from their comm
+	in := Input{Event: "exec", Comm: "sh", TTY: true, Spawn: SpawnDenied, Container: "api-1",
+		Ancestors: []Ancestor{{Comm: "myserver", Roles: []string{RoleMain}}}}
+	if r := s.Evaluate(&in); r == nil || r.ID != "lineage.server-spawn" {
+		t.Errorf("shell under an overridden web_server: got %v, want lineage.server-spawn", r)
+	}
+	if !slices.Equal(in.Ancestors[0].Classes, []string{"web_server"}) {
+		t.Errorf("parent classes = %v, want [web_server]", in.Ancestors[0].Classes)
+	}
+
+	if builtinSet(t).Version == s.Version {
+		t.Error("rules version does not change with the classes")
+	}
+	if _, err := Parse([]byte("classes:\n  Web-Server: [nginx]\n"), "bad.yaml"); err == nil {
+		t.Error("class name with upper case and '-' accepted")
+	}
+	if _, err := Parse([]byte("classes:\n  web: [\"\"]\n"), "bad.yaml"); err == nil {
+		t.Error("empty process name accepted in a class")
+	}
+}
+
+func TestCommPrefilter(t *testing.T) {
+	f, err := Parse([]byte(`
+rules:
+  - id: exact
+    events: [exec]
+    match: [{field: comm, exact: [cron, anacron]}]
+    action: ignore
+  - id: prefix
+    match: [{field: comm, glob: ["kworker*"]}]
+    action: ignore
+  - id: inner-wildcard
+    events: [exec]
+    match: [{field: comm, glob: ["k?ctl*"]}]
+    action: ignore
+  - id: two-conditions
+    events: [exec]
+    match: [{field: comm, exact: [sh]}, {field: tty, exact: ["false"]}]
+    action: ignore
+  - id: service-scoped
+    events: [exec]
+    services: [web]
+    match: [{field: comm, exact: [nginx]}]
+    action: ignore
+  - id: host-scoped
+    events: [exec]
+    scope: host
+    match: [{field: comm, exact: [gopls]}]
+    action: ignore
+  - id: negated
+    events: [exec]
+    match: [{field: comm, exact: [sshd], not: true}]
+    action: ignore
+  - id: other-event
+    events: [open]
+    match: [{field: comm, exact: [updatedb]}]
+    action: ignore
+  - id: not-comm
+    events: [exec]
+    match: [{field: exe, exact: [/usr/bin/true]}]
+    action: ignore
+  - id: alert
+    events: [exec]
+    match: [{field: comm, exact: [nc]}]
+    action: alert
+    severity: HIGH
+`), "test.yaml")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	got := NewSet(f).CommPrefilter()
+	want := []string{"cron\x00", "anacron\x00", "kworker"}
+	if !slices.Equal(got, want) {
+		t.Errorf("CommPrefilter() = %q, want %q", got, want)
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		name, data string
+	}{
+		{"no id", "rules:\n  - match: [{field: comm, exact: [sh]}]\n    action: ignore\n"},
+		{"unknown field", "rules:\n  - id: a\n    match: [{field: pid, exact: ['1']}]\n    action: ignore\n"},
+		{"two matchers", "rules:\n  - id: a\n    match: [{field: comm, exact: [sh], glob: [\"b*\"]}]\n    action: ignore\n"},
+		{"bad regex", "rules:\n  - id: a\n    match: [{field: comm, regex: [\"(\"]}]\n    action: ignore\n"},
+		{"empty match", "rules:\n  - id: a\n    match: []\n    action: ignore\n"},
+		{"alert without severity", "rules:\n  - id: a\n    match: [{field: comm, exact: [sh]}]\n    action: alert\n"},
+		{"unknown action", "rules:\n  - id: a\n    match: [{field: comm, exact: [sh]}]\n    action: block\n"},
+		{"unknown event", "rules:\n  - id: a\n    events: [mmap]\n    match: [{field: comm, exact: [sh]}]\n    action: ignore\n"},
+		{"unknown scope", "rules:\n  - id: a\n    scope: pods\n    match: [{field: comm, exact: [sh]}]\n    action: ignore\n"},
+		{"services on host scope", "rules:\n  - id: a\n    scope: host\n    services: [web]\n    match: [{field: comm, exact: [sh]}]\n    action: ignore\n"},
+		{"duplicate id", "rules:\n  - id: a\n    match: [{field: comm, exact: [sh]}]\n    action: ignore\n  - id: a\n    match: [{field: comm, exact: [ls]}]\n    action: ignore\n"},
+		{"unknown key", "rules:\n  - id: a\n    match: [{field: comm, exact: [sh]}]\n    action: ignore\n    severty: HIGH\n"},
+	}
+	for _, tt := range tests {
+		if _, err := Parse([]byte(tt.data), "bad.yaml"); err == nil {
+			t.Errorf("%s: accepted", tt.name)
+		}
+	}
+}
+
+func TestRuleScope(t *testing.T) {
+	s := builtinSet(t, `
+rules:
+  - id: host-only
+    scope: host
+    match: [{field: comm, exact: [pprof]}]
+    action: alert
+    severity: LOW
+  - id: containers-only
+    scope: containers
+    match: [{field: comm, exact: [pprof]}]
+    action: alert
+    severity: HIGH
+`)
+	host := s.Evaluate(&Input{Event: "exec", Comm: "pprof"})
+	container := s.Evaluate(&Input{Event: "exec", Comm: "pprof", Container: "web-1", Service: "web"})
+	if host == nil || host.ID != "host-only" {
+		t.Errorf("host process matched %v, want host-only", host)
+	}
+	if container == nil || container.ID != "containers-only" {
+		t.Errorf("container process matched %v, want containers-only", container)
+	}
+
+	// The host noise of the built-in policy never hides container execs
+	if r := s.Evaluate(&Input{Event: "exec", Comm: "ps"}); r == nil || r.ID != "noise.tools" {
+		t.Errorf("host ps matched %v, want noise.tools", r)
+	}
+	if r := s.Evaluate(&Input{Event: "exec", Comm: "ps", Container: "web-1"}); r != nil && r.ID == "noise.tools" {
+		t.Error("container ps ignored by the host policy")
+	}
+}
+
+func TestConditionMatch(t *testing.T) {
+	in := &Input{Comm: "python3", Exe: "/usr/local/bin/python3.12", Argv: []string{"python3", "-c", "import os"},
+		Ancestors: []Ancestor{{Comm: "bash"}, {Comm: "sshd"}}}
+	tests := []struct {
+		c    Condition
+		want bool
+	}{
+		{Condition{Field: "comm", Exact: []string{"python3"}}, true},
+		{Condition{Field: "comm", Exact: []string{"python3"}, Not: true}, false},
+		{Condition{Field: "exe", Glob: []string{"*/python3.?*"}}, true},
+		{Condition{Field: "exe", Glob: []string{"/usr/bin/*"}}, false},
+		{Condition{Field: "args", Exact: []string{"-c import os"}}, true},
+		{Condition{Field: "argv", Regex: []string{`^import\b`}}, true},
+		{Condition{Field: "ancestor", Exact: []string{"sshd"}}, true},
+		{Condition{Field: "parent", Exact: []string{"sshd"}}, false},
+		{Condition{Field: "parent_role", Exact: []string{""}}, true},
+		{Condition{Field: "ancestor_role", Exact: []string{RoleMain}, Not: true}, true},
+	}
+	for _, tt := range tests {
+		c := tt.c
+		if err := c.compile(); err != nil {
+			t.Fatalf("compile %+v: %v", tt.c, err)
+		}
+		if got := c.matches(in); got != tt.want {
+			t.Errorf("%+v: matches = %v, want %v", tt.c, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package security
+
+import (
+	"fmt"
+	"log"
+	"net/netip"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/orchestrator"
+	"github.com/Debasish-87/aegis-v/internal/platform"
+)
+
+const (
+	// MinLearnWindow and MaxLearnWindow bound a behaviour learning window
+	MinLearnWindow = time.Minute
+	MaxLearnWindow = 30 * 24 * time.Hour
+	// profileFlushInterval is how often learned observations are written to the DB
+	profileFlushInterval = 10 * time.Second
+	// profileMaxEntries caps each profile list so a noisy service cannot grow it unbounded
+	profileMaxEntries = 1024
+)
+
+// Observation kinds of a behaviour profile
+const (
+	observeBinary      = "binary"
+	observeSpawn       = "spawn"
+	observeUser        = "user"
+	observeDestination = "destination"
+)
+
+// ParseLearnWindow validates the learning window of a deployment ("" means none)
+func ParseLearnWindow(v string) (time.Duration, error) {
+	if v == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("learn: %v", err)
+	}
+	if d < MinLearnWindow || d > MaxLearnWindow {
+		return 0, fmt.Errorf("learn: window must be between %s and %s", MinLearnWindow, MaxLearnWindow)
+	}
+	return d, nil
+}
+
+// observation is one fact about a service's behaviour
+type observation struct {
+	kind  string
+	value string
+	spawn platform.Spawn // kind spawn only
+	uid   uint32         // kind user only
+}
+
+func binaryObservation(exe string) observation {
+	return observation{kind: observeBinary, value: exe}
+}
+
+func spawnObservation(parent, child string) observation {
+	return observation{kind: observeSpawn, value: parent + " > " + child, spawn: platform.Spawn{Parent: parent, Child: child}}
+}
+
+func userObservation(uid uint32) observation {
+	return observation{kind: observeUser, value: strconv.FormatUint(uint64(uid), 10), uid: uid}
+}
+
+func destinationObservation(ip netip.Addr, port uint16, proto string) observation {
+	return observation{kind: observeDestination, value: netip.AddrPortFrom(ip, port).String() + "/" + proto}
+}
+
+func (o observation) key() string {
+	return o.kind + "|" + o.value
+}
+
+type profileKey struct {
+	service, imageID string
+}
+
+// profileEntry is the cached profile of one image revision
+type profileEntry struct {
+	profile *platform.BehaviourProfile // nil: nothing learned
+	index   map[string]bool            // observation keys
+	loaded  time.Time
+	dirty   bool // learned observations not yet in the DB
+}
+
+func newProfileEntry(p *platform.BehaviourProfile, now time.Time) *profileEntry {
+	e := &profileEntry{profile: p, index: map[string]bool{}, loaded: now}
+	if p == nil {
+		return e
+	}
+	for _, b := range p.Binaries {
+		e.index[binaryObservation(b).key()] = true
+	}
+	for _, s := range p.Spawns {
+		e.index[spawnObservation(s.Parent, s.Child).key()] = true
+	}
+	for _, u := range p.Users {
+		e.index[userObservation(u).key()] = true
+	}
+	for _, d := range p.Destinations {
+		e.index[observeDestination+"|"+d] = true
+	}
+	return e
+}
+
+// learn adds an observation; false when the profile list is full
+func (e *profileEntry) learn(o observation) bool {
+	p := e.profile
+	switch o.kind {
+	case observeBinary:
+		if len(p.Binaries) >= profileMaxEntries {
+			return false
+		}
+		p.Binaries = append(p.Binaries, o.value)
+	case observeSpawn:
+		if len(p.Spawns) >= profileMaxEntries {
+			return false
+		}
+		p.Spawns = append(p.Spawns, o.spawn)
+	case observeUser:
+		if len(p.Users) >= profileMaxEntries {
+			return false
+		}
+		p.Users = append(p.Users, o.uid)
+	case observeDestination:
+		if len(p.Destinations) >= profileMaxEntries {
+			return false
+		}
+		p.Destinations = append(p.Destinations, o.value)
+	}
+	e.index[o.key()] = true
+	e.dirty = true
+	return true
+}
+
+// baselines caches behaviour profiles per service and image revision
+type baselines struct {
+	mu      sync.Mutex
+	entries map[profileKey]*profileEntry
+}
+
+// profiles is the live monitor's store; replay runs without one
+var profiles = &baselines{entries: map[profileKey]*profileEntry{}}
+
+// entry returns the cached profile, reloading it from the DB after policyCacheTTL
+// unless it holds unsaved observations. Caller holds b.mu.
+func (b *baselines) entry(k profileKey, now time.Time) *profileEntry {
+	if e, ok := b.entries[k]; ok && (e.dirty || now.Sub(e.loaded) < policyCacheTTL) {
+		return e
+	}
+	p, err := platform.LoadProfile(k.service, k.imageID)
+	if err != nil {
+		log.Printf("[GUARDIAN] Could not load behaviour profile of %s: %v", k.service, err)
+	}
+	e := newProfileEntry(p, now)
+	b.entries[k] = e
+	return e
+}
+
+// observe learns the observations while the service is in its learning window and
+// returns the ones the profile has never seen once the window is over
+func (b *baselines) observe(info orchestrator.ContainerInfo, learnUntil time.Time, obs []observation, now time.Time) []observation {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e := b.entry(profileKey{info.Service, info.ImageID}, now)
+	if now.Before(learnUntil) {
+		if e.profile == nil {
+			e.profile = &platform.BehaviourProfile{Service: info.Service, ImageID: info.ImageID, Image: info.Image}
+			e.dirty = true
+		}
+		for _, o := range obs {
+			if !e.index[o.key()] && !e.learn(o) {
+				log.Printf("[GUARDIAN] ⚠️ Behaviour profile of %s is full (%d %s entries); not learning %s", info.Service, profileMaxEntries, o.kind, o.value)
+			}
+		}
+		return nil
+	}
+	if e.profile == nil {
+		return nil // never learned: nothing to enforce
+	}
+	var out []observation
+	for _, o := range obs {
+		if !e.index[o.key()] {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// flush writes learned observations to the DB (one service, or all when service is "").
+// Caller holds b.mu.
+func (b *baselines) flush(service string) {
+	for k, e := range b.entries {
+		if !e.dirty || (service != "" && k.service != service) {
+			continue
+		}
+		if err := platform.SaveProfile(*e.profile); err != nil {
+			log.Printf("[GUARDIAN] Could not save behaviour profile of %s: %v", k.service, err)
+			continue
+		}
+		e.dirty = false
+	}
+}
+
+// flushLoop saves learned observations until done is closed
+func (b *baselines) flushLoop(done <-chan struct{}) {
+	tick := time.NewTicker(profileFlushInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-done:
+			b.mu.Lock()
+			b.flush("")
+			b.mu.Unlock()
+			return
+		case <-tick.C:
+			b.mu.Lock()
+			b.flush("")
+			b.mu.Unlock()
+		}
+	}
+}
+
+// SyncProfiles applies an operator change to a service's profiles (edit, learn,
+// promote): pending observations are saved first, then change runs, then the
+// cached profiles and runtime policy of the service are dropped so it applies at once.
+func SyncProfiles(service string, change func() error) error {
+	b := profiles
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.flush(service)
+	err := change()
+	for k := range b.entries {
+		if k.service == service {
+			delete(b.entries, k)
+		}
+	}
+	runtimeMu.Lock()
+	delete(policies, service)
+	runtimeMu.Unlock()
+	return err
+}
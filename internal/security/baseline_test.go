@@ -0,0 +1,112 @@
+package security
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/guardian"
+	"github.com/Debasish-87/aegis-v/internal/orchestrator"
+	"github.com/Debasish-87/aegis-v/internal/platform"
+)
+
+func TestParseLearnWindow(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"1h", time.Hour, false},
+		{"1m", MinLearnWindow, false},
+		{"720h", MaxLearnWindow, false},
+		{"30s", 0, true},
+		{"721h", 0, true},
+		{"a week", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLearnWindow(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLearnWindow(%q) = %v, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestBaselineLearnThenEnforce(t *testing.T) {
+	platform.OpenTestDB(t)
+	b := &baselines{entries: map[profileKey]*profileEntry{}}
+	info := orchestrator.ContainerInfo{Name: "web-1", Service: "web", ImageID: "sha256:aaa", Image: "nginx:1.27", Managed: true}
+	now := time.Now()
+	learnUntil := now.Add(time.Hour)
+
+	nginx := binaryObservation("/usr/sbin/nginx")
+	dest := destinationObservation(netip.MustParseAddr("10.0.0.5"), 5432, "tcp")
+	if dev := b.observe(info, learnUntil, []observation{nginx, dest, userObservation(101)}, now); dev != nil {
+		t.Fatalf("deviations while learning: %v", dev)
+	}
+	b.mu.Lock()
+	b.flush("")
+	b.mu.Unlock()
+
+	saved, err := platform.LoadProfile("web", "sha256:aaa")
+	if err != nil || saved == nil || len(saved.Binaries) != 1 || saved.Destinations[0] != "10.0.0.5:5432/tcp" || saved.Users[0] != 101 {
+		t.Fatalf("saved profile = %+v, %v", saved, err)
+	}
+
+	// After the window: only what the profile never saw comes back, from a fresh cache too
+	later := now.Add(2 * time.Hour)
+	fresh := &baselines{entries: map[profileKey]*profileEntry{}}
+	shell := spawnObservation("nginx", "sh")
+	dev := fresh.observe(info, learnUntil, []observation{nginx, shell, userObservation(0)}, later)
+	if len(dev) != 2 || dev[0].key() != shell.key() || dev[1].value != "0" {
+		t.Errorf("deviations = %+v", dev)
+	}
+
+	// Another image revision was never learned: nothing to enforce
+	info.ImageID = "sha256:bbb"
+	if dev := fresh.observe(info, learnUntil, []observation{shell}, later); dev != nil {
+		t.Errorf("unlearned revision deviations = %+v", dev)
+	}
+}
+
+func TestProfileEntryFull(t *testing.T) {
+	e := newProfileEntry(&platform.BehaviourProfile{}, time.Now())
+	for i := 0; i < profileMaxEntries; i++ {
+		if !e.learn(userObservation(uint32(i))) {
+			t.Fatalf("entry %d refused", i)
+		}
+	}
+	if e.learn(userObservation(profileMaxEntries)) {
+		t.Error("full profile learned another user")
+	}
+	if !e.learn(binaryObservation("/bin/sh")) {
+		t.Error("a full list blocked another kind")
+	}
+}
+
+func TestCheckBaselineReportsDeviations(t *testing.T) {
+	detectionDB(t)
+	now := time.Now()
+	ctx := containerCtx("dev-web-1", "dev-web", "low")
+	ctx.Container.ImageID = "sha256:dev"
+	ctx.Policy.LearnUntil = now.Add(-time.Minute)
+	platform.SaveProfile(platform.BehaviourProfile{Service: "dev-web", ImageID: "sha256:dev", Binaries: []string{"/usr/sbin/nginx"}, Users: []uint32{101}})
+
+	p := &pipeline{selfPid: 1, respond: &fakeResponder{}, baseline: &baselines{entries: map[profileKey]*profileEntry{}}}
+	p.checkBaseline(ctx, 950, 101, "nginx", now, binaryObservation("/usr/sbin/nginx"), userObservation(101))
+	if n := detections(t, guardian.DetectionDeviation); n != 0 {
+		t.Fatalf("learned behaviour reported: %d deviations", n)
+	}
+	p.checkBaseline(ctx, 951, 0, "nginx", now, binaryObservation("/usr/bin/curl"), userObservation(0))
+	// Repeats within the report window are not stored again
+	p.checkBaseline(ctx, 952, 0, "nginx", now, binaryObservation("/usr/bin/curl"))
+	if n := detections(t, guardian.DetectionDeviation); n != 2 {
+		t.Errorf("%d deviations stored, want 2", n)
+	}
+
+	// Replay runs without a profile store
+	(&pipeline{selfPid: 1, respond: &fakeResponder{}}).checkBaseline(ctx, 953, 0, "nginx", now, binaryObservation("/usr/bin/wget"))
+	if n := detections(t, guardian.DetectionDeviation); n != 2 {
+		t.Errorf("replay stored a deviation")
+	}
+}
@@ -54,15 +54,31 @@ type bpfSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type bpfProgramSpecs struct {
-	TraceExecve *ebpf.ProgramSpec `ebpf:"trace_execve"`
+	CheckExec        *ebpf.ProgramSpec `ebpf:"check_exec"`
+	TraceCommitCreds *ebpf.ProgramSpec `ebpf:"trace_commit_creds"`
+	TraceExecve      *ebpf.ProgramSpec `ebpf:"trace_execve"`
+	TraceOpenat      *ebpf.ProgramSpec `ebpf:"trace_openat"`
+	TraceOpenat2     *ebpf.ProgramSpec `ebpf:"trace_openat2"`
+	TraceSchedExec   *ebpf.ProgramSpec `ebpf:"trace_sched_exec"`
+	TraceSchedExit   *ebpf.ProgramSpec `ebpf:"trace_sched_exit"`
+	TraceSchedFork   *ebpf.ProgramSpec `ebpf:"trace_sched_fork"`
+	TraceTcpConnect  *ebpf.ProgramSpec `ebpf:"trace_tcp_connect"`
+	TraceUdp4Connect *ebpf.ProgramSpec `ebpf:"trace_udp4_connect"`
+	TraceUdp6Connect *ebpf.ProgramSpec `ebpf:"trace_udp6_connect"`
 }
 
 // bpfMapSpecs contains maps before they are loaded into the kernel.
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type bpfMapSpecs struct {
-	FilterMap *ebpf.MapSpec `ebpf:"filter_map"`
-	Rb        *ebpf.MapSpec `ebpf:"rb"`
+	Drops          *ebpf.MapSpec `ebpf:"drops"`
+	ExecDeny       *ebpf.MapSpec `ebpf:"exec_deny"`
+	ExecIgnore     *ebpf.MapSpec `ebpf:"exec_ignore"`
+	Heap           *ebpf.MapSpec `ebpf:"heap"`
+	Rb             *ebpf.MapSpec `ebpf:"rb"`
+	Scope          *ebpf.MapSpec `ebpf:"scope"`
+	ScopeCfg       *ebpf.MapSpec `ebpf:"scope_cfg"`
+	SensitivePaths *ebpf.MapSpec `ebpf:"sensitive_paths"`
 }
 
 // bpfVariableSpecs contains global variables before they are loaded into the kernel.
@@ -91,14 +107,26 @@ func (o *bpfObjects) Close() error {
 //
 // It can be passed to loadBpfObjects or ebpf.CollectionSpec.LoadAndAssign.
 type bpfMaps struct {
-	FilterMap *ebpf.Map `ebpf:"filter_map"`
-	Rb        *ebpf.Map `ebpf:"rb"`
+	Drops          *ebpf.Map `ebpf:"drops"`
+	ExecDeny       *ebpf.Map `ebpf:"exec_deny"`
+	ExecIgnore     *ebpf.Map `ebpf:"exec_ignore"`
+	Heap           *ebpf.Map `ebpf:"heap"`
+	Rb             *ebpf.Map `ebpf:"rb"`
+	Scope          *ebpf.Map `ebpf:"scope"`
+	ScopeCfg       *ebpf.Map `ebpf:"scope_cfg"`
+	SensitivePaths *ebpf.Map `ebpf:"sensitive_paths"`
 }
 
 func (m *bpfMaps) Close() error {
 	return _BpfClose(
-		m.FilterMap,
+		m.Drops,
+		m.ExecDeny,
+		m.ExecIgnore,
+		m.Heap,
 		m.Rb,
+		m.Scope,
+		m.ScopeCfg,
+		m.SensitivePaths,
 	)
 }
 
@@ -112,12 +140,32 @@ type bpfVariables struct {
 //
 // It can be passed to loadBpfObjects or ebpf.CollectionSpec.LoadAndAssign.
 type bpfPrograms struct {
-	TraceExecve *ebpf.Program `ebpf:"trace_execve"`
+	CheckExec        *ebpf.Program `ebpf:"check_exec"`
+	TraceCommitCreds *ebpf.Program `ebpf:"trace_commit_creds"`
+	TraceExecve      *ebpf.Program `ebpf:"trace_execve"`
+	TraceOpenat      *ebpf.Program `ebpf:"trace_openat"`
+	TraceOpenat2     *ebpf.Program `ebpf:"trace_openat2"`
+	TraceSchedExec   *ebpf.Program `ebpf:"trace_sched_exec"`
+	TraceSchedExit   *ebpf.Program `ebpf:"trace_sched_exit"`
+	TraceSchedFork   *ebpf.Program `ebpf:"trace_sched_fork"`
+	TraceTcpConnect  *ebpf.Program `ebpf:"trace_tcp_connect"`
+	TraceUdp4Connect *ebpf.Program `ebpf:"trace_udp4_connect"`
+	TraceUdp6Connect *ebpf.Program `ebpf:"trace_udp6_connect"`
 }
 
 func (p *bpfPrograms) Close() error {
 	return _BpfClose(
+		p.CheckExec,
+		p.TraceCommitCreds,
 		p.TraceExecve,
+		p.TraceOpenat,
+		p.TraceOpenat2,
+		p.TraceSchedExec,
+		p.TraceSchedExit,
+		p.TraceSchedFork,
+		p.TraceTcpConnect,
+		p.TraceUdp4Connect,
+		p.TraceUdp6Connect,
 	)
 }
 
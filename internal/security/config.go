@@ -0,0 +1,121 @@
+package security
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Debasish-87/aegis-v/internal/platform"
+	"github.com/cilium/ebpf"
+)
+
+// MonitorConfig holds the tunables of the runtime probes
+type MonitorConfig struct {
+	// SensitivePaths are path prefixes whose opens are reported (AEGIS_SENSITIVE_PATHS)
+	SensitivePaths []string
+	// RingBufferSize is the kernel ring buffer size in bytes (AEGIS_RINGBUF_SIZE)
+	RingBufferSize int
+	// RecordFile, when set, receives every event with its context for replay (AEGIS_RECORD_FILE)
+	RecordFile string
+	// RulesDir holds the detection rule files, hot-reloaded (AEGIS_RULES_DIR)
+	RulesDir string
+	// Scope is which processes are monitored: managed, host or all (AEGIS_MONITOR_SCOPE)
+	Scope string
+	// TrustFile lists extra trusted binaries by path and/or hash (AEGIS_TRUST_FILE)
+	TrustFile string
+	// ForensicsDir receives the samples of fileless executions; "" keeps hashes only (AEGIS_FORENSICS_DIR)
+	ForensicsDir string
+	// Backend is the event source: auto, ebpf or proc_connector (AEGIS_MONITOR_BACKEND)
+	Backend string
+}
+
+// Event sources; auto prefers the probes and falls back to the proc connector
+const (
+	BackendAuto          = "auto"
+	BackendEBPF          = "ebpf"
+	BackendProcConnector = "proc_connector"
+)
+
+// defaultRingBufferSize matches max_entries of rb in guardian.c
+const defaultRingBufferSize = 1 << 20
+
+var defaultSensitivePaths = []string{
+	"/etc/shadow",
+	"/etc/gshadow",
+	"/etc/sudoers",
+	"/etc/ssh/ssh_host_",
+	"/root/.ssh",
+	"/var/run/secrets/kubernetes.io/serviceaccount",
+	"/run/secrets/kubernetes.io/serviceaccount",
+	"/var/run/docker.sock",
+	"/run/docker.sock",
+}
+
+// LoadMonitorConfig reads the probe configuration from the environment
+func LoadMonitorConfig() MonitorConfig {
+	return MonitorConfig{
+		SensitivePaths: platform.EnvList("AEGIS_SENSITIVE_PATHS", defaultSensitivePaths),
+		RingBufferSize: ringBufferSize(platform.EnvInt("AEGIS_RINGBUF_SIZE", defaultRingBufferSize)),
+		RecordFile:     platform.EnvString("AEGIS_RECORD_FILE", ""),
+		RulesDir:       platform.EnvString("AEGIS_RULES_DIR", "rules"),
+		TrustFile:      platform.EnvString("AEGIS_TRUST_FILE", "trusted.yaml"),
+		ForensicsDir:   platform.EnvString("AEGIS_FORENSICS_DIR", "forensics"),
+		Scope:          monitorScope(platform.EnvString("AEGIS_MONITOR_SCOPE", ScopeManaged)),
+		Backend:        monitorBackend(platform.EnvString("AEGIS_MONITOR_BACKEND", BackendAuto)),
+	}
+}
+
+// ringBufferSize enforces the kernel's rule for BPF_MAP_TYPE_RINGBUF: a power of two
+// and a multiple of the page size. Anything else would fail the whole probe load.
+func ringBufferSize(n int) int {
+	if n < os.Getpagesize() || n&(n-1) != 0 {
+		log.Printf("[WARN] AEGIS_RINGBUF_SIZE=%d is not a power of two >= %d bytes, using %d", n, os.Getpagesize(), defaultRingBufferSize)
+		return defaultRingBufferSize
+	}
+	return n
+}
+
+// monitorBackend validates AEGIS_MONITOR_BACKEND; anything unknown falls back to auto
+func monitorBackend(s string) string {
+	switch s {
+	case BackendAuto, BackendEBPF, BackendProcConnector:
+		return s
+	}
+	log.Printf("[WARN] AEGIS_MONITOR_BACKEND=%q is not one of auto, ebpf, proc_connector; using %s", s, BackendAuto)
+	return BackendAuto
+}
+
+// pathKey mirrors struct path_key (LPM trie key; prefix length in bits)
+type pathKey struct {
+	PrefixLen uint32
+	Path      [maxPathLen]byte
+}
+
+// syncSensitivePaths makes the in-kernel prefix filter hold prefixes instead of
+// installed. Every prefix is checked before the map is touched.
+func syncSensitivePaths(m *ebpf.Map, installed, prefixes []string) error {
+	for _, p := range prefixes {
+		if !strings.HasPrefix(p, "/") || len(p) >= maxPathLen {
+			return fmt.Errorf("sensitive path '%s' must be absolute and shorter than %d bytes", p, maxPathLen)
+		}
+	}
+	for _, p := range prefixes {
+		if err := m.Put(sensitiveKey(p), uint32(1)); err != nil {
+			return fmt.Errorf("sensitive path '%s': %v", p, err)
+		}
+	}
+	for _, p := range installed {
+		if !slices.Contains(prefixes, p) {
+			_ = m.Delete(sensitiveKey(p))
+		}
+	}
+	return nil
+}
+
+func sensitiveKey(p string) *pathKey {
+	key := pathKey{PrefixLen: uint32(len(p) * 8)}
+	copy(key.Path[:], p)
+	return &key
+}
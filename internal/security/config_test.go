@@ -0,0 +1,31 @@
+package security
+
+import (
+	"os"
+	"testing"
+)
+
+func TestRingBufferSize(t *testing.T) {
+	page := os.Getpagesize()
+	tests := []struct {
+		n, want int
+	}{
+		{defaultRingBufferSize, defaultRingBufferSize},
+		{page, page},
+		{64 << 20, 64 << 20},
+		{page / 2, defaultRingBufferSize}, // below a page
+		{3 << 20, defaultRingBufferSize},  // not a power of two
+		{0, defaultRingBufferSize},
+		{-4096, defaultRingBufferSize},
+	}
+	for _, tt := range tests {
+		if got := ringBufferSize(tt.n); got != tt.want {
+			t.Errorf("ringBufferSize(%d) = %d, want %d", tt.n, got, tt.want)
+		}
+	}
+
+	t.Setenv("AEGIS_RINGBUF_SIZE", "1048576")
+	if got := LoadMonitorConfig().RingBufferSize; got != 1<<20 {
+		t.Errorf("AEGIS_RINGBUF_SIZE=1048576: RingBufferSize = %d", got)
+	}
+}
@@ -0,0 +1,70 @@
+package security
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// Admission phases recorded in the decision log
+const (
+	PhasePrePull  = "pre-pull"
+	PhasePostPull = "post-pull"
+)
+
+// RuleResult is the outcome of one evaluated rule: PASS, WARN, BLOCK or EXCEPTED
+type RuleResult struct {
+	Rule     string    `json:"rule"`
+	Result   string    `json:"result"`
+	Findings []Finding `json:"findings,omitempty"`
+}
+
+var resultRank = map[string]int{"PASS": 0, string(ActionWarn): 1, string(ActionExcepted): 2, string(ActionBlock): 3}
+
+// PhaseRules lists the rules a phase evaluates, so passing rules are logged too
+func (g *Gatekeeper) PhaseRules(phase string) []string {
+	if phase == PhasePrePull {
+		return []string{RuleSpecInvalid, RuleLatestTag, RuleUntrustedRegistry, RuleBlockedKeyword, RuleMalformedImage}
+	}
+	rules := []string{RuleRootUser, RuleSecretEnv, RulePrivilegedPort, RuleMissingHealthcheck, RuleMissingLabels}
+	if g.ScanFilesystem {
+		rules = append(rules, RuleSetuidBinary, RuleShell, RuleNetTool, RulePrivateKey, RuleCloudCredentials)
+	}
+	return rules
+}
+
+// RuleResults folds findings into one result per evaluated rule (worst outcome wins)
+func RuleResults(rules []string, findings []Finding) []RuleResult {
+	results := make([]RuleResult, 0, len(rules))
+	index := map[string]int{}
+	for _, rule := range rules {
+		index[rule] = len(results)
+		results = append(results, RuleResult{Rule: rule, Result: "PASS"})
+	}
+
+	for _, f := range findings {
+		i, ok := index[f.Rule]
+		if !ok {
+			index[f.Rule] = len(results)
+			results = append(results, RuleResult{Rule: f.Rule, Result: "PASS"})
+			i = len(results) - 1
+		}
+		results[i].Findings = append(results[i].Findings, f)
+		if resultRank[string(f.Action)] > resultRank[results[i].Result] {
+			results[i].Result = string(f.Action)
+		}
+	}
+	return results
+}
+
+// PolicyVersion fingerprints the active Gatekeeper configuration and level thresholds,
+// so a logged decision can be traced back to the exact policy that produced it.
+func (g *Gatekeeper) PolicyVersion() string {
+	data, _ := json.Marshal(struct {
+		Gatekeeper *Gatekeeper
+		Thresholds map[string]int
+		Rules      []string
+	}{g, blockThreshold, KnownRules()})
+	sum := sha256.Sum256(data)
+	return "gk-" + hex.EncodeToString(sum[:6])
+}
@@ -0,0 +1,65 @@
+package security
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRuleResults(t *testing.T) {
+	rules := []string{RuleRootUser, RuleSecretEnv, RuleMissingHealthcheck}
+	findings := []Finding{
+		{Rule: RuleSecretEnv, Action: ActionWarn},
+		{Rule: RuleSecretEnv, Action: ActionBlock},
+		{Rule: RuleSecretEnv, Action: ActionWarn}, // worst outcome wins, order does not matter
+		{Rule: RuleMissingHealthcheck, Action: ActionExcepted},
+		{Rule: RuleSpecInvalid, Action: ActionBlock}, // not in the phase list, still reported
+	}
+
+	got := RuleResults(rules, findings)
+	want := []struct {
+		rule, result string
+		findings     int
+	}{
+		{RuleRootUser, "PASS", 0},
+		{RuleSecretEnv, "BLOCK", 3},
+		{RuleMissingHealthcheck, "EXCEPTED", 1},
+		{RuleSpecInvalid, "BLOCK", 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("results %+v, want %d entries", got, len(want))
+	}
+	for i, w := range want {
+		if got[i].Rule != w.rule || got[i].Result != w.result || len(got[i].Findings) != w.findings {
+			t.Errorf("result %d = {%s %s %d findings}, want {%s %s %d}", i, got[i].Rule, got[i].Result, len(got[i].Findings), w.rule, w.result, w.findings)
+		}
+	}
+
+	if got := RuleResults(nil, nil); got == nil || len(got) != 0 {
+		t.Errorf("no rules: %#v, want an empty (non-nil) list", got)
+	}
+}
+
+func TestPhaseRules(t *testing.T) {
+	g := &Gatekeeper{}
+	if pre := g.PhaseRules(PhasePrePull); !slices.Contains(pre, RuleSpecInvalid) || slices.Contains(pre, RuleRootUser) {
+		t.Errorf("pre-pull rules %v", pre)
+	}
+	if post := g.PhaseRules(PhasePostPull); slices.Contains(post, RuleShell) {
+		t.Errorf("post-pull rules without filesystem scan include %s", RuleShell)
+	}
+	g.ScanFilesystem = true
+	if post := g.PhaseRules(PhasePostPull); !slices.Contains(post, RuleShell) || !slices.Contains(post, RuleRootUser) {
+		t.Errorf("post-pull rules with filesystem scan %v", post)
+	}
+}
+
+func TestPolicyVersion(t *testing.T) {
+	a, b := NewGatekeeper(), NewGatekeeper()
+	if a.PolicyVersion() != b.PolicyVersion() {
+		t.Errorf("same policy, different versions: %s, %s", a.PolicyVersion(), b.PolicyVersion())
+	}
+	b.RequiredLabels = []string{"team"}
+	if a.PolicyVersion() == b.PolicyVersion() {
+		t.Errorf("changed policy kept version %s", a.PolicyVersion())
+	}
+}
@@ -0,0 +1,95 @@
+package security
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/guardian"
+	"github.com/Debasish-87/aegis-v/internal/orchestrator"
+	"github.com/Debasish-87/aegis-v/internal/telemetry"
+	"github.com/Debasish-87/aegis-v/internal/trust"
+)
+
+// Drift kinds
+const (
+	driftAdded    = "added"    // not in any image layer
+	driftModified = "modified" // in the image, with different content
+)
+
+// drift is a container exec of a file that did not ship with the image
+type drift struct {
+	Kind    string `json:"kind"`
+	Path    string `json:"path"` // inside the container
+	SHA256  string `json:"sha256"`
+	Deleted bool   `json:"deleted,omitempty"` // removed again after the exec
+}
+
+// checkDrift compares the image an exec loaded with the container's overlay2 layers.
+// Files the container never wrote are served from the image layers and never drift;
+// anything in the writable layer is checked against the image's copy by hash, so a
+// copy-up that only touched metadata (chmod, touch) is not reported. A check that
+// cannot tell which image the exec loaded is counted as skipped.
+func checkDrift(info orchestrator.ContainerInfo, pid uint32, filename string) *drift {
+	if info.UpperDir == "" {
+		return nil
+	}
+	// Hash first, then make sure it was the image of this exec
+	running, err := trust.OfFile(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil {
+		telemetry.CountSkippedCheck("drift")
+		return nil
+	}
+	exe, deleted, ok := execImage(pid, filename)
+	if !ok {
+		telemetry.CountSkippedCheck("drift")
+		return nil
+	}
+	if !deleted {
+		if fi, err := os.Lstat(filepath.Join(info.UpperDir, exe)); err != nil || !fi.Mode().IsRegular() {
+			return nil
+		}
+	}
+
+	d := &drift{Kind: driftAdded, Path: exe, SHA256: running.SHA256, Deleted: deleted}
+	for _, layer := range filepath.SplitList(info.LowerDir) {
+		fi, err := os.Lstat(filepath.Join(layer, exe))
+		if err != nil {
+			continue
+		}
+		if !fi.Mode().IsRegular() {
+			break // whiteout, directory or symlink: the image has no such file
+		}
+		image, err := trust.OfFile(filepath.Join(layer, exe))
+		if err != nil {
+			break
+		}
+		if image.SHA256 == running.SHA256 {
+			return nil
+		}
+		d.Kind = driftModified
+		break
+	}
+	return d
+}
+
+// reportDrift raises the drifted executable detection of a container exec
+func (p *pipeline) reportDrift(event Event, ctx *eventContext, comm string, now time.Time) {
+	d := ctx.Drift
+	if !shouldReport(fmt.Sprintf("drift|%s|%s|%s", ctx.Container.ID, d.Path, d.SHA256), now) {
+		return
+	}
+	guardian.LogDrift(guardian.DriftAlert{
+		Command:  comm,
+		Filename: d.Path,
+		SHA256:   d.SHA256,
+		Kind:     d.Kind,
+		Deleted:  d.Deleted,
+		PID:      int(event.Pid),
+		Source:   ctx.Container.Name,
+		Identity: identityOf(event.Uid),
+		Ancestry: ctx.Ancestors,
+		Context:  ctx.detection(),
+	})
+}
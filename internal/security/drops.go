@@ -0,0 +1,38 @@
+package security
+
+import (
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/telemetry"
+
+	"github.com/cilium/ebpf"
+)
+
+// dropSampleInterval is how often the per-CPU drop counters are summed
+const dropSampleInterval = time.Second
+
+// sampleDrops publishes the kernel's ring buffer drop counters (the drops map,
+// indexed by event type) until done is closed
+func sampleDrops(m *ebpf.Map, done <-chan struct{}) {
+	tick := time.NewTicker(dropSampleInterval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-tick.C:
+			totals := map[string]uint64{}
+			for kind, name := range eventNames {
+				var perCPU []uint64
+				if err := m.Lookup(kind, &perCPU); err != nil {
+					continue
+				}
+				for _, n := range perCPU {
+					totals[name] += n
+				}
+			}
+			telemetry.SetKernelDrops(totals, now)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package security
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/Debasish-87/aegis-v/internal/platform"
+)
+
+// ValidateEgress rejects malformed egress rules before they are stored
+func ValidateEgress(rules []platform.EgressRule) error {
+	for i, r := range rules {
+		if _, err := parseEgressCIDR(r.CIDR); err != nil {
+			return fmt.Errorf("egress[%d]: %v", i, err)
+		}
+		switch strings.ToLower(r.Protocol) {
+		case "", "tcp", "udp":
+		default:
+			return fmt.Errorf("egress[%d]: unknown protocol '%s' (tcp or udp)", i, r.Protocol)
+		}
+		for _, p := range r.Ports {
+			if p < 1 || p > 65535 {
+				return fmt.Errorf("egress[%d]: invalid port %d", i, p)
+			}
+		}
+	}
+	return nil
+}
+
+// EgressAllowed reports whether any rule covers the destination
+func EgressAllowed(rules []platform.EgressRule, ip netip.Addr, port uint16, protocol string) bool {
+	for _, r := range rules {
+		prefix, err := parseEgressCIDR(r.CIDR)
+		if err != nil || !prefix.Contains(ip) {
+			continue
+		}
+		if r.Protocol != "" && !strings.EqualFold(r.Protocol, protocol) {
+			continue
+		}
+		if len(r.Ports) == 0 {
+			return true
+		}
+		for _, p := range r.Ports {
+			if p == int(port) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseEgressCIDR accepts a CIDR or a bare IP (single host)
+func parseEgressCIDR(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid cidr '%s'", s)
+		}
+		return p.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid cidr '%s'", s)
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
@@ -0,0 +1,68 @@
+package security
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/Debasish-87/aegis-v/internal/platform"
+)
+
+func TestValidateEgress(t *testing.T) {
+	valid := [][]platform.EgressRule{
+		nil,
+		{{CIDR: "10.0.0.0/8"}},
+		{{CIDR: "10.1.2.3", Ports: []int{443}, Protocol: "TCP"}},
+		{{CIDR: "2001:db8::/32", Protocol: "udp", Ports: []int{53}}},
+	}
+	for _, rules := range valid {
+		if err := ValidateEgress(rules); err != nil {
+			t.Errorf("ValidateEgress(%+v): %v", rules, err)
+		}
+	}
+	invalid := [][]platform.EgressRule{
+		{{CIDR: "10.0.0.0/33"}},
+		{{CIDR: "example.com"}},
+		{{CIDR: "10.0.0.1", Protocol: "icmp"}},
+		{{CIDR: "10.0.0.1", Ports: []int{0}}},
+		{{CIDR: "10.0.0.1", Ports: []int{65536}}},
+	}
+	for _, rules := range invalid {
+		if err := ValidateEgress(rules); err == nil {
+			t.Errorf("ValidateEgress(%+v): want an error", rules)
+		}
+	}
+}
+
+func TestEgressAllowed(t *testing.T) {
+	rules := []platform.EgressRule{
+		{CIDR: "10.0.0.0/24", Ports: []int{5432}, Protocol: "tcp"},
+		{CIDR: "10.0.1.7"},
+		{CIDR: "192.168.0.1/16", Protocol: "UDP"}, // host bits are masked off
+		{CIDR: "2001:db8::/32", Ports: []int{443}},
+	}
+	tests := []struct {
+		ip       string
+		port     uint16
+		protocol string
+		want     bool
+	}{
+		{"10.0.0.20", 5432, "tcp", true},
+		{"10.0.0.20", 5433, "tcp", false},
+		{"10.0.0.20", 5432, "udp", false},
+		{"10.0.1.7", 9999, "udp", true},
+		{"10.0.1.8", 9999, "udp", false},
+		{"192.168.44.1", 53, "udp", true},
+		{"192.168.44.1", 53, "tcp", false},
+		{"2001:db8::1", 443, "tcp", true},
+		{"2001:db9::1", 443, "tcp", false},
+		{"8.8.8.8", 53, "udp", false},
+	}
+	for _, tt := range tests {
+		if got := EgressAllowed(rules, netip.MustParseAddr(tt.ip), tt.port, tt.protocol); got != tt.want {
+			t.Errorf("EgressAllowed(%s:%d/%s) = %v, want %v", tt.ip, tt.port, tt.protocol, got, tt.want)
+		}
+	}
+	if EgressAllowed(nil, netip.MustParseAddr("10.0.0.1"), 80, "tcp") {
+		t.Errorf("an empty allowlist allowed a connection")
+	}
+}
@@ -0,0 +1,60 @@
+package security
+
+import "fmt"
+
+// Responses to a privilege escalation inside a container
+const (
+	EscalationAlert = "ALERT"
+	EscalationKill  = "KILL"
+)
+
+// escalationKillThreshold maps a security_level to the lowest escalation severity
+// whose process is killed. Levels not listed here (low, audit) only alert.
+var escalationKillThreshold = map[string]int{
+	"high":   2, // MEDIUM and above: any capability gain
+	"medium": 4, // CRITICAL only: root, or a root-equivalent capability
+}
+
+// EscalationAction decides the response to an escalation of the given severity.
+// Containers the engine did not deploy are never acted on, only reported.
+func EscalationAction(severity, level string, managed bool) string {
+	if !managed {
+		return EscalationAlert
+	}
+	threshold, ok := escalationKillThreshold[NormalizeLevel(level)]
+	if ok && severityRank[severity] >= threshold {
+		return EscalationKill
+	}
+	return EscalationAlert
+}
+
+// capabilityNames indexes capability bits (linux/capability.h)
+var capabilityNames = []string{
+	"CAP_CHOWN", "CAP_DAC_OVERRIDE", "CAP_DAC_READ_SEARCH", "CAP_FOWNER",
+	"CAP_FSETID", "CAP_KILL", "CAP_SETGID", "CAP_SETUID",
+	"CAP_SETPCAP", "CAP_LINUX_IMMUTABLE", "CAP_NET_BIND_SERVICE", "CAP_NET_BROADCAST",
+	"CAP_NET_ADMIN", "CAP_NET_RAW", "CAP_IPC_LOCK", "CAP_IPC_OWNER",
+	"CAP_SYS_MODULE", "CAP_SYS_RAWIO", "CAP_SYS_CHROOT", "CAP_SYS_PTRACE",
+	"CAP_SYS_PACCT", "CAP_SYS_ADMIN", "CAP_SYS_BOOT", "CAP_SYS_NICE",
+	"CAP_SYS_RESOURCE", "CAP_SYS_TIME", "CAP_SYS_TTY_CONFIG", "CAP_MKNOD",
+	"CAP_LEASE", "CAP_AUDIT_WRITE", "CAP_AUDIT_CONTROL", "CAP_SETFCAP",
+	"CAP_MAC_OVERRIDE", "CAP_MAC_ADMIN", "CAP_SYSLOG", "CAP_WAKE_ALARM",
+	"CAP_BLOCK_SUSPEND", "CAP_AUDIT_READ", "CAP_PERFMON", "CAP_BPF",
+	"CAP_CHECKPOINT_RESTORE",
+}
+
+// capNames turns a capability bitmask into names; unknown (newer) bits are numbered
+func capNames(mask uint64) []string {
+	var names []string
+	for bit := 0; bit < 64; bit++ {
+		if mask&(1<<bit) == 0 {
+			continue
+		}
+		if bit < len(capabilityNames) {
+			names = append(names, capabilityNames[bit])
+		} else {
+			names = append(names, fmt.Sprintf("CAP_%d", bit))
+		}
+	}
+	return names
+}
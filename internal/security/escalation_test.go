@@ -0,0 +1,101 @@
+package security
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/guardian"
+	"github.com/Debasish-87/aegis-v/internal/platform"
+)
+
+func TestEscalationAction(t *testing.T) {
+	tests := []struct {
+		severity, level string
+		managed         bool
+		want            string
+	}{
+		{"CRITICAL", "high", true, EscalationKill},
+		{"MEDIUM", "high", true, EscalationKill},
+		{"CRITICAL", "medium", true, EscalationKill},
+		{"MEDIUM", "medium", true, EscalationAlert},
+		{"CRITICAL", "low", true, EscalationAlert},
+		{"CRITICAL", "audit", true, EscalationAlert},
+		{"CRITICAL", "HIGH", true, EscalationKill},
+		// Containers the engine did not deploy are only reported
+		{"CRITICAL", "high", false, EscalationAlert},
+	}
+	for _, tt := range tests {
+		if got := EscalationAction(tt.severity, tt.level, tt.managed); got != tt.want {
+			t.Errorf("EscalationAction(%s, %s, %v) = %s, want %s", tt.severity, tt.level, tt.managed, got, tt.want)
+		}
+	}
+}
+
+func TestCredEventChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		ev     CredEvent
+		toRoot bool
+		gained []string
+	}{
+		{"setuid root", CredEvent{OldUid: 1000, NewUid: 0, OldEuid: 1000, NewEuid: 0}, true, nil},
+		{"euid only", CredEvent{OldUid: 1000, NewUid: 1000, OldEuid: 1000, NewEuid: 0}, true, nil},
+		{"already root", CredEvent{OldUid: 0, NewUid: 0, OldEuid: 0, NewEuid: 0}, false, nil},
+		{"cap gain", CredEvent{OldUid: 1000, NewUid: 1000, OldCaps: 1 << 10, NewCaps: 1<<10 | 1<<21}, false, []string{"CAP_SYS_ADMIN"}},
+		{"unknown bit", CredEvent{OldUid: 1000, NewUid: 1000, NewCaps: 1<<0 | 1<<45}, false, []string{"CAP_CHOWN", "CAP_45"}},
+	}
+	for _, tt := range tests {
+		if got := tt.ev.ToRoot(); got != tt.toRoot {
+			t.Errorf("%s: ToRoot() = %v, want %v", tt.name, got, tt.toRoot)
+		}
+		if got := tt.ev.GainedCaps(); !reflect.DeepEqual(got, tt.gained) {
+			t.Errorf("%s: GainedCaps() = %v, want %v", tt.name, got, tt.gained)
+		}
+	}
+}
+
+func TestHandleCred(t *testing.T) {
+	detectionDB(t)
+	now := time.Now()
+	netBind := CredEvent{Comm: comm16("node"), OldUid: 1000, NewUid: 1000, OldEuid: 1000, NewEuid: 1000, NewCaps: 1 << 10}
+	toRoot := CredEvent{Comm: comm16("exploit"), OldUid: 1000, NewUid: 0, OldEuid: 1000, NewEuid: 0}
+
+	tests := []struct {
+		name   string
+		level  string
+		ev     CredEvent
+		killed bool
+		resp   string
+	}{
+		{"medium level, capability gain", "medium", netBind, false, "ALERTED"},
+		{"medium level, root", "medium", toRoot, true, "KILLED"},
+		{"high level, capability gain", "high", netBind, true, "KILLED"},
+		{"low level, root", "low", toRoot, false, "ALERTED"},
+	}
+	for i, tt := range tests {
+		r := &fakeResponder{}
+		p := &pipeline{selfPid: 1, respond: r}
+		ev := tt.ev
+		ev.Pid = uint32(700 + i)
+		p.handle(ev, containerCtx("cred-"+tt.level, "cred-"+tt.level, tt.level), now)
+
+		if killed := len(r.killed) == 1 && r.killed[0] == int(ev.Pid); killed != tt.killed {
+			t.Errorf("%s: killed %v, want kill=%v", tt.name, r.killed, tt.killed)
+		}
+		var resp string
+		if err := platform.DB.QueryRow("SELECT response FROM detections WHERE detection_type = ? AND pid = ?", guardian.DetectionPrivEsc, ev.Pid).Scan(&resp); err != nil {
+			t.Fatalf("%s: no detection stored: %v", tt.name, err)
+		}
+		if resp != tt.resp {
+			t.Errorf("%s: response = %s, want %s", tt.name, resp, tt.resp)
+		}
+	}
+
+	// Host escalations (sudo, su, ...) are out of scope
+	r := &fakeResponder{}
+	(&pipeline{selfPid: 1, respond: r}).handle(toRoot, &eventContext{}, now)
+	if len(r.killed) != 0 || detections(t, guardian.DetectionPrivEsc) != len(tests) {
+		t.Errorf("host escalation was acted on or stored")
+	}
+}
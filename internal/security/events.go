@@ -0,0 +1,455 @@
+package security
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net/netip"
+	"strings"
+	"syscall"
+
+	"github.com/Debasish-87/aegis-v/internal/telemetry"
+)
+
+// Record types written by guardian.c (first field of every ring buffer record)
+const (
+	eventExec     = 1
+	eventConnect  = 2
+	eventOpen     = 3
+	eventCred     = 4
+	eventBlocked  = 5
+	eventFork     = 6
+	eventSchExec  = 7
+	eventExit     = 8
+	eventFileless = 9
+)
+
+// eventNames label event types in metrics
+var eventNames = map[uint32]string{
+	eventExec:     "exec",
+	eventConnect:  "connect",
+	eventOpen:     "open",
+	eventCred:     "cred",
+	eventBlocked:  "blocked",
+	eventFork:     "fork",
+	eventSchExec:  "sched_exec",
+	eventExit:     "exit",
+	eventFileless: "fileless",
+}
+
+// maxPathLen mirrors MAX_PATH_LEN (sensitive path keys and open events)
+const maxPathLen = 128
+
+// Event flags set by the exec probe
+const (
+	flagArgvTruncated = 0x1
+	flagCwdTruncated  = 0x2
+)
+
+// Why an exec counts as fileless (FILELESS_* in guardian.c)
+const (
+	filelessMemfd    = 0x1
+	filelessUnlinked = 0x2
+	filelessTmpfs    = 0x4
+	filelessTmpdir   = 0x8
+)
+
+var filelessReasons = []struct {
+	flag uint32
+	name string
+}{
+	{filelessMemfd, "memfd"},
+	{filelessUnlinked, "unlinked"},
+	{filelessTmpfs, "tmpfs"},
+	{filelessTmpdir, "tmpdir"},
+}
+
+const (
+	afInet   = 2
+	afInet6  = 10
+	protoTCP = 6
+	protoUDP = 17
+)
+
+// eventHeader mirrors struct event_header in guardian.c
+type eventHeader struct {
+	Type     uint32
+	Pid      uint32
+	Ppid     uint32
+	Uid      uint32
+	MntNs    uint32
+	_        uint32
+	CgroupID uint64
+	Comm     [16]byte
+}
+
+// execFields mirrors the fixed part of struct exec_event after the header
+type execFields struct {
+	FilenameLen uint16
+	ArgvLen     uint16
+	CwdLen      uint16
+	Argc        uint8
+	Flags       uint8
+}
+
+// connectFields mirrors struct connect_event after the header
+type connectFields struct {
+	Family   uint16
+	Dport    uint16
+	Protocol uint8
+	_        [3]byte
+	Daddr    [16]byte
+}
+
+// openFields mirrors struct open_event after the header
+type openFields struct {
+	Flags   uint32
+	PathLen uint16
+	_       uint16
+	Path    [maxPathLen]byte
+}
+
+// credFields mirrors struct cred_event after the header
+type credFields struct {
+	OldUid  uint32
+	NewUid  uint32
+	OldEuid uint32
+	NewEuid uint32
+	OldCaps uint64
+	NewCaps uint64
+}
+
+// blockedFields mirrors struct blocked_event after the header
+type blockedFields struct {
+	Name [maxDentryName]byte
+	Path [maxPathLen]byte
+}
+
+// forkFields mirrors struct fork_event after the header
+type forkFields struct {
+	ChildPid   uint32
+	_          uint32
+	ChildStart uint64
+}
+
+// schedExecFields mirrors struct sched_exec_event after the header
+type schedExecFields struct {
+	StartTime uint64
+	Filename  [maxPathLen]byte
+}
+
+// filelessFields mirrors struct fileless_event after the header
+type filelessFields struct {
+	StartTime uint64
+	Flags     uint32
+	_         uint32
+	Filename  [maxPathLen]byte
+	Name      [maxDentryName]byte
+}
+
+var (
+	eventHeaderSize = binary.Size(eventHeader{})
+	execFieldsSize  = binary.Size(execFields{})
+)
+
+// Event is one decoded execve record: the fixed header plus the variable-length tail
+type Event struct {
+	Pid           uint32
+	Ppid          uint32
+	Uid           uint32
+	MntNs         uint32
+	CgroupID      uint64
+	Comm          [16]byte
+	Filename      string
+	Argv          []string
+	ArgvTruncated bool
+	Cwd           string
+}
+
+// ConnectEvent is one outbound connection attempt
+type ConnectEvent struct {
+	Pid      uint32
+	Ppid     uint32
+	Uid      uint32
+	MntNs    uint32
+	CgroupID uint64
+	Comm     [16]byte
+	Protocol string
+	DestIP   netip.Addr
+	DestPort uint16
+}
+
+// OpenEvent is an open of a path below a sensitive prefix
+type OpenEvent struct {
+	Pid      uint32
+	Ppid     uint32
+	Uid      uint32
+	MntNs    uint32
+	CgroupID uint64
+	Comm     [16]byte
+	Path     string
+	Flags    uint32
+}
+
+// Write reports whether the file was opened for modification
+func (e OpenEvent) Write() bool {
+	return e.Flags&(syscall.O_WRONLY|syscall.O_RDWR|syscall.O_CREAT|syscall.O_TRUNC) != 0
+}
+
+// CredEvent is a credential change that raised a process's privileges
+type CredEvent struct {
+	Pid      uint32
+	Ppid     uint32
+	Uid      uint32
+	MntNs    uint32
+	CgroupID uint64
+	Comm     [16]byte
+	OldUid   uint32
+	NewUid   uint32
+	OldEuid  uint32
+	NewEuid  uint32
+	OldCaps  uint64
+	NewCaps  uint64
+}
+
+// ToRoot reports whether the real or effective uid became 0
+func (e CredEvent) ToRoot() bool {
+	return (e.OldUid != 0 && e.NewUid == 0) || (e.OldEuid != 0 && e.NewEuid == 0)
+}
+
+// GainedCaps lists the effective capabilities the process did not hold before
+func (e CredEvent) GainedCaps() []string {
+	return capNames(e.NewCaps &^ e.OldCaps)
+}
+
+// BlockedExecEvent is an exec the LSM hook refused before the new image ran
+type BlockedExecEvent struct {
+	Pid      uint32
+	Ppid     uint32
+	Uid      uint32
+	MntNs    uint32
+	CgroupID uint64
+	Comm     [16]byte // the caller: the exec never happened
+	Binary   string   // deny_exec entry that matched
+	Path     string   // filename as passed to execve
+}
+
+// FilelessEvent is an exec of a file that cannot be inspected on disk afterwards:
+// memfd-backed, unlinked, on tmpfs or invoked from a temp directory
+type FilelessEvent struct {
+	Pid      uint32
+	Ppid     uint32
+	Uid      uint32
+	MntNs    uint32
+	CgroupID uint64
+	Comm     [16]byte // the new image's
+	Filename string   // as invoked; /dev/fd/N for fexecve()
+	Name     string   // executed file, e.g. memfd:payload
+	Flags    uint32
+}
+
+// Reasons names the flags: memfd, unlinked, tmpfs, tmpdir
+func (e FilelessEvent) Reasons() []string {
+	var out []string
+	for _, r := range filelessReasons {
+		if e.Flags&r.flag != 0 {
+			out = append(out, r.name)
+		}
+	}
+	return out
+}
+
+// ProcessEvent feeds the process table: a fork, a completed exec, or an exit
+type ProcessEvent struct {
+	Kind      int // eventFork, eventSchExec or eventExit
+	Pid       uint32
+	Ppid      uint32
+	CgroupID  uint64
+	Comm      [16]byte
+	ChildPid  uint32 // fork only
+	StartTime uint64 // ns since boot: the child's on fork, the process's otherwise
+	Filename  string // exec only
+}
+
+// decodeRecord parses a ring buffer record into one of the event types above
+func decodeRecord(raw []byte) (interface{}, error) {
+	var h eventHeader
+	if err := binary.Read(bytes.NewReader(raw), binary.LittleEndian, &h); err != nil {
+		return nil, err
+	}
+	body := raw[eventHeaderSize:]
+	if name, ok := eventNames[h.Type]; ok {
+		telemetry.CountEvent(name)
+	}
+
+	switch h.Type {
+	case eventExec:
+		return decodeExec(h, body)
+	case eventConnect:
+		return decodeConnect(h, body)
+	case eventOpen:
+		return decodeOpen(h, body)
+	case eventCred:
+		return decodeCred(h, body)
+	case eventBlocked:
+		return decodeBlocked(h, body)
+	case eventFork, eventSchExec, eventExit:
+		return decodeProcess(h, body)
+	case eventFileless:
+		return decodeFileless(h, body)
+	}
+	return nil, fmt.Errorf("unknown event type %d", h.Type)
+}
+
+// decodeExec parses the exec tail (filename / argv / cwd bytes)
+func decodeExec(h eventHeader, body []byte) (Event, error) {
+	var f execFields
+	if err := binary.Read(bytes.NewReader(body), binary.LittleEndian, &f); err != nil {
+		return Event{}, err
+	}
+
+	data := body[execFieldsSize:]
+	need := int(f.FilenameLen) + int(f.ArgvLen) + int(f.CwdLen)
+	if len(data) < need {
+		return Event{}, fmt.Errorf("short event record: %d < %d bytes", len(data), need)
+	}
+
+	ev := Event{
+		Pid: h.Pid, Ppid: h.Ppid, Uid: h.Uid, MntNs: h.MntNs, CgroupID: h.CgroupID, Comm: h.Comm,
+		ArgvTruncated: f.Flags&flagArgvTruncated != 0,
+	}
+	ev.Filename = string(bytes.TrimRight(data[:f.FilenameLen], "\x00"))
+	data = data[f.FilenameLen:]
+	ev.Argv = splitCStrings(data[:f.ArgvLen])
+	data = data[f.ArgvLen:]
+
+	// The probe walks the cwd leaf first; it stops at the mount root or after MAX_CWD_DEPTH
+	parts := splitCStrings(data[:f.CwdLen])
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	ev.Cwd = "/" + strings.Join(parts, "/")
+	if f.Flags&flagCwdTruncated != 0 {
+		ev.Cwd = "..." + ev.Cwd
+	}
+	return ev, nil
+}
+
+func decodeConnect(h eventHeader, body []byte) (ConnectEvent, error) {
+	var f connectFields
+	if err := binary.Read(bytes.NewReader(body), binary.LittleEndian, &f); err != nil {
+		return ConnectEvent{}, err
+	}
+
+	ev := ConnectEvent{
+		Pid: h.Pid, Ppid: h.Ppid, Uid: h.Uid, MntNs: h.MntNs, CgroupID: h.CgroupID, Comm: h.Comm,
+		DestPort: f.Dport,
+	}
+	switch f.Protocol {
+	case protoTCP:
+		ev.Protocol = "tcp"
+	case protoUDP:
+		ev.Protocol = "udp"
+	default:
+		return ConnectEvent{}, fmt.Errorf("unknown protocol %d", f.Protocol)
+	}
+	switch f.Family {
+	case afInet:
+		ev.DestIP = netip.AddrFrom4([4]byte(f.Daddr[:4]))
+	case afInet6:
+		// v4-mapped destinations (dual-stack sockets) are reported as plain IPv4
+		ev.DestIP = netip.AddrFrom16(f.Daddr).Unmap()
+	default:
+		return ConnectEvent{}, fmt.Errorf("unknown address family %d", f.Family)
+	}
+	return ev, nil
+}
+
+func decodeOpen(h eventHeader, body []byte) (OpenEvent, error) {
+	var f openFields
+	if err := binary.Read(bytes.NewReader(body), binary.LittleEndian, &f); err != nil {
+		return OpenEvent{}, err
+	}
+	if int(f.PathLen) > len(f.Path) {
+		return OpenEvent{}, fmt.Errorf("bad path length %d", f.PathLen)
+	}
+	return OpenEvent{
+		Pid: h.Pid, Ppid: h.Ppid, Uid: h.Uid, MntNs: h.MntNs, CgroupID: h.CgroupID, Comm: h.Comm,
+		Path:  string(f.Path[:f.PathLen]),
+		Flags: f.Flags,
+	}, nil
+}
+
+func decodeCred(h eventHeader, body []byte) (CredEvent, error) {
+	var f credFields
+	if err := binary.Read(bytes.NewReader(body), binary.LittleEndian, &f); err != nil {
+		return CredEvent{}, err
+	}
+	return CredEvent{
+		Pid: h.Pid, Ppid: h.Ppid, Uid: h.Uid, MntNs: h.MntNs, CgroupID: h.CgroupID, Comm: h.Comm,
+		OldUid: f.OldUid, NewUid: f.NewUid, OldEuid: f.OldEuid, NewEuid: f.NewEuid,
+		OldCaps: f.OldCaps, NewCaps: f.NewCaps,
+	}, nil
+}
+
+func decodeBlocked(h eventHeader, body []byte) (BlockedExecEvent, error) {
+	var f blockedFields
+	if err := binary.Read(bytes.NewReader(body), binary.LittleEndian, &f); err != nil {
+		return BlockedExecEvent{}, err
+	}
+	return BlockedExecEvent{
+		Pid: h.Pid, Ppid: h.Ppid, Uid: h.Uid, MntNs: h.MntNs, CgroupID: h.CgroupID, Comm: h.Comm,
+		Binary: string(bytes.TrimRight(f.Name[:], "\x00")),
+		Path:   string(bytes.TrimRight(f.Path[:], "\x00")),
+	}, nil
+}
+
+func decodeFileless(h eventHeader, body []byte) (FilelessEvent, error) {
+	var f filelessFields
+	if err := binary.Read(bytes.NewReader(body), binary.LittleEndian, &f); err != nil {
+		return FilelessEvent{}, err
+	}
+	return FilelessEvent{
+		Pid: h.Pid, Ppid: h.Ppid, Uid: h.Uid, MntNs: h.MntNs, CgroupID: h.CgroupID, Comm: h.Comm,
+		Filename: string(bytes.TrimRight(f.Filename[:], "\x00")),
+		Name:     string(bytes.TrimRight(f.Name[:], "\x00")),
+		Flags:    f.Flags,
+	}, nil
+}
+
+func decodeProcess(h eventHeader, body []byte) (ProcessEvent, error) {
+	ev := ProcessEvent{Kind: int(h.Type), Pid: h.Pid, Ppid: h.Ppid, CgroupID: h.CgroupID, Comm: h.Comm}
+	r := bytes.NewReader(body)
+	switch h.Type {
+	case eventFork:
+		var f forkFields
+		if err := binary.Read(r, binary.LittleEndian, &f); err != nil {
+			return ProcessEvent{}, err
+		}
+		ev.ChildPid, ev.StartTime = f.ChildPid, f.ChildStart
+	case eventSchExec:
+		var f schedExecFields
+		if err := binary.Read(r, binary.LittleEndian, &f); err != nil {
+			return ProcessEvent{}, err
+		}
+		ev.StartTime = f.StartTime
+		ev.Filename = string(bytes.TrimRight(f.Filename[:], "\x00"))
+	default:
+		if err := binary.Read(r, binary.LittleEndian, &ev.StartTime); err != nil {
+			return ProcessEvent{}, err
+		}
+	}
+	return ev, nil
+}
+
+// splitCStrings splits NUL-terminated strings; empty arguments are preserved
+func splitCStrings(b []byte) []string {
+	if len(b) == 0 {
+		return nil
+	}
+	fields := strings.Split(string(b), "\x00")
+	if fields[len(fields)-1] == "" {
+		fields = fields[:len(fields)-1]
+	}
+	return fields
+}
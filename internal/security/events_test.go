@@ -0,0 +1,146 @@
+package security
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/netip"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// rawRecord encodes a ring buffer record the way guardian.c lays it out
+func rawRecord(typ uint32, parts ...interface{}) []byte {
+	var b bytes.Buffer
+	h := eventHeader{Type: typ, Pid: 42, Ppid: 7, Uid: 1000, MntNs: 4026532000, CgroupID: 99, Comm: comm16("bash")}
+	binary.Write(&b, binary.LittleEndian, h)
+	for _, p := range parts {
+		if raw, ok := p.([]byte); ok {
+			b.Write(raw)
+			continue
+		}
+		binary.Write(&b, binary.LittleEndian, p)
+	}
+	return b.Bytes()
+}
+
+func path128(s string) (out [maxPathLen]byte) {
+	copy(out[:], s)
+	return out
+}
+
+func TestDecodeRecord(t *testing.T) {
+	cwd := []byte("src\x00alice\x00home\x00") // leaf first
+	argv := []byte("ls\x00-la\x00\x00")       // an empty argument is kept
+	file := []byte("/bin/ls\x00")
+	exec := execFields{FilenameLen: uint16(len(file)), ArgvLen: uint16(len(argv)), CwdLen: uint16(len(cwd)), Argc: 3, Flags: flagArgvTruncated}
+
+	var daddr6, mapped [16]byte
+	copy(daddr6[:], netip.MustParseAddr("2001:db8::7").AsSlice())
+	copy(mapped[:], netip.MustParseAddr("::ffff:10.0.0.5").AsSlice())
+	var daddr4 [16]byte
+	copy(daddr4[:], []byte{10, 0, 0, 5})
+	var blockedName [maxDentryName]byte
+	copy(blockedName[:], "nc")
+
+	tests := []struct {
+		name string
+		raw  []byte
+		want interface{}
+	}{
+		{"exec", rawRecord(eventExec, exec, file, argv, cwd), Event{
+			Pid: 42, Ppid: 7, Uid: 1000, MntNs: 4026532000, CgroupID: 99, Comm: comm16("bash"),
+			Filename: "/bin/ls", Argv: []string{"ls", "-la", ""}, ArgvTruncated: true, Cwd: "/home/alice/src",
+		}},
+		{"exec with truncated cwd", rawRecord(eventExec, execFields{FilenameLen: uint16(len(file)), CwdLen: 4, Flags: flagCwdTruncated}, file, []byte("src\x00")), Event{
+			Pid: 42, Ppid: 7, Uid: 1000, MntNs: 4026532000, CgroupID: 99, Comm: comm16("bash"),
+			Filename: "/bin/ls", Cwd: ".../src",
+		}},
+		{"connect ipv4", rawRecord(eventConnect, connectFields{Family: afInet, Dport: 4444, Protocol: protoTCP, Daddr: daddr4}), ConnectEvent{
+			Pid: 42, Ppid: 7, Uid: 1000, MntNs: 4026532000, CgroupID: 99, Comm: comm16("bash"),
+			Protocol: "tcp", DestIP: netip.MustParseAddr("10.0.0.5"), DestPort: 4444,
+		}},
+		{"connect ipv6", rawRecord(eventConnect, connectFields{Family: afInet6, Dport: 53, Protocol: protoUDP, Daddr: daddr6}), ConnectEvent{
+			Pid: 42, Ppid: 7, Uid: 1000, MntNs: 4026532000, CgroupID: 99, Comm: comm16("bash"),
+			Protocol: "udp", DestIP: netip.MustParseAddr("2001:db8::7"), DestPort: 53,
+		}},
+		{"connect v4-mapped", rawRecord(eventConnect, connectFields{Family: afInet6, Dport: 80, Protocol: protoTCP, Daddr: mapped}), ConnectEvent{
+			Pid: 42, Ppid: 7, Uid: 1000, MntNs: 4026532000, CgroupID: 99, Comm: comm16("bash"),
+			Protocol: "tcp", DestIP: netip.MustParseAddr("10.0.0.5"), DestPort: 80,
+		}},
+		{"open", rawRecord(eventOpen, openFields{Flags: 1, PathLen: 11, Path: path128("/etc/shadow")}), OpenEvent{
+			Pid: 42, Ppid: 7, Uid: 1000, MntNs: 4026532000, CgroupID: 99, Comm: comm16("bash"),
+			Path: "/etc/shadow", Flags: 1,
+		}},
+		{"cred", rawRecord(eventCred, credFields{OldUid: 1000, NewUid: 0, OldEuid: 1000, NewEuid: 0, OldCaps: 0, NewCaps: 1 << 21}), CredEvent{
+			Pid: 42, Ppid: 7, Uid: 1000, MntNs: 4026532000, CgroupID: 99, Comm: comm16("bash"),
+			OldUid: 1000, OldEuid: 1000, NewCaps: 1 << 21,
+		}},
+		{"blocked", rawRecord(eventBlocked, blockedFields{Name: blockedName, Path: path128("/usr/bin/nc")}), BlockedExecEvent{
+			Pid: 42, Ppid: 7, Uid: 1000, MntNs: 4026532000, CgroupID: 99, Comm: comm16("bash"),
+			Binary: "nc", Path: "/usr/bin/nc",
+		}},
+		{"fork", rawRecord(eventFork, forkFields{ChildPid: 43, ChildStart: 5000}), ProcessEvent{
+			Kind: eventFork, Pid: 42, Ppid: 7, CgroupID: 99, Comm: comm16("bash"), ChildPid: 43, StartTime: 5000,
+		}},
+		{"sched exec", rawRecord(eventSchExec, schedExecFields{StartTime: 5000, Filename: path128("/usr/bin/id")}), ProcessEvent{
+			Kind: eventSchExec, Pid: 42, Ppid: 7, CgroupID: 99, Comm: comm16("bash"), StartTime: 5000, Filename: "/usr/bin/id",
+		}},
+		{"exit", rawRecord(eventExit, uint64(5000)), ProcessEvent{
+			Kind: eventExit, Pid: 42, Ppid: 7, CgroupID: 99, Comm: comm16("bash"), StartTime: 5000,
+		}},
+		{"fileless", rawRecord(eventFileless, filelessFields{StartTime: 5000, Flags: filelessMemfd | filelessUnlinked, Filename: path128("/dev/fd/3"), Name: blockedName}), FilelessEvent{
+			Pid: 42, Ppid: 7, Uid: 1000, MntNs: 4026532000, CgroupID: 99, Comm: comm16("bash"),
+			Filename: "/dev/fd/3", Name: "nc", Flags: filelessMemfd | filelessUnlinked,
+		}},
+	}
+	for _, tt := range tests {
+		got, err := decodeRecord(tt.raw)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got %+v\nwant %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeRecordErrors(t *testing.T) {
+	file := []byte("/bin/ls\x00")
+	tests := []struct {
+		name string
+		raw  []byte
+	}{
+		{"short header", rawRecord(eventExec)[:10]},
+		{"unknown type", rawRecord(77, uint64(0))},
+		{"exec tail shorter than its lengths", rawRecord(eventExec, execFields{FilenameLen: uint16(len(file)), ArgvLen: 32}, file)},
+		{"connect protocol", rawRecord(eventConnect, connectFields{Family: afInet, Protocol: 1})},
+		{"connect family", rawRecord(eventConnect, connectFields{Family: 1, Protocol: protoTCP})},
+		{"open path length", rawRecord(eventOpen, openFields{PathLen: maxPathLen + 1})},
+		{"truncated cred", rawRecord(eventCred, uint32(0))},
+	}
+	for _, tt := range tests {
+		if ev, err := decodeRecord(tt.raw); err == nil {
+			t.Errorf("%s: decoded %+v", tt.name, ev)
+		}
+	}
+}
+
+func TestSplitCStrings(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"sh\x00", []string{"sh"}},
+		{"sh\x00-c\x00id\x00", []string{"sh", "-c", "id"}},
+		{"a\x00\x00b\x00", []string{"a", "", "b"}},
+		{"no-terminator", []string{"no-terminator"}},
+	}
+	for _, tt := range tests {
+		if got := splitCStrings([]byte(tt.in)); !slices.Equal(got, tt.want) {
+			t.Errorf("splitCStrings(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package security
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/platform"
+)
+
+// ExceptionExpiryWarning is how early admissions and the engine warn about an expiring exception
+const ExceptionExpiryWarning = 72 * time.Hour
+
+// MaxExceptionLifetime keeps exceptions time-boxed (AEGIS_EXCEPTION_MAX_DAYS, default 90)
+var MaxExceptionLifetime = time.Duration(platform.EnvInt("AEGIS_EXCEPTION_MAX_DAYS", 90)) * 24 * time.Hour
+
+// KnownRules lists every Gatekeeper rule an exception may target. A malformed
+// spec (spec.invalid) is not a policy finding and can never be waived.
+func KnownRules() []string {
+	return []string{
+		RuleLatestTag, RuleUntrustedRegistry, RuleBlockedKeyword, RuleMalformedImage,
+		RuleRootUser, RuleSecretEnv, RulePrivilegedPort, RuleMissingHealthcheck, RuleMissingLabels,
+		RuleSetuidBinary, RuleShell, RuleNetTool, RulePrivateKey, RuleCloudCredentials,
+	}
+}
+
+// ValidateException rejects incomplete, unknown-rule or open-ended exceptions
+func ValidateException(e platform.PolicyException) error {
+	if e.Service == "" || e.Rule == "" || e.Owner == "" || strings.TrimSpace(e.Reason) == "" {
+		return fmt.Errorf("service, rule, owner and reason are all required")
+	}
+
+	known := false
+	for _, r := range KnownRules() {
+		if r == e.Rule {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return fmt.Errorf("unknown rule '%s' (known: %s)", e.Rule, strings.Join(KnownRules(), ", "))
+	}
+
+	if !e.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("expiry must be in the future")
+	}
+	if time.Until(e.ExpiresAt) > MaxExceptionLifetime {
+		return fmt.Errorf("expiry exceeds the maximum lifetime of %d days", int(MaxExceptionLifetime.Hours()/24))
+	}
+	return nil
+}
+
+// ApplyExceptions waives findings covered by an active exception of the service.
+// Waived findings stay in the result as EXCEPTED so the admission is flagged as having used one.
+func (g *Gatekeeper) ApplyExceptions(service string, findings []Finding) []Finding {
+	exceptions, err := platform.ActiveExceptions(service)
+	if err != nil || len(exceptions) == 0 {
+		return findings
+	}
+
+	for i := range findings {
+		if findings[i].Action == ActionExcepted || findings[i].Rule == RuleSpecInvalid {
+			continue
+		}
+		for _, e := range exceptions {
+			if e.Rule != findings[i].Rule {
+				continue
+			}
+			findings[i].Action = ActionExcepted
+			findings[i].ExceptionID = e.ID
+			findings[i].Detail += fmt.Sprintf(" (waived by exception #%d, owner %s, expires %s)",
+				e.ID, e.Owner, e.ExpiresAt.Format(time.RFC3339))
+
+			if remaining := time.Until(e.ExpiresAt); remaining < ExceptionExpiryWarning {
+				fmt.Printf("[GATEKEEPER] ⏳ Exception #%d (%s/%s, owner %s) expires in %s.\n",
+					e.ID, e.Service, e.Rule, e.Owner, remaining.Round(time.Minute))
+			}
+			break
+		}
+	}
+	return findings
+}
+
+// ExceptionsUsed returns the distinct exception IDs that waived a finding
+func ExceptionsUsed(findings []Finding) []int64 {
+	seen := map[int64]bool{}
+	var ids []int64
+	for _, f := range findings {
+		if f.Action == ActionExcepted && !seen[f.ExceptionID] {
+			seen[f.ExceptionID] = true
+			ids = append(ids, f.ExceptionID)
+		}
+	}
+	return ids
+}
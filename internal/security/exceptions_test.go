@@ -0,0 +1,89 @@
+package security
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/platform"
+)
+
+func TestValidateException(t *testing.T) {
+	valid := platform.PolicyException{Service: "web", Rule: RuleRootUser, Owner: "ops", Reason: "legacy image",
+		ExpiresAt: time.Now().Add(24 * time.Hour)}
+	tests := []struct {
+		name    string
+		edit    func(e *platform.PolicyException)
+		wantErr string
+	}{
+		{"valid", func(e *platform.PolicyException) {}, ""},
+		{"spec invalid is not waivable", func(e *platform.PolicyException) { e.Rule = RuleSpecInvalid }, "unknown rule"},
+		{"unknown rule", func(e *platform.PolicyException) { e.Rule = "config.nope" }, "unknown rule"},
+		{"no owner", func(e *platform.PolicyException) { e.Owner = "" }, "required"},
+		{"blank reason", func(e *platform.PolicyException) { e.Reason = "  " }, "required"},
+		{"expired", func(e *platform.PolicyException) { e.ExpiresAt = time.Now().Add(-time.Hour) }, "future"},
+		{"too long", func(e *platform.PolicyException) { e.ExpiresAt = time.Now().Add(MaxExceptionLifetime + time.Hour) }, "maximum lifetime"},
+	}
+	for _, tt := range tests {
+		e := valid
+		tt.edit(&e)
+		err := ValidateException(e)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: got %v, want an error containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestApplyExceptions(t *testing.T) {
+	platform.OpenTestDB(t)
+	add := func(service, rule string, expires time.Time) int64 {
+		id, err := platform.CreateException(platform.PolicyException{Service: service, Rule: rule, Owner: "ops", Reason: "test", ExpiresAt: expires})
+		if err != nil {
+			t.Fatalf("create exception: %v", err)
+		}
+		return id
+	}
+	soon := time.Now().Add(24 * time.Hour)
+	rootID := add("web", RuleRootUser, soon)
+	add("web", RuleLatestTag, time.Now().Add(-time.Hour)) // expired
+	add("api", RuleSecretEnv, soon)                       // other service
+	// Stored directly, as before ValidateException rejected it
+	add("web", RuleSpecInvalid, soon)
+
+	findings := []Finding{
+		{Rule: RuleRootUser, Severity: "MEDIUM", Detail: "root", Action: ActionBlock},
+		{Rule: RuleLatestTag, Severity: "MEDIUM", Detail: "latest", Action: ActionBlock},
+		{Rule: RuleSecretEnv, Severity: "HIGH", Detail: "secret", Action: ActionBlock},
+		{Rule: RuleSpecInvalid, Severity: "CRITICAL", Detail: "bad level", Action: ActionBlock},
+		{Rule: RuleMissingLabels, Severity: "LOW", Detail: "labels", Action: ActionWarn},
+	}
+	got := (&Gatekeeper{}).ApplyExceptions("web", findings)
+
+	want := []struct {
+		action PolicyAction
+		id     int64
+	}{
+		{ActionExcepted, rootID},
+		{ActionBlock, 0}, // expired exception
+		{ActionBlock, 0}, // exception of another service
+		{ActionBlock, 0}, // spec.invalid is never waived
+		{ActionWarn, 0},  // no exception
+	}
+	for i, w := range want {
+		if got[i].Action != w.action || got[i].ExceptionID != w.id {
+			t.Errorf("%s: action %s (exception %d), want %s (%d)", got[i].Rule, got[i].Action, got[i].ExceptionID, w.action, w.id)
+		}
+	}
+	if !strings.Contains(got[0].Detail, "waived by exception") {
+		t.Errorf("waived finding does not say so: %q", got[0].Detail)
+	}
+	if ids := ExceptionsUsed(got); len(ids) != 1 || ids[0] != rootID {
+		t.Errorf("ExceptionsUsed = %v, want [%d]", ids, rootID)
+	}
+	if !Blocked(got) {
+		t.Error("admission with unwaived blocking findings not blocked")
+	}
+}
@@ -0,0 +1,115 @@
+package security
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/orchestrator"
+
+	"github.com/cilium/ebpf"
+)
+
+// maxDentryName mirrors MAX_DENTRY_NAME (exec deny keys, blocked events)
+const maxDentryName = 64
+
+// execDenySyncInterval is how often the kernel deny map follows container starts and
+// policy changes (on top of the runtime policy cache TTL)
+const execDenySyncInterval = 10 * time.Second
+
+// execDenyKey mirrors struct exec_deny_key in guardian.c
+type execDenyKey struct {
+	CgroupID uint64
+	Name     [maxDentryName]byte
+}
+
+// ValidateDenyExec rejects deny_exec entries the kernel cannot match
+func ValidateDenyExec(names []string) error {
+	for i, n := range names {
+		switch {
+		case n == "" || n == "." || n == "..":
+			return fmt.Errorf("deny_exec[%d]: empty binary name", i)
+		case strings.Contains(n, "/"):
+			return fmt.Errorf("deny_exec[%d]: '%s' must be a binary name, not a path", i, n)
+		case len(n) >= maxDentryName:
+			return fmt.Errorf("deny_exec[%d]: '%s' is longer than %d bytes", i, n, maxDentryName-1)
+		}
+	}
+	return nil
+}
+
+// execDenied reports whether the executed file is on the deny list (userspace fallback)
+func execDenied(deny []string, filename string) bool {
+	base := path.Base(filename)
+	for _, n := range deny {
+		if n == base {
+			return true
+		}
+	}
+	return false
+}
+
+// bpfLSMEnabled reports whether "bpf" is in the active LSM list. Without it an
+// lsm/ program loads and attaches fine but is never called.
+func bpfLSMEnabled() bool {
+	data, err := os.ReadFile("/sys/kernel/security/lsm")
+	if err != nil {
+		return false
+	}
+	for _, name := range strings.Split(strings.TrimSpace(string(data)), ",") {
+		if name == "bpf" {
+			return true
+		}
+	}
+	return false
+}
+
+// syncExecDeny keeps the exec_deny map equal to the deny_exec policy of every running
+// managed container, until done is closed
+func syncExecDeny(m *ebpf.Map, done <-chan struct{}) {
+	installed := map[execDenyKey]bool{}
+	tick := time.NewTicker(execDenySyncInterval)
+	defer tick.Stop()
+
+	for {
+		want := map[execDenyKey]bool{}
+		for info, ids := range orchestrator.LiveContainers() {
+			if !info.Managed {
+				continue
+			}
+			for _, name := range runtimePolicy(info.Service).DenyExec {
+				for _, id := range ids {
+					k := execDenyKey{CgroupID: id}
+					copy(k.Name[:maxDentryName-1], name)
+					want[k] = true
+				}
+			}
+		}
+
+		for k := range want {
+			if installed[k] {
+				continue
+			}
+			if err := m.Put(k, uint32(1)); err != nil {
+				log.Printf("[WARN] Exec deny entry for cgroup %d not installed: %v", k.CgroupID, err)
+				continue
+			}
+			installed[k] = true
+		}
+		for k := range installed {
+			if !want[k] {
+				_ = m.Delete(k)
+				delete(installed, k)
+			}
+		}
+
+		select {
+		case <-done:
+			return
+		case <-tick.C:
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package security
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/guardian"
+	"github.com/Debasish-87/aegis-v/internal/platform"
+)
+
+func TestValidateDenyExec(t *testing.T) {
+	if err := ValidateDenyExec([]string{"nc", "curl", "python3.11"}); err != nil {
+		t.Errorf("valid names: %v", err)
+	}
+	long := make([]byte, maxDentryName)
+	for i := range long {
+		long[i] = 'a'
+	}
+	for _, bad := range []string{"", ".", "..", "/usr/bin/nc", "bin/nc", string(long)} {
+		if err := ValidateDenyExec([]string{"nc", bad}); err == nil {
+			t.Errorf("ValidateDenyExec(%q): want an error", bad)
+		}
+	}
+	if err := ValidateDenyExec([]string{string(long[:maxDentryName-1])}); err != nil {
+		t.Errorf("name of %d bytes: %v", maxDentryName-1, err)
+	}
+}
+
+func TestExecDenied(t *testing.T) {
+	deny := []string{"nc", "curl"}
+	tests := []struct {
+		filename string
+		want     bool
+	}{
+		{"/usr/bin/nc", true},
+		{"nc", true},
+		{"/tmp/x/curl", true},
+		{"/usr/bin/ncat", false},
+		{"/usr/bin/nc/", true},
+		{"/usr/bin/sh", false},
+	}
+	for _, tt := range tests {
+		if got := execDenied(deny, tt.filename); got != tt.want {
+			t.Errorf("execDenied(%q) = %v, want %v", tt.filename, got, tt.want)
+		}
+	}
+	if execDenied(nil, "/usr/bin/nc") {
+		t.Error("empty deny list denied an exec")
+	}
+}
+
+// deniedResponses lists the responses stored for denied execs of one pid
+func deniedResponses(t *testing.T, pid int) []string {
+	t.Helper()
+	rows, err := platform.DB.Query("SELECT response FROM detections WHERE detection_type = ? AND pid = ?", guardian.DetectionExec, pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var r string
+		rows.Scan(&r)
+		out = append(out, r)
+	}
+	return out
+}
+
+func TestDenyExecResponse(t *testing.T) {
+	detectionDB(t)
+	now := time.Now()
+	ctx := containerCtx("deny-web-1", "deny-web", "medium")
+	ctx.Policy.DenyExec = []string{"nc"}
+	nc := Event{Pid: 800, Ppid: 799, Comm: comm16("nc"), Filename: "/usr/bin/nc"}
+
+	// Without BPF LSM the binary already runs: SIGKILL fallback
+	r := &fakeResponder{}
+	(&pipeline{selfPid: 1, respond: r}).handle(nc, ctx, now)
+	if len(r.killed) != 1 || r.killed[0] != 800 {
+		t.Errorf("fallback killed %v, want [800]", r.killed)
+	}
+	if got := deniedResponses(t, 800); len(got) != 1 || got[0] != "KILLED" {
+		t.Errorf("fallback responses = %v", got)
+	}
+
+	// With BPF LSM the exec never happened; the kernel event is reported instead
+	nc.Pid = 801
+	r = &fakeResponder{}
+	p := &pipeline{selfPid: 1, respond: r, blocking: true}
+	p.handle(nc, ctx, now)
+	if len(r.killed) != 0 || len(deniedResponses(t, 801)) != 0 {
+		t.Errorf("blocking mode acted on the exec event: killed %v", r.killed)
+	}
+	p.handle(BlockedExecEvent{Pid: 801, Comm: comm16("sh"), Binary: "nc", Path: "/usr/bin/nc"}, ctx, now)
+	if got := deniedResponses(t, 801); len(got) != 1 || got[0] != "BLOCKED" {
+		t.Errorf("blocked responses = %v", got)
+	}
+}
@@ -0,0 +1,103 @@
+package security
+
+import (
+	"sort"
+	"time"
+)
+
+// execSettleTimeout bounds how long an exec is held for its sched_process_exec:
+// an execve that fails never completes
+const execSettleTimeout = 100 * time.Millisecond
+
+// settledEvent is a decoded record ready for the pipeline, with when it was read
+type settledEvent struct {
+	decoded interface{}
+	arrived time.Time
+}
+
+// execSettler holds each exec (read at sys_enter_execve, while the caller's image
+// still runs) until the kernel reports it completed, so that trust and drift look
+// at the new image in /proc/<pid>/exe. Only used when the process tree probes
+// deliver sched_process_exec; the proc connector reports completed execs already.
+type execSettler struct {
+	held map[uint32]settledEvent // by pid
+}
+
+func newExecSettler() *execSettler {
+	return &execSettler{held: map[uint32]settledEvent{}}
+}
+
+// add takes the next record and returns what can go to the pipeline, in order.
+// A held exec is released after its completion, or before any later record of
+// the same pid that shows it did not happen (another attempt, a refusal, a fork
+// or exit of the caller).
+func (s *execSettler) add(decoded interface{}, now time.Time) []settledEvent {
+	cur := settledEvent{decoded, now}
+	switch ev := decoded.(type) {
+	case Event:
+		out := s.release(ev.Pid, nil)
+		s.held[ev.Pid] = cur
+		return out
+	case ProcessEvent:
+		if ev.Kind == eventSchExec {
+			if h, ok := s.held[ev.Pid]; ok {
+				delete(s.held, ev.Pid)
+				return []settledEvent{cur, h}
+			}
+			return []settledEvent{cur}
+		}
+		return s.release(ev.Pid, &cur)
+	case BlockedExecEvent:
+		return s.release(ev.Pid, &cur)
+	}
+	return []settledEvent{cur}
+}
+
+// release returns the exec held for pid, if any, followed by next
+func (s *execSettler) release(pid uint32, next *settledEvent) []settledEvent {
+	var out []settledEvent
+	if h, ok := s.held[pid]; ok {
+		delete(s.held, pid)
+		out = append(out, h)
+	}
+	if next != nil {
+		out = append(out, *next)
+	}
+	return out
+}
+
+// expire releases the execs held for execSettleTimeout or longer, oldest first
+func (s *execSettler) expire(now time.Time) []settledEvent {
+	return s.take(func(h settledEvent) bool { return now.Sub(h.arrived) >= execSettleTimeout })
+}
+
+// flush releases every held exec, oldest first
+func (s *execSettler) flush() []settledEvent {
+	return s.take(func(settledEvent) bool { return true })
+}
+
+func (s *execSettler) take(match func(settledEvent) bool) []settledEvent {
+	var out []settledEvent
+	for pid, h := range s.held {
+		if match(h) {
+			delete(s.held, pid)
+			out = append(out, h)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].arrived.Before(out[j].arrived) })
+	return out
+}
+
+// deadline is when the oldest held exec expires; zero when nothing is held
+func (s *execSettler) deadline() time.Time {
+	var oldest time.Time
+	for _, h := range s.held {
+		if oldest.IsZero() || h.arrived.Before(oldest) {
+			oldest = h.arrived
+		}
+	}
+	if oldest.IsZero() {
+		return time.Time{}
+	}
+	return oldest.Add(execSettleTimeout)
+}
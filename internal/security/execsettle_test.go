@@ -0,0 +1,115 @@
+package security
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// label names a settled record for comparison, e.g. exec:10:/bin/sh or sched_exec:10
+func label(decoded interface{}) string {
+	switch ev := decoded.(type) {
+	case Event:
+		return fmt.Sprintf("exec:%d:%s", ev.Pid, ev.Filename)
+	case ProcessEvent:
+		return fmt.Sprintf("%s:%d", map[int]string{eventFork: "fork", eventSchExec: "sched_exec", eventExit: "exit"}[ev.Kind], ev.Pid)
+	case BlockedExecEvent:
+		return fmt.Sprintf("blocked:%d", ev.Pid)
+	case CredEvent:
+		return fmt.Sprintf("cred:%d", ev.Pid)
+	}
+	return "?"
+}
+
+func TestExecSettlerOrder(t *testing.T) {
+	exec := func(pid uint32, file string) Event { return Event{Pid: pid, Filename: file} }
+	proc := func(kind int, pid uint32) ProcessEvent { return ProcessEvent{Kind: kind, Pid: pid} }
+	tests := []struct {
+		name string
+		in   []interface{}
+		want string // released records, in order; | separates the records' batches
+	}{
+		{
+			name: "exec waits for its completion",
+			in:   []interface{}{exec(10, "/bin/sh"), proc(eventSchExec, 10)},
+			want: "|sched_exec:10,exec:10:/bin/sh",
+		},
+		{
+			name: "credential change of a setuid exec passes through",
+			in:   []interface{}{exec(10, "/usr/bin/su"), CredEvent{Pid: 10}, proc(eventSchExec, 10)},
+			want: "|cred:10|sched_exec:10,exec:10:/usr/bin/su",
+		},
+		{
+			name: "failed attempt released by the next one",
+			in:   []interface{}{exec(10, "/usr/local/bin/sh"), exec(10, "/bin/sh"), proc(eventSchExec, 10)},
+			want: "|exec:10:/usr/local/bin/sh|sched_exec:10,exec:10:/bin/sh",
+		},
+		{
+			name: "refused exec released before the refusal",
+			in:   []interface{}{exec(10, "/bin/nc"), BlockedExecEvent{Pid: 10}},
+			want: "|exec:10:/bin/nc,blocked:10",
+		},
+		{
+			name: "caller exits after a failed exec",
+			in:   []interface{}{exec(10, "/missing"), proc(eventExit, 10)},
+			want: "|exec:10:/missing,exit:10",
+		},
+		{
+			name: "other pids are not held up",
+			in:   []interface{}{exec(10, "/bin/sh"), proc(eventFork, 11), exec(12, "/bin/ls"), proc(eventSchExec, 12)},
+			want: "|fork:11||sched_exec:12,exec:12:/bin/ls",
+		},
+	}
+	for _, tt := range tests {
+		s := newExecSettler()
+		now := time.Now()
+		var batches []string
+		for _, ev := range tt.in {
+			var names []string
+			for _, r := range s.add(ev, now) {
+				names = append(names, label(r.decoded))
+			}
+			batches = append(batches, strings.Join(names, ","))
+		}
+		if got := strings.Join(batches, "|"); got != tt.want {
+			t.Errorf("%s: released %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExecSettlerExpire(t *testing.T) {
+	s := newExecSettler()
+	start := time.Now()
+	if !s.deadline().IsZero() {
+		t.Fatal("deadline set with nothing held")
+	}
+	s.add(Event{Pid: 10, Filename: "/bin/a"}, start)
+	s.add(Event{Pid: 11, Filename: "/bin/b"}, start.Add(50*time.Millisecond))
+	if got, want := s.deadline(), start.Add(execSettleTimeout); !got.Equal(want) {
+		t.Errorf("deadline = %v, want %v", got, want)
+	}
+
+	if out := s.expire(start.Add(execSettleTimeout - time.Millisecond)); len(out) != 0 {
+		t.Errorf("expired %d execs before the timeout", len(out))
+	}
+	out := s.expire(start.Add(execSettleTimeout))
+	if len(out) != 1 || label(out[0].decoded) != "exec:10:/bin/a" || !out[0].arrived.Equal(start) {
+		t.Errorf("expire released %v, want the first exec with its arrival time", out)
+	}
+	if got, want := s.deadline(), start.Add(50*time.Millisecond+execSettleTimeout); !got.Equal(want) {
+		t.Errorf("deadline after expiry = %v, want %v", got, want)
+	}
+
+	s.add(Event{Pid: 12, Filename: "/bin/c"}, start.Add(60*time.Millisecond))
+	var names []string
+	for _, r := range s.flush() {
+		names = append(names, label(r.decoded))
+	}
+	if got := strings.Join(names, ","); got != "exec:11:/bin/b,exec:12:/bin/c" {
+		t.Errorf("flush released %q, want oldest first", got)
+	}
+	if len(s.held) != 0 {
+		t.Errorf("%d execs still held after flush", len(s.held))
+	}
+}
@@ -0,0 +1,107 @@
+package security
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Debasish-87/aegis-v/internal/trust"
+)
+
+// maxSampleSize bounds what is copied to the forensics directory; larger images
+// are reported by hash only
+const maxSampleSize = 64 << 20
+
+// sample is the file a fileless exec ran, hashed while the process still held it
+type sample struct {
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+	Exe    string `json:"exe"` // e.g. "/memfd:payload (deleted)" or "/tmp/x"
+	from   string // where the bytes can be read from; live only
+}
+
+// captureSample hashes the file of a fileless exec. For memfd, unlinked and tmpfs
+// images that is /proc/<pid>/exe, the only handle left on it; an exec from a temp
+// directory may be a script, so there the invoked file itself is kept.
+func captureSample(ev FilelessEvent) *sample {
+	if ev.Flags&^filelessTmpdir == 0 {
+		return sampleOf(fmt.Sprintf("/proc/%d/root%s", ev.Pid, ev.Filename), ev.Filename)
+	}
+	link := fmt.Sprintf("/proc/%d/exe", ev.Pid)
+	s := sampleOf(link, "")
+	if s == nil {
+		return nil
+	}
+	// Hash first, then make sure it was the image of this exec (see execImage)
+	exe, err := os.Readlink(link)
+	if err != nil || path.Base(strings.TrimSuffix(exe, " (deleted)")) != ev.Name {
+		return nil
+	}
+	s.Exe = exe
+	return s
+}
+
+func sampleOf(from, exe string) *sample {
+	fi, err := os.Stat(from)
+	if err != nil || !fi.Mode().IsRegular() {
+		return nil
+	}
+	id, err := trust.OfFile(from)
+	if err != nil {
+		return nil
+	}
+	return &sample{SHA256: id.SHA256, Size: fi.Size(), Exe: exe, from: from}
+}
+
+// preserveSample copies a sample to <dir>/<sha256>.bin (read-only) and returns the
+// path. The copy is hashed on the way and dropped if the file changed since capture.
+func preserveSample(dir string, s *sample) (string, error) {
+	switch {
+	case dir == "":
+		return "", fmt.Errorf("no forensics directory (AEGIS_FORENSICS_DIR)")
+	case s.from == "":
+		return "", fmt.Errorf("sample %s was not captured live", s.SHA256)
+	case s.Size > maxSampleSize:
+		return "", fmt.Errorf("sample %s is %d bytes, over the %d byte limit", s.SHA256, s.Size, maxSampleSize)
+	}
+
+	dst := filepath.Join(dir, s.SHA256+".bin")
+	if _, err := os.Stat(dst); err == nil {
+		return dst, nil // same content kept before
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	src, err := os.Open(s.from)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	tmp, err := os.CreateTemp(dir, ".sample-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, h), io.LimitReader(src, maxSampleSize+1))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != s.SHA256 {
+		return "", fmt.Errorf("sample of %s changed after capture (now %s)", s.Exe, sum)
+	}
+	if err := os.Chmod(tmp.Name(), 0400); err != nil {
+		return "", err
+	}
+	return dst, os.Rename(tmp.Name(), dst)
+}
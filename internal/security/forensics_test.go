@@ -0,0 +1,127 @@
+package security
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/guardian"
+	"github.com/Debasish-87/aegis-v/internal/platform"
+)
+
+func TestFilelessReasons(t *testing.T) {
+	ev := FilelessEvent{Flags: filelessTmpdir | filelessMemfd}
+	if got := ev.Reasons(); !slices.Equal(got, []string{"memfd", "tmpdir"}) {
+		t.Errorf("Reasons() = %v", got)
+	}
+	if got := (FilelessEvent{}).Reasons(); got != nil {
+		t.Errorf("no flags: Reasons() = %v", got)
+	}
+}
+
+// payload writes a file to a temporary directory and returns its path and hash
+func payload(t *testing.T, content string) (string, string) {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "payload")
+	if err := os.WriteFile(p, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(content))
+	return p, hex.EncodeToString(sum[:])
+}
+
+func TestCaptureSample(t *testing.T) {
+	pid := uint32(os.Getpid())
+
+	// A temp-directory exec may be a script: the invoked file is kept
+	file, sum := payload(t, "#!/bin/sh\nid\n")
+	s := captureSample(FilelessEvent{Pid: pid, Filename: file, Name: "payload", Flags: filelessTmpdir})
+	if s == nil || s.SHA256 != sum || s.Exe != file || s.Size != int64(len("#!/bin/sh\nid\n")) {
+		t.Fatalf("tmpdir sample = %+v", s)
+	}
+
+	// Otherwise the running image, as long as it is the one the exec named
+	self, _ := os.Executable()
+	s = captureSample(FilelessEvent{Pid: pid, Filename: "/dev/fd/3", Name: filepath.Base(self), Flags: filelessMemfd})
+	if s == nil || s.Exe != self || s.SHA256 == "" {
+		t.Errorf("memfd sample = %+v", s)
+	}
+	if s := captureSample(FilelessEvent{Pid: pid, Filename: "/dev/fd/3", Name: "other", Flags: filelessMemfd}); s != nil {
+		t.Errorf("image of a later exec captured: %+v", s)
+	}
+	if s := captureSample(FilelessEvent{Pid: pid, Filename: "/tmp/gone", Name: "gone", Flags: filelessTmpdir}); s != nil {
+		t.Errorf("missing file captured: %+v", s)
+	}
+}
+
+func TestPreserveSample(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "forensics")
+	file, sum := payload(t, "payload v1")
+	s := sampleOf(file, file)
+
+	kept, err := preserveSample(dir, s)
+	if err != nil || kept != filepath.Join(dir, sum+".bin") {
+		t.Fatalf("preserveSample = %s, %v", kept, err)
+	}
+	fi, err := os.Stat(kept)
+	if err != nil || fi.Mode().Perm() != 0400 {
+		t.Errorf("kept sample mode = %v, %v", fi.Mode(), err)
+	}
+	// Same content again is not copied twice
+	if again, err := preserveSample(dir, s); err != nil || again != kept {
+		t.Errorf("second preserve = %s, %v", again, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("forensics dir holds %d files", len(entries))
+	}
+
+	// Changed between capture and copy: nothing kept
+	other := filepath.Join(t.TempDir(), "forensics")
+	os.WriteFile(file, []byte("payload v2"), 0755)
+	if _, err := preserveSample(other, s); err == nil {
+		t.Error("changed sample preserved")
+	}
+	if entries, _ := os.ReadDir(other); len(entries) != 0 {
+		t.Errorf("changed sample left %d files", len(entries))
+	}
+
+	if _, err := preserveSample("", s); err == nil {
+		t.Error("no forensics dir: want an error")
+	}
+	if _, err := preserveSample(dir, &sample{SHA256: sum}); err == nil {
+		t.Error("replayed sample: want an error")
+	}
+	if _, err := preserveSample(dir, &sample{SHA256: sum, Size: maxSampleSize + 1, from: file}); err == nil {
+		t.Error("oversized sample: want an error")
+	}
+}
+
+func TestHandleFileless(t *testing.T) {
+	detectionDB(t)
+	dir := filepath.Join(t.TempDir(), "forensics")
+	file, sum := payload(t, "\x7fELF fileless")
+	ctx := containerCtx("fl-web-1", "fl-web", "medium")
+	ctx.Sample = sampleOf(file, "/memfd:payload (deleted)")
+
+	p := &pipeline{selfPid: 1, respond: liveResponder{forensicsDir: func() string { return dir }}}
+	p.handle(FilelessEvent{Pid: 960, Ppid: 959, Comm: comm16("payload"), Filename: "/dev/fd/3", Name: "memfd:payload", Flags: filelessMemfd | filelessUnlinked}, ctx, time.Now())
+
+	var gotSum, reasons, kept string
+	err := platform.DB.QueryRow("SELECT sha256, fileless, sample FROM detections WHERE detection_type = ? AND pid = 960", guardian.DetectionFileless).Scan(&gotSum, &reasons, &kept)
+	if err != nil {
+		t.Fatalf("no detection stored: %v", err)
+	}
+	if gotSum != sum || kept != filepath.Join(dir, sum+".bin") || reasons == "" {
+		t.Errorf("detection sha256 %s, fileless %q, sample %s", gotSum, reasons, kept)
+	}
+
+	// Execs forked by the engine itself are not reported
+	p.handle(FilelessEvent{Pid: 961, Ppid: 1, Comm: comm16("payload"), Flags: filelessMemfd}, ctx, time.Now())
+	if n := detections(t, guardian.DetectionFileless); n != 1 {
+		t.Errorf("%d fileless detections, want 1", n)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+
+	"github.com/Debasish-87/aegis-v/internal/platform"
 )
 
 // Gatekeeper handles Supply Chain Integrity & Policy Enforcement
@@ -11,6 +13,10 @@ type Gatekeeper struct {
 	EnforceSigning    bool
 	AllowedRegistries []string
 	BlockedKeywords   []string
+	// RequiredLabels must be present on the pulled image config (empty = any label)
+	RequiredLabels []string
+	// ScanFilesystem exports pulled images and walks their layers (AEGIS_IMAGE_FS_SCAN=1)
+	ScanFilesystem bool
 }
 
 // NewGatekeeper initializes a production-ready guard
@@ -28,16 +34,53 @@ func NewGatekeeper() *Gatekeeper {
 		BlockedKeywords: []string{
 			"vulnerable", "exploit", "malware", "test-build",
 		},
+		ScanFilesystem: platform.EnvBool("AEGIS_IMAGE_FS_SCAN", false),
 	}
 }
 
-// VerifyImage performs a multi-layer security check on the image
-func (g *Gatekeeper) VerifyImage(imageName string) (bool, string) {
+// Pre-pull admission rules (spec + image reference)
+const (
+	RuleSpecInvalid       = "spec.invalid"
+	RuleLatestTag         = "image.tag.latest"
+	RuleUntrustedRegistry = "image.registry.untrusted"
+	RuleBlockedKeyword    = "image.keyword.blocked"
+	RuleMalformedImage    = "image.name.malformed"
+)
+
+var (
+	validImagePattern   = regexp.MustCompile(`^[a-z0-9]+(?:[._-][a-z0-9]+)*[:/][a-z0-9._-]+$`)
+	validServicePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+)
+
+// EvaluateSpec runs every pre-pull check and reports all violations, not just the first.
+// These rules are hard requirements, so they block at every security_level.
+func (g *Gatekeeper) EvaluateSpec(name, imageName string) []Finding {
+	var findings []Finding
+	block := func(rule, detail string) {
+		findings = append(findings, Finding{Rule: rule, Severity: "CRITICAL", Detail: detail, Action: ActionBlock})
+	}
+
+	if !validServicePattern.MatchString(name) {
+		block(RuleSpecInvalid, fmt.Sprintf("Service name '%s' is empty or not a valid container name.", name))
+	}
+	if imageName == "" {
+		block(RuleSpecInvalid, "Image is required.")
+		return findings
+	}
+	return append(findings, g.EvaluateImage(imageName)...)
+}
+
+// EvaluateImage runs the image reference checks and collects every violation
+func (g *Gatekeeper) EvaluateImage(imageName string) []Finding {
+	var findings []Finding
+	block := func(rule, detail string) {
+		findings = append(findings, Finding{Rule: rule, Severity: "CRITICAL", Detail: detail, Action: ActionBlock})
+	}
 
 	// 1. Strict Versioning Check (Prevent Supply Chain Poisoning)
 	// Block 'latest' tag or images without any version tag
 	if strings.HasSuffix(imageName, ":latest") || !strings.Contains(imageName, ":") {
-		return false, "Policy Violation: Specific version tags are required. 'latest' is forbidden."
+		block(RuleLatestTag, "Policy Violation: Specific version tags are required. 'latest' is forbidden.")
 	}
 
 	// 2. Registry Whitelisting
@@ -51,21 +94,30 @@ func (g *Gatekeeper) VerifyImage(imageName string) (bool, string) {
 	}
 
 	if !isTrusted && g.EnforceSigning {
-		return false, fmt.Sprintf("Untrusted Source: Registry for '%s' is not in the whitelist.", imageName)
+		block(RuleUntrustedRegistry, fmt.Sprintf("Untrusted Source: Registry for '%s' is not in the whitelist.", imageName))
 	}
 
 	// 3. SBOM & Keyword Scan (Heuristic Analysis)
 	for _, word := range g.BlockedKeywords {
 		if strings.Contains(strings.ToLower(imageName), word) {
-			return false, fmt.Sprintf("Security Risk: Image name contains blacklisted keyword '%s'.", word)
+			block(RuleBlockedKeyword, fmt.Sprintf("Security Risk: Image name contains blacklisted keyword '%s'.", word))
 		}
 	}
 
 	// 4. Integrity Check (Regex validation for valid image format)
-	validPattern := regexp.MustCompile(`^[a-z0-9]+(?:[._-][a-z0-9]+)*[:/][a-z0-9._-]+$`)
-	if !validPattern.MatchString(imageName) {
-		return false, "Malformed Image Name: Potential Injection Attempt."
+	if !validImagePattern.MatchString(imageName) {
+		block(RuleMalformedImage, "Malformed Image Name: Potential Injection Attempt.")
 	}
 
+	return findings
+}
+
+// VerifyImage performs a multi-layer security check on the image and returns the first violation
+func (g *Gatekeeper) VerifyImage(imageName string) (bool, string) {
+	for _, f := range g.EvaluateImage(imageName) {
+		if f.Action == ActionBlock {
+			return false, f.Detail
+		}
+	}
 	return true, "Verified: Image meets AEGIS-V security standards."
 }
@@ -0,0 +1,78 @@
+package security
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+)
+
+func rulesOf(findings []Finding) []string {
+	var out []string
+	for _, f := range findings {
+		out = append(out, f.Rule)
+	}
+	return out
+}
+
+func TestEvaluateSpecReportsEveryViolation(t *testing.T) {
+	g := NewGatekeeper()
+	tests := []struct {
+		name, service, image string
+		want                 []string
+	}{
+		{"clean", "web", "nginx:1.25", nil},
+		{"no image", "web", "", []string{RuleSpecInvalid}},
+		{"bad name and no image", "-web", "", []string{RuleSpecInvalid, RuleSpecInvalid}},
+		{"latest tag", "web", "nginx:latest", []string{RuleLatestTag}},
+		{"untagged, untrusted, blocked keyword", "web", "evil.io/exploit-kit",
+			[]string{RuleLatestTag, RuleUntrustedRegistry, RuleBlockedKeyword}},
+		{"injection", "web", "nginx:1.25;rm -rf /", []string{RuleMalformedImage}},
+	}
+	for _, tt := range tests {
+		got := rulesOf(g.EvaluateSpec(tt.service, tt.image))
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: rules %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	for _, f := range g.EvaluateSpec("-web", "evil.io/exploit-kit") {
+		if f.Action != ActionBlock {
+			t.Errorf("pre-pull finding %s: %s, want BLOCK", f.Rule, f.Action)
+		}
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	g := NewGatekeeper()
+	g.ScanFilesystem = false
+
+	// Not local: only the pre-pull checks, and a note saying so
+	findings, notes := g.DryRun("web", "nginx:1.25", "high", nil, nil)
+	if len(findings) != 0 {
+		t.Errorf("not local: findings %+v", findings)
+	}
+	if len(notes) != 1 || !strings.Contains(notes[0], "not present locally") {
+		t.Errorf("not local: notes %v", notes)
+	}
+
+	// Local: the post-pull config checks run at the given level
+	img := &types.ImageInspect{ID: "sha256:abc", Config: &container.Config{}}
+	findings, notes = g.DryRun("web", "nginx:1.25", "high", img, nil)
+	if len(notes) != 0 {
+		t.Errorf("local: notes %v", notes)
+	}
+	if !slices.Contains(rulesOf(findings), RuleRootUser) || !Blocked(findings) {
+		t.Errorf("local at high: findings %+v, want a blocking %s", findings, RuleRootUser)
+	}
+	if findings, _ = g.DryRun("web", "nginx:1.25", "audit", img, nil); Blocked(findings) {
+		t.Errorf("local at audit: findings %+v block", findings)
+	}
+
+	// No image: the spec finding alone, post-pull checks are not attempted
+	findings, notes = g.DryRun("web", "", "high", img, nil)
+	if got := rulesOf(findings); !slices.Equal(got, []string{RuleSpecInvalid}) || notes != nil {
+		t.Errorf("no image: rules %v, notes %v", got, notes)
+	}
+}
@@ -3,12 +3,54 @@
 #include <linux/bpf.h>
 #include <bpf/bpf_helpers.h>
 #include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include <bpf/bpf_endian.h>
 
 // Standard types for absolute compatibility
 typedef unsigned int u32;
+typedef unsigned short u16;
 typedef unsigned char u8;
 typedef unsigned long long u64;
 
+// Capture limits (the Go decoder in monitor.go mirrors these)
+#define MAX_FILENAME_LEN 256
+#define MAX_ARGS         16
+#define MAX_ARG_LEN      128
+#define MAX_CWD_DEPTH    8
+#define MAX_DENTRY_NAME  64
+#define MAX_PATH_LEN     128
+#define MAX_DATA (MAX_FILENAME_LEN + MAX_ARGS * MAX_ARG_LEN + MAX_CWD_DEPTH * MAX_DENTRY_NAME)
+
+// Event flags
+#define FLAG_ARGV_TRUNCATED 0x1
+#define FLAG_CWD_TRUNCATED  0x2
+
+// Event types: every ring buffer record starts with one of these
+#define EVENT_EXEC       1
+#define EVENT_CONNECT    2
+#define EVENT_OPEN       3
+#define EVENT_CRED       4
+#define EVENT_BLOCKED    5
+#define EVENT_FORK       6
+#define EVENT_SCHED_EXEC 7
+#define EVENT_EXIT       8
+#define EVENT_FILELESS   9
+
+// Why an exec counts as fileless (fileless_event.flags)
+#define FILELESS_MEMFD    0x1 // memfd_create() file, e.g. fexecve() of an in-memory payload
+#define FILELESS_UNLINKED 0x2 // no link left on any filesystem
+#define FILELESS_TMPFS    0x4 // on tmpfs (/dev/shm, /run, ...)
+#define FILELESS_TMPDIR   0x8 // invoked from /tmp, /var/tmp or /dev/shm
+
+#define TMPFS_MAGIC 0x01021994
+
+#define EPERM 1
+
+#define AF_INET    2
+#define AF_INET6   10
+#define PROTO_TCP  6
+#define PROTO_UDP  17
+
 // --- KERNEL STRUCT SHIMS ---
 // Yeh definitions compiler ke liye hain taaki "incomplete definition" error na aaye.
 // CO-RE (Compile Once - Run Everywhere) attribute ke saath.
@@ -25,96 +67,764 @@ struct nsproxy {
     struct mnt_namespace *mnt_ns;
 };
 
+struct qstr {
+    const unsigned char *name;
+} __attribute__((preserve_access_index));
+
+struct dentry {
+    struct dentry *d_parent;
+    struct qstr d_name;
+} __attribute__((preserve_access_index));
+
+struct path {
+    struct dentry *dentry;
+} __attribute__((preserve_access_index));
+
+struct fs_struct {
+    struct path pwd;
+} __attribute__((preserve_access_index));
+
+struct in6_addr {
+    u8 u6_addr8[16];
+};
+
+struct sock_common {
+    u32 skc_daddr;
+    u16 skc_dport;
+    unsigned short skc_family;
+    struct in6_addr skc_v6_daddr;
+} __attribute__((preserve_access_index));
+
+struct sock {
+    struct sock_common __sk_common;
+} __attribute__((preserve_access_index));
+
+struct sockaddr;
+
+typedef struct {
+    u32 val;
+} __attribute__((preserve_access_index)) kuid_t;
+
+// kernel_cap_t is u32[2] before 6.3 and a u64 since; both are read as 8 raw bytes
+typedef struct {
+    u64 val;
+} kernel_cap_t;
+
+struct cred {
+    kuid_t uid;
+    kuid_t euid;
+    kernel_cap_t cap_effective;
+} __attribute__((preserve_access_index));
+
+struct super_block {
+    unsigned long s_magic;
+} __attribute__((preserve_access_index));
+
+struct inode {
+    unsigned int i_nlink;
+    struct super_block *i_sb;
+} __attribute__((preserve_access_index));
+
+struct file {
+    struct path f_path;
+    struct inode *f_inode;
+} __attribute__((preserve_access_index));
+
+struct linux_binprm {
+    struct file *file;
+    const char *filename;
+} __attribute__((preserve_access_index));
+
+// UAPI struct from linux/openat2.h (stable layout, no CO-RE needed)
+struct open_how {
+    u64 flags;
+    u64 mode;
+    u64 resolve;
+};
+
 struct task_struct {
     struct nsproxy *nsproxy;
     struct task_struct *real_parent;
+    struct fs_struct *fs;
+    const struct cred *cred;
+    int pid;
     int tgid;
+    u64 start_time;
 } __attribute__((preserve_access_index));
 
-// Event struct: Go side ke struct (Event) se bit-by-bit match karta hai
-struct event {
+// Raw syscall tracepoint context (fixed tracefs format, not CO-RE)
+struct sys_enter_ctx {
+    u64 common;
+    long syscall_nr;
+    unsigned long args[6];
+};
+
+// Common header: Go side ka eventHeader isse bit-by-bit match karta hai
+struct event_header {
+    u32 type;      // EVENT_EXEC / EVENT_CONNECT / EVENT_OPEN / EVENT_CRED / EVENT_BLOCKED
     u32 pid;
     u32 ppid;      // Parent PID: Kaunsa process ise trigger kar raha hai
     u32 uid;       // User ID: 0 (root) hai ya normal user
     u32 mnt_ns;    // Mount Namespace: Container identification key
+    u32 _pad;
+    u64 cgroup_id; // cgroup v2 ID: userspace cache isse container resolve karta hai
     u8 comm[16];   // Command name
 };
 
-// 1. Ring Buffer Map: Kernel-to-User communication
+// Exec event. data[] holds, back to back: filename, argv strings (NUL separated),
+// cwd components (leaf first, NUL separated). Only the used part is sent to userspace.
+struct exec_event {
+    struct event_header h;
+    u16 filename_len;
+    u16 argv_len;
+    u16 cwd_len;
+    u8 argc;
+    u8 flags;
+    u8 data[MAX_DATA];
+};
+
+// Outbound connect (TCP connect, or connect() on a UDP socket)
+struct connect_event {
+    struct event_header h;
+    u16 family;
+    u16 dport;     // host byte order
+    u8 protocol;   // PROTO_TCP / PROTO_UDP
+    u8 _pad[3];
+    u8 daddr[16];  // IPv4 uses the first 4 bytes
+};
+
+// Open of a path below one of the sensitive prefixes
+struct open_event {
+    struct event_header h;
+    u32 flags;     // open(2) flags (O_WRONLY, O_CREAT, ...)
+    u16 path_len;
+    u16 _pad;
+    u8 path[MAX_PATH_LEN];
+};
+
+// Credential change that raised privileges (old = current creds, new = committed ones)
+struct cred_event {
+    struct event_header h;
+    u32 old_uid;
+    u32 new_uid;
+    u32 old_euid;
+    u32 new_euid;
+    u64 old_caps;  // effective capability set, bit N = CAP_N
+    u64 new_caps;
+};
+
+// Exec refused by the LSM hook
+struct blocked_event {
+    struct event_header h;
+    u8 name[MAX_DENTRY_NAME];  // the denied name that matched
+    u8 path[MAX_PATH_LEN];     // filename as passed to execve
+};
+
+// Process table feed (sched tracepoints). Thread-group leaders only.
+struct fork_event {
+    struct event_header h;   // the forking process
+    u32 child_pid;
+    u32 _pad;
+    u64 child_start;         // task start_time, ns since boot
+};
+
+struct sched_exec_event {
+    struct event_header h;   // comm is already the new image's
+    u64 start_time;
+    u8 filename[MAX_PATH_LEN];
+};
+
+// Exec of a file that leaves nothing (or nothing trustworthy) on disk
+struct fileless_event {
+    struct event_header h;   // comm is already the new image's
+    u64 start_time;
+    u32 flags;               // FILELESS_*
+    u32 _pad;
+    u8 filename[MAX_PATH_LEN]; // as invoked; /dev/fd/N for fexecve()
+    u8 name[MAX_DENTRY_NAME];  // executed file, e.g. memfd:payload
+};
+
+struct exit_event {
+    struct event_header h;
+    u64 start_time;
+};
+
+// Exec deny key: a binary name inside one container cgroup
+struct exec_deny_key {
+    u64 cgroup_id;
+    u8 name[MAX_DENTRY_NAME];
+};
+
+// Monitoring scope: which processes the event probes report (see in_scope)
+#define SCOPE_ALL     0 // every process on the machine
+#define SCOPE_MANAGED 1 // AEGIS-managed container cgroups only
+#define SCOPE_HOST    2 // managed containers plus host processes (initial mount namespace)
+
+struct scope_config {
+    u32 mode;
+    u32 host_mnt_ns;
+};
+
+// LPM trie key: prefixlen is in bits, path bytes are matched left to right
+struct path_key {
+    u32 prefixlen;
+    u8 path[MAX_PATH_LEN];
+};
+
+// Same layout for the exec comm filter
+struct comm_key {
+    u32 prefixlen;
+    u8 comm[16];
+};
+
+// 1. Ring Buffer Map: Kernel-to-User communication (variable-length records).
+// The size is a default; userspace overrides it from AEGIS_RINGBUF_SIZE before loading.
 struct {
     __uint(type, BPF_MAP_TYPE_RINGBUF);
-    __uint(max_entries, 1 << 16);
+    __uint(max_entries, 1 << 20);
 } rb SEC(".maps");
 
-// 2. Filter Map: Specific Container PIDs monitoring ke liye
+// Lost events per type (ring buffer full); per-CPU so the hot path needs no atomics
 struct {
-    __uint(type, BPF_MAP_TYPE_HASH);
+    __uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+    __uint(max_entries, 16);
+    __type(key, u32);
+    __type(value, u64);
+} drops SEC(".maps");
+
+// Per-CPU scratch space: struct exec_event is too big for the 512-byte BPF stack
+struct {
+    __uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+    __uint(max_entries, 1);
+    __type(key, u32);
+    __type(value, struct exec_event);
+} heap SEC(".maps");
+
+// Sensitive path prefixes, loaded from config by userspace (longest prefix match)
+struct {
+    __uint(type, BPF_MAP_TYPE_LPM_TRIE);
+    __uint(max_entries, 256);
+    __type(key, struct path_key);
+    __type(value, u32);
+    __uint(map_flags, BPF_F_NO_PREALLOC);
+} sensitive_paths SEC(".maps");
+
+// Comm prefixes of execs to drop early, synced by userspace from the ignore rules
+// (an exact name is stored with its NUL terminator)
+struct {
+    __uint(type, BPF_MAP_TYPE_LPM_TRIE);
     __uint(max_entries, 1024);
-    __type(key, u32);   
-    __type(value, u32); 
-} filter_map SEC(".maps");
+    __type(key, struct comm_key);
+    __type(value, u32);
+    __uint(map_flags, BPF_F_NO_PREALLOC);
+} exec_ignore SEC(".maps");
+
+// Per-container exec deny policy, synced by userspace from each service's deny_exec
+struct {
+    __uint(type, BPF_MAP_TYPE_HASH);
+    __uint(max_entries, 4096);
+    __type(key, struct exec_deny_key);
+    __type(value, u32);
+} exec_deny SEC(".maps");
+
+// Monitoring scope, set by userspace before any probe is attached
+struct {
+    __uint(type, BPF_MAP_TYPE_ARRAY);
+    __uint(max_entries, 1);
+    __type(key, u32);
+    __type(value, struct scope_config);
+} scope_cfg SEC(".maps");
+
+// cgroup IDs of AEGIS-managed containers, synced by userspace from the container cache
+struct {
+    __uint(type, BPF_MAP_TYPE_HASH);
+    __uint(max_entries, 16384);
+    __type(key, u64);
+    __type(value, u32);
+} scope SEC(".maps");
+
+// in_scope decides whether the current task is monitored at all. Out-of-scope
+// events never reach the ring buffer (process table events are not scoped).
+static __always_inline int in_scope(struct task_struct *task) {
+    u32 zero = 0;
+    struct scope_config *cfg = bpf_map_lookup_elem(&scope_cfg, &zero);
+    if (!cfg || cfg->mode == SCOPE_ALL)
+        return 1;
+
+    u64 cgroup_id = bpf_get_current_cgroup_id();
+    if (bpf_map_lookup_elem(&scope, &cgroup_id))
+        return 1;
+
+    if (cfg->mode == SCOPE_HOST) {
+        u32 mnt_ns = BPF_CORE_READ(task, nsproxy, mnt_ns, ns.inum);
+        return mnt_ns == cfg->host_mnt_ns;
+    }
+    return 0;
+}
+
+// count_drop records an event that could not be submitted
+static __always_inline void count_drop(u32 type) {
+    u64 *n = bpf_map_lookup_elem(&drops, &type);
+    if (n)
+        *n += 1;
+}
+
+// fill_header stamps the identity of the current task on an event
+static __always_inline void fill_header(struct event_header *h, u32 type, struct task_struct *task) {
+    u64 id = bpf_get_current_pid_tgid();
+
+    h->type = type;
+    h->pid = id >> 32;
+    h->uid = bpf_get_current_uid_gid();
+    h->mnt_ns = 0;
+    h->_pad = 0;
+    BPF_CORE_READ_INTO(&h->mnt_ns, task, nsproxy, mnt_ns, ns.inum);
+    h->cgroup_id = bpf_get_current_cgroup_id();
+
+    // Parent PID fetch karo safely
+    struct task_struct *parent = BPF_CORE_READ(task, real_parent);
+    h->ppid = BPF_CORE_READ(parent, tgid);
+    bpf_get_current_comm(&h->comm, sizeof(h->comm));
+}
+
+// capture_argv copies up to MAX_ARGS user strings after the filename
+static __always_inline u32 capture_argv(struct exec_event *e, u32 off, const char *const *argv) {
+    for (int i = 0; i < MAX_ARGS; i++) {
+        const char *argp = 0;
+        bpf_probe_read_user(&argp, sizeof(argp), &argv[i]);
+        if (!argp)
+            return off;
+
+        if (off > MAX_FILENAME_LEN + (MAX_ARGS - 1) * MAX_ARG_LEN)
+            break;
+        long n = bpf_probe_read_user_str(&e->data[off], MAX_ARG_LEN, argp);
+        if (n <= 0)
+            return off;
+        if (n > MAX_ARG_LEN)
+            n = MAX_ARG_LEN;
+        off += n;
+        e->argc++;
+    }
+
+    // More arguments than we keep?
+    const char *next = 0;
+    bpf_probe_read_user(&next, sizeof(next), &argv[MAX_ARGS]);
+    if (next)
+        e->flags |= FLAG_ARGV_TRUNCATED;
+    return off;
+}
+
+// capture_cwd walks pwd towards the root, one dentry name per step (leaf first)
+static __always_inline u32 capture_cwd(struct exec_event *e, u32 off, struct task_struct *task) {
+    struct dentry *d = BPF_CORE_READ(task, fs, pwd.dentry);
+
+    for (int i = 0; i < MAX_CWD_DEPTH; i++) {
+        if (!d)
+            return off;
+        struct dentry *parent = BPF_CORE_READ(d, d_parent);
+        if (parent == d)
+            return off; // reached the (mount) root
+
+        if (off > MAX_DATA - MAX_DENTRY_NAME)
+            return off;
+        const unsigned char *name = BPF_CORE_READ(d, d_name.name);
+        long n = bpf_probe_read_kernel_str(&e->data[off], MAX_DENTRY_NAME, name);
+        if (n <= 0)
+            return off;
+        if (n > MAX_DENTRY_NAME)
+            n = MAX_DENTRY_NAME;
+        off += n;
+        d = parent;
+    }
+
+    e->flags |= FLAG_CWD_TRUNCATED;
+    return off;
+}
 
 // SEC Definition: Hooking into 'execve' system call entry
 SEC("tracepoint/syscalls/sys_enter_execve")
-int trace_execve(void *ctx) {
+int trace_execve(struct sys_enter_ctx *ctx) {
     u64 id = bpf_get_current_pid_tgid();
     u32 pid = id >> 32;
-    u32 uid = bpf_get_current_uid_gid();
 
     // Filter out PID 0 (Idle process)
     if (pid == 0) return 0;
 
     // Current task pointer uthao
     struct task_struct *task = (struct task_struct *)bpf_get_current_task();
-    u32 mnt_ns_id = 0;
-
-    // Namespace information safely read karo using CO-RE logic
-    BPF_CORE_READ_INTO(&mnt_ns_id, task, nsproxy, mnt_ns, ns.inum);
-
-    // Current command name capture karo
-    char comm[16];
-    bpf_get_current_comm(&comm, sizeof(comm));
-
-    // --- AGGRESSIVE NOISE FILTERING ---
-    // VS Code (code)
-    if (comm[0] == 'c' && comm[1] == 'o' && comm[2] == 'd' && comm[3] == 'e') return 0;
-    // Go Language Server (gopls)
-    if (comm[0] == 'g' && comm[1] == 'o' && comm[2] == 'p' && comm[3] == 'l') return 0;
-    // Apt checks
-    if (comm[0] == 'a' && comm[1] == 'p' && comm[2] == 't') return 0;
-    // Update notifier
-    if (comm[0] == 'u' && comm[1] == 'p' && comm[2] == 'd' && comm[3] == 'a') return 0;
-    // Monitoring scripts
-    if (comm[0] == 'c' && comm[1] == 'p' && comm[2] == 'u') return 0;
-    // System utilities
-    if (comm[0] == 'n' && comm[1] == 'i' && comm[2] == 'c' && comm[3] == 'e') return 0;
-    if (comm[0] == 'i' && comm[1] == 'o' && comm[2] == 'n' && comm[3] == 'i') return 0;
-
-    struct event *e;
-
-    // 1. Space reserve karo Ring Buffer mein
-    e = bpf_ringbuf_reserve(&rb, sizeof(*e), 0);
+    if (!in_scope(task))
+        return 0;
+
+    // 1. Scratch event lo (per-CPU, stack se bada hai)
+    u32 zero = 0;
+    struct exec_event *e = bpf_map_lookup_elem(&heap, &zero);
     if (!e) {
-        return 0; 
+        return 0;
     }
 
     // 2. Metadata fill karo
-    e->pid = pid;
-    e->uid = uid;
-    e->mnt_ns = mnt_ns_id;
+    fill_header(&e->h, EVENT_EXEC, task);
+    e->argc = 0;
+    e->flags = 0;
 
-    // Parent PID fetch karo safely
-    struct task_struct *parent = BPF_CORE_READ(task, real_parent);
-    e->ppid = BPF_CORE_READ(parent, tgid);
-    
-    // Copy command name securely
-    for (int i = 0; i < 16; i++) {
-        e->comm[i] = comm[i];
+    // 3. Variable part: filename, argv, cwd
+    long n = bpf_probe_read_user_str(&e->data[0], MAX_FILENAME_LEN, (const char *)ctx->args[0]);
+    u32 off = 0;
+    if (n > 0)
+        off = n > MAX_FILENAME_LEN ? MAX_FILENAME_LEN : n;
+    e->filename_len = off;
+
+    // --- NOISE FILTERING: comm prefixes from the ignore rules, matched against
+    // the comm the new image will get (the basename of the filename), not the caller's ---
+    struct comm_key ck = { .prefixlen = sizeof(ck.comm) * 8 };
+    __builtin_memset(ck.comm, 0, sizeof(ck.comm));
+    u32 base = 0;
+    for (u32 i = 0; i < MAX_FILENAME_LEN; i++) {
+        if (i >= off)
+            break;
+        if (e->data[i] == '/')
+            base = i + 1;
+    }
+    for (u32 i = 0; i < sizeof(ck.comm) - 1; i++) {
+        u32 j = base + i;
+        if (j >= off)
+            break;
+        char c = e->data[j & (MAX_FILENAME_LEN - 1)];
+        if (!c)
+            break;
+        ck.comm[i] = c;
     }
+    if (bpf_map_lookup_elem(&exec_ignore, &ck))
+        return 0;
+
+    u32 argv_start = off;
+    off = capture_argv(e, off, (const char *const *)ctx->args[1]);
+    e->argv_len = off - argv_start;
+
+    u32 cwd_start = off;
+    off = capture_cwd(e, off, task);
+    e->cwd_len = off - cwd_start;
+
+    // 4. Submit only the used bytes to User Space (Go Engine)
+    u64 size = sizeof(*e) - MAX_DATA + off;
+    if (size > sizeof(*e))
+        size = sizeof(*e);
+    if (bpf_ringbuf_output(&rb, e, size, 0) < 0)
+        count_drop(EVENT_EXEC);
+
+    return 0;
+}
+
+// emit_connect reports the destination of a connecting socket
+static __always_inline int emit_connect(struct sock *sk, u8 protocol) {
+    u16 family = BPF_CORE_READ(sk, __sk_common.skc_family);
+    if (family != AF_INET && family != AF_INET6)
+        return 0;
+    if (!in_scope((struct task_struct *)bpf_get_current_task()))
+        return 0;
+
+    struct connect_event *e = bpf_ringbuf_reserve(&rb, sizeof(*e), 0);
+    if (!e) {
+        count_drop(EVENT_CONNECT);
+        return 0;
+    }
+
+    fill_header(&e->h, EVENT_CONNECT, (struct task_struct *)bpf_get_current_task());
+    e->family = family;
+    e->protocol = protocol;
+    e->dport = bpf_ntohs(BPF_CORE_READ(sk, __sk_common.skc_dport));
+    __builtin_memset(e->_pad, 0, sizeof(e->_pad));
+    __builtin_memset(e->daddr, 0, sizeof(e->daddr));
+    if (family == AF_INET)
+        bpf_core_read(&e->daddr, 4, &sk->__sk_common.skc_daddr);
+    else
+        bpf_core_read(&e->daddr, 16, &sk->__sk_common.skc_v6_daddr);
 
-    // 3. Submit event to User Space (Go Engine)
     bpf_ringbuf_submit(e, 0);
+    return 0;
+}
+
+// check_open reports an open only if the (absolute) path falls under a sensitive prefix.
+// Relative paths are not resolved against dfd and are therefore not matched.
+static __always_inline int check_open(const char *filename, u32 flags) {
+    if (!in_scope((struct task_struct *)bpf_get_current_task()))
+        return 0;
+
+    struct path_key key;
+    __builtin_memset(&key, 0, sizeof(key));
+
+    long n = bpf_probe_read_user_str(key.path, sizeof(key.path), filename);
+    if (n <= 1 || key.path[0] != '/')
+        return 0;
+    if (n > MAX_PATH_LEN)
+        n = MAX_PATH_LEN;
+    key.prefixlen = (n - 1) * 8;
+
+    if (!bpf_map_lookup_elem(&sensitive_paths, &key))
+        return 0;
+
+    struct open_event *e = bpf_ringbuf_reserve(&rb, sizeof(*e), 0);
+    if (!e) {
+        count_drop(EVENT_OPEN);
+        return 0;
+    }
+
+    fill_header(&e->h, EVENT_OPEN, (struct task_struct *)bpf_get_current_task());
+    e->flags = flags;
+    e->path_len = n - 1;
+    e->_pad = 0;
+    __builtin_memcpy(e->path, key.path, sizeof(e->path));
+
+    bpf_ringbuf_submit(e, 0);
+    return 0;
+}
+
+SEC("tracepoint/syscalls/sys_enter_openat")
+int trace_openat(struct sys_enter_ctx *ctx) {
+    return check_open((const char *)ctx->args[1], (u32)ctx->args[2]);
+}
+
+SEC("tracepoint/syscalls/sys_enter_openat2")
+int trace_openat2(struct sys_enter_ctx *ctx) {
+    struct open_how how = {};
+    bpf_probe_read_user(&how, sizeof(how), (void *)ctx->args[2]);
+    return check_open((const char *)ctx->args[1], (u32)how.flags);
+}
+
+// TCP: tcp_connect() runs once the destination is set on the socket (v4 and v6)
+SEC("fentry/tcp_connect")
+int BPF_PROG(trace_tcp_connect, struct sock *sk) {
+    return emit_connect(sk, PROTO_TCP);
+}
+
+// UDP: only connected sockets are seen here; unconnected sendto() is not
+SEC("fexit/ip4_datagram_connect")
+int BPF_PROG(trace_udp4_connect, struct sock *sk, struct sockaddr *uaddr, int addr_len, int ret) {
+    if (ret != 0)
+        return 0;
+    return emit_connect(sk, PROTO_UDP);
+}
+
+SEC("fexit/ip6_datagram_connect")
+int BPF_PROG(trace_udp6_connect, struct sock *sk, struct sockaddr *uaddr, int addr_len, int ret) {
+    if (ret != 0)
+        return 0;
+    return emit_connect(sk, PROTO_UDP);
+}
+
+// commit_creds() is the single place new credentials take effect: setuid binaries
+// (via exec), setresuid/setresgid, capset, ... Only escalations are reported.
+SEC("fentry/commit_creds")
+int BPF_PROG(trace_commit_creds, struct cred *new) {
+    struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+    const struct cred *old = BPF_CORE_READ(task, cred);
+
+    u32 old_uid = BPF_CORE_READ(old, uid.val);
+    u32 old_euid = BPF_CORE_READ(old, euid.val);
+    u32 new_uid = BPF_CORE_READ(new, uid.val);
+    u32 new_euid = BPF_CORE_READ(new, euid.val);
+    u64 old_caps = 0, new_caps = 0;
+    bpf_core_read(&old_caps, sizeof(old_caps), &old->cap_effective);
+    bpf_core_read(&new_caps, sizeof(new_caps), &new->cap_effective);
+
+    int to_root = (old_uid != 0 && new_uid == 0) || (old_euid != 0 && new_euid == 0);
+    if (!to_root && !(new_caps & ~old_caps))
+        return 0; // drops and no-op changes are the common case
+    if (!in_scope(task))
+        return 0;
+
+    struct cred_event *e = bpf_ringbuf_reserve(&rb, sizeof(*e), 0);
+    if (!e) {
+        count_drop(EVENT_CRED);
+        return 0;
+    }
+
+    fill_header(&e->h, EVENT_CRED, task);
+    e->old_uid = old_uid;
+    e->new_uid = new_uid;
+    e->old_euid = old_euid;
+    e->new_euid = new_euid;
+    e->old_caps = old_caps;
+    e->new_caps = new_caps;
+
+    bpf_ringbuf_submit(e, 0);
+    return 0;
+}
 
+// emit_blocked reports an exec refused by check_exec
+static __always_inline void emit_blocked(struct exec_deny_key *key, const u8 *path) {
+    struct blocked_event *e = bpf_ringbuf_reserve(&rb, sizeof(*e), 0);
+    if (!e) {
+        count_drop(EVENT_BLOCKED);
+        return;
+    }
+
+    fill_header(&e->h, EVENT_BLOCKED, (struct task_struct *)bpf_get_current_task());
+    __builtin_memcpy(e->name, key->name, sizeof(e->name));
+    __builtin_memcpy(e->path, path, sizeof(e->path));
+    bpf_ringbuf_submit(e, 0);
+}
+
+// BPF LSM: refuse denied binaries before the new image runs. Both the name the
+// binary was invoked as and the name of the file actually executed (symlinks
+// resolved) are checked; for scripts the hook runs again for the interpreter.
+SEC("lsm/bprm_check_security")
+int BPF_PROG(check_exec, struct linux_binprm *bprm, int ret) {
+    if (ret != 0)
+        return ret;
+
+    struct exec_deny_key key;
+    __builtin_memset(&key, 0, sizeof(key));
+    key.cgroup_id = bpf_get_current_cgroup_id();
+
+    u8 path[MAX_PATH_LEN];
+    __builtin_memset(path, 0, sizeof(path));
+    long n = bpf_probe_read_kernel_str(path, sizeof(path), BPF_CORE_READ(bprm, filename));
+
+    // 1. Executed file (dentry name)
+    const unsigned char *name = BPF_CORE_READ(bprm, file, f_path.dentry, d_name.name);
+    bpf_probe_read_kernel_str(key.name, sizeof(key.name), name);
+    if (bpf_map_lookup_elem(&exec_deny, &key)) {
+        emit_blocked(&key, path);
+        return -EPERM;
+    }
+
+    // 2. Invoked name (basename of the filename passed to execve)
+    if (n <= 1)
+        return 0;
+    u32 start = 0;
+    for (int i = 0; i < MAX_PATH_LEN; i++) {
+        if (i >= n)
+            break;
+        if (path[i] == '/')
+            start = i + 1;
+    }
+    __builtin_memset(key.name, 0, sizeof(key.name));
+    for (int i = 0; i < MAX_DENTRY_NAME - 1; i++) {
+        u32 j = start + i;
+        if (j >= MAX_PATH_LEN)
+            break;
+        u8 c = path[j & (MAX_PATH_LEN - 1)];
+        if (!c)
+            break;
+        key.name[i] = c;
+    }
+    if (bpf_map_lookup_elem(&exec_deny, &key)) {
+        emit_blocked(&key, path);
+        return -EPERM;
+    }
+    return 0;
+}
+
+// --- PROCESS TABLE FEED ---
+// BTF tracepoints (tp_btf) give typed task pointers; userspace keeps the tree.
+
+SEC("tp_btf/sched_process_fork")
+int BPF_PROG(trace_sched_fork, struct task_struct *parent, struct task_struct *child) {
+    // New threads are not processes
+    if (BPF_CORE_READ(child, pid) != BPF_CORE_READ(child, tgid))
+        return 0;
+
+    struct fork_event *e = bpf_ringbuf_reserve(&rb, sizeof(*e), 0);
+    if (!e) {
+        count_drop(EVENT_FORK);
+        return 0;
+    }
+
+    fill_header(&e->h, EVENT_FORK, (struct task_struct *)bpf_get_current_task());
+    e->child_pid = BPF_CORE_READ(child, tgid);
+    e->_pad = 0;
+    e->child_start = BPF_CORE_READ(child, start_time);
+    bpf_ringbuf_submit(e, 0);
+    return 0;
+}
+
+// has_prefix compares against a string literal (n is a constant at every call site)
+static __always_inline int has_prefix(const u8 *s, const char *prefix, u32 n) {
+    for (u32 i = 0; i < n; i++) {
+        if (s[i] != prefix[i])
+            return 0;
+    }
+    return 1;
+}
+
+#define HAS_PREFIX(s, lit) has_prefix(s, lit, sizeof(lit) - 1)
+
+// emit_fileless reports an exec whose file cannot be inspected (or trusted) on disk
+// afterwards. It runs on every successful exec, so execveat()/fexecve() are covered
+// too; for scripts bprm->file is the interpreter and only the invoked path counts.
+static __always_inline void emit_fileless(struct task_struct *p, struct linux_binprm *bprm) {
+    struct file *file = BPF_CORE_READ(bprm, file);
+    struct inode *inode = BPF_CORE_READ(file, f_inode);
+    u32 flags = 0;
+
+    u8 name[MAX_DENTRY_NAME];
+    __builtin_memset(name, 0, sizeof(name));
+    bpf_probe_read_kernel_str(name, sizeof(name), BPF_CORE_READ(file, f_path.dentry, d_name.name));
+    if (HAS_PREFIX(name, "memfd:"))
+        flags |= FILELESS_MEMFD;
+    if (BPF_CORE_READ(inode, i_nlink) == 0)
+        flags |= FILELESS_UNLINKED;
+    if (BPF_CORE_READ(inode, i_sb, s_magic) == TMPFS_MAGIC)
+        flags |= FILELESS_TMPFS;
+
+    u8 path[MAX_PATH_LEN];
+    __builtin_memset(path, 0, sizeof(path));
+    bpf_probe_read_kernel_str(path, sizeof(path), BPF_CORE_READ(bprm, filename));
+    if (HAS_PREFIX(path, "/tmp/") || HAS_PREFIX(path, "/var/tmp/") || HAS_PREFIX(path, "/dev/shm/"))
+        flags |= FILELESS_TMPDIR;
+
+    if (!flags || !in_scope(p))
+        return;
+
+    struct fileless_event *e = bpf_ringbuf_reserve(&rb, sizeof(*e), 0);
+    if (!e) {
+        count_drop(EVENT_FILELESS);
+        return;
+    }
+    fill_header(&e->h, EVENT_FILELESS, p);
+    e->start_time = BPF_CORE_READ(p, start_time);
+    e->flags = flags;
+    e->_pad = 0;
+    __builtin_memcpy(e->filename, path, sizeof(e->filename));
+    __builtin_memcpy(e->name, name, sizeof(e->name));
+    bpf_ringbuf_submit(e, 0);
+}
+
+// Fires after a successful exec, unlike sys_enter_execve (and without its comm filter)
+SEC("tp_btf/sched_process_exec")
+int BPF_PROG(trace_sched_exec, struct task_struct *p, int old_pid, struct linux_binprm *bprm) {
+    struct sched_exec_event *e = bpf_ringbuf_reserve(&rb, sizeof(*e), 0);
+    if (!e) {
+        count_drop(EVENT_SCHED_EXEC);
+        return 0;
+    }
+
+    fill_header(&e->h, EVENT_SCHED_EXEC, p);
+    e->start_time = BPF_CORE_READ(p, start_time);
+    __builtin_memset(e->filename, 0, sizeof(e->filename));
+    bpf_probe_read_kernel_str(e->filename, sizeof(e->filename), BPF_CORE_READ(bprm, filename));
+    bpf_ringbuf_submit(e, 0);
+
+    emit_fileless(p, bprm);
+    return 0;
+}
+
+// Reported when the group leader exits (other threads may briefly outlive it)
+SEC("tp_btf/sched_process_exit")
+int BPF_PROG(trace_sched_exit, struct task_struct *p) {
+    if (BPF_CORE_READ(p, pid) != BPF_CORE_READ(p, tgid))
+        return 0;
+
+    struct exit_event *e = bpf_ringbuf_reserve(&rb, sizeof(*e), 0);
+    if (!e) {
+        count_drop(EVENT_EXIT);
+        return 0;
+    }
+
+    fill_header(&e->h, EVENT_EXIT, p);
+    e->start_time = BPF_CORE_READ(p, start_time);
+    bpf_ringbuf_submit(e, 0);
     return 0;
 }
 
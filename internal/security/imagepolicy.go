@@ -0,0 +1,200 @@
+package security
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+)
+
+// PolicyAction is the admission outcome attached to a single finding
+type PolicyAction string
+
+const (
+	ActionWarn  PolicyAction = "WARN"
+	ActionBlock PolicyAction = "BLOCK"
+	// ActionExcepted marks a finding waived by an active policy exception
+	ActionExcepted PolicyAction = "EXCEPTED"
+)
+
+// Finding is one policy observation produced during admission
+type Finding struct {
+	Rule        string       `json:"rule"`
+	Severity    string       `json:"severity"`
+	Detail      string       `json:"detail"`
+	Action      PolicyAction `json:"action"`
+	ExceptionID int64        `json:"exception_id,omitempty"`
+}
+
+// Post-pull image configuration rules
+const (
+	RuleRootUser           = "config.user.root"
+	RuleSecretEnv          = "config.env.secret"
+	RulePrivilegedPort     = "config.port.privileged"
+	RuleMissingHealthcheck = "config.healthcheck.missing"
+	RuleMissingLabels      = "config.labels.missing"
+)
+
+var severityRank = map[string]int{"LOW": 1, "MEDIUM": 2, "HIGH": 3, "CRITICAL": 4}
+
+// blockThreshold maps a security_level to the lowest severity that blocks.
+// audit is not listed: it only ever warns.
+var blockThreshold = map[string]int{
+	"high":   2, // MEDIUM and above
+	"medium": 3, // HIGH and above
+	"low":    4, // CRITICAL only
+}
+
+var (
+	secretKeyPattern   = regexp.MustCompile(`(?i)(PASSWORD|PASSWD|SECRET|TOKEN|API_?KEY|ACCESS_?KEY|PRIVATE_?KEY|CREDENTIAL)`)
+	secretValuePattern = regexp.MustCompile(`AKIA[0-9A-Z]{16}|-----BEGIN [A-Z ]*PRIVATE KEY-----|ghp_[A-Za-z0-9]{36}|xox[baprs]-[A-Za-z0-9-]{10,}`)
+)
+
+// securityLevels are the levels a spec may declare
+var securityLevels = map[string]bool{"high": true, "medium": true, "low": true, "audit": true}
+
+// ValidateLevel rejects a security_level that is not a known level, so a typo
+// never silently turns every finding into a warning
+func ValidateLevel(level string) error {
+	if l := strings.ToLower(strings.TrimSpace(level)); l != "" && !securityLevels[l] {
+		return fmt.Errorf("security_level '%s' is not one of high, medium, low, audit", level)
+	}
+	return nil
+}
+
+// NormalizeLevel maps the security_level of a spec onto a known level: empty is
+// medium, and anything unknown (e.g. stored before validation) the strictest, high
+func NormalizeLevel(level string) string {
+	level = strings.ToLower(strings.TrimSpace(level))
+	switch {
+	case level == "":
+		return "medium"
+	case !securityLevels[level]:
+		return "high"
+	}
+	return level
+}
+
+// ActionFor decides whether a finding of the given severity blocks at this level
+func ActionFor(severity, level string) PolicyAction {
+	threshold, ok := blockThreshold[NormalizeLevel(level)]
+	if ok && severityRank[severity] >= threshold {
+		return ActionBlock
+	}
+	return ActionWarn
+}
+
+// Blocked reports whether any finding carries a BLOCK action
+func Blocked(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Action == ActionBlock {
+			return true
+		}
+	}
+	return false
+}
+
+// Summarize renders the blocking findings as a single rejection reason
+func Summarize(findings []Finding) string {
+	var reasons []string
+	for _, f := range findings {
+		if f.Action == ActionBlock {
+			reasons = append(reasons, fmt.Sprintf("[%s] %s", f.Rule, f.Detail))
+		}
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// InspectImageConfig runs the post-pull checks against the image config.
+// It never touches a container; the caller decides what to do with BLOCK findings.
+func (g *Gatekeeper) InspectImageConfig(cfg *container.Config, level string) []Finding {
+	if cfg == nil {
+		return nil
+	}
+
+	var findings []Finding
+	add := func(rule, severity, detail string) {
+		findings = append(findings, Finding{Rule: rule, Severity: severity, Detail: detail, Action: ActionFor(severity, level)})
+	}
+
+	// 1. Default user: empty means the runtime falls back to root
+	user := strings.SplitN(cfg.User, ":", 2)[0]
+	if user == "" || user == "root" || user == "0" {
+		add(RuleRootUser, "MEDIUM", "Image runs as root by default (no USER directive).")
+	}
+
+	// 2. Secrets baked into ENV (never echo the value itself)
+	for _, kv := range cfg.Env {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			continue
+		}
+		if secretKeyPattern.MatchString(parts[0]) || secretValuePattern.MatchString(parts[1]) {
+			add(RuleSecretEnv, "HIGH", fmt.Sprintf("ENV '%s' looks like an embedded secret.", parts[0]))
+		}
+	}
+
+	// 3. Privileged ports (<1024) exposed by the image
+	for port := range cfg.ExposedPorts {
+		if port.Int() > 0 && port.Int() < 1024 {
+			add(RulePrivilegedPort, "LOW", fmt.Sprintf("Image exposes privileged port %s.", port))
+		}
+	}
+
+	// 4. Healthcheck
+	if cfg.Healthcheck == nil || len(cfg.Healthcheck.Test) == 0 || cfg.Healthcheck.Test[0] == "NONE" {
+		add(RuleMissingHealthcheck, "LOW", "Image defines no HEALTHCHECK.")
+	}
+
+	// 5. Labels: either the required set, or at least some provenance metadata
+	if len(g.RequiredLabels) == 0 {
+		if len(cfg.Labels) == 0 {
+			add(RuleMissingLabels, "LOW", "Image carries no labels.")
+		}
+	} else {
+		for _, label := range g.RequiredLabels {
+			if _, ok := cfg.Labels[label]; !ok {
+				add(RuleMissingLabels, "LOW", fmt.Sprintf("Required label '%s' is missing.", label))
+			}
+		}
+	}
+
+	return findings
+}
+
+// EvaluatePulledImage runs every post-pull check (config, plus the filesystem scan when enabled).
+// A scan failure is returned next to the config findings so the caller can decide to fail open.
+func (g *Gatekeeper) EvaluatePulledImage(img types.ImageInspect, level string, export ImageExporter) ([]Finding, error) {
+	findings := g.InspectImageConfig(img.Config, level)
+	if !g.ScanFilesystem {
+		return findings, nil
+	}
+
+	files, err := g.ImageInventory(img, export)
+	if err != nil {
+		return findings, err
+	}
+	return append(findings, g.EvaluateInventory(files, level)...), nil
+}
+
+// DryRun evaluates a spec without provisioning or pulling anything. Post-pull checks
+// only run when the caller found the image locally (img != nil); notes explain what was skipped.
+func (g *Gatekeeper) DryRun(name, imageName, level string, img *types.ImageInspect, export ImageExporter) ([]Finding, []string) {
+	findings := g.EvaluateSpec(name, imageName)
+	if imageName == "" {
+		return g.ApplyExceptions(name, findings), nil
+	}
+
+	if img == nil {
+		return g.ApplyExceptions(name, findings), []string{fmt.Sprintf("Post-pull checks skipped: image '%s' is not present locally.", imageName)}
+	}
+
+	pulled, err := g.EvaluatePulledImage(*img, level, export)
+	var notes []string
+	if err != nil {
+		notes = append(notes, fmt.Sprintf("Filesystem scan failed: %v", err))
+	}
+	return g.ApplyExceptions(name, append(findings, pulled...)), notes
+}
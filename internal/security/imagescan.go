@@ -0,0 +1,343 @@
+package security
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/Debasish-87/aegis-v/internal/platform"
+	"github.com/docker/docker/api/types"
+)
+
+// Image filesystem rules
+const (
+	RuleSetuidBinary     = "fs.setuid"
+	RuleShell            = "fs.shell"
+	RuleNetTool          = "fs.nettool"
+	RulePrivateKey       = "fs.private-key"
+	RuleCloudCredentials = "fs.cloud-credentials"
+)
+
+// Inventory categories stored per file
+const (
+	CategoryExecutable      = "executable"
+	CategorySetuid          = "setuid"
+	CategorySetgid          = "setgid"
+	CategoryShell           = "shell"
+	CategoryNetTool         = "nettool"
+	CategoryPrivateKey      = "private-key"
+	CategoryCloudCredential = "cloud-credential"
+)
+
+// maxContentScan bounds how much of a file is read to look for embedded keys
+const maxContentScan = 64 * 1024
+
+var (
+	shellNames   = map[string]bool{"sh": true, "bash": true, "dash": true, "ash": true, "zsh": true, "ksh": true, "csh": true, "tcsh": true, "fish": true}
+	netToolNames = map[string]bool{"nc": true, "ncat": true, "netcat": true, "curl": true, "wget": true, "socat": true}
+
+	cloudCredentialPaths = []string{
+		"/.aws/credentials",
+		"/.config/gcloud/credentials.db",
+		"/.config/gcloud/application_default_credentials.json",
+		"/.azure/accessTokens.json",
+		"/.docker/config.json",
+		"/.kube/config",
+	}
+)
+
+// scannedFile is an entry of the merged (all layers applied) filesystem
+type scannedFile struct {
+	layer int
+	platform.ImageFile
+}
+
+// ImageExporter streams a local image as a `docker save` archive; the engine backs it
+// with the orchestrator so policy evaluation never drives Docker itself
+type ImageExporter func(imageName string) (io.ReadCloser, error)
+
+// ScanImageFilesystem reads an exported image archive and returns its inventory.
+// Executables and every flagged file are kept; plain data files are not.
+func ScanImageFilesystem(archive io.Reader) ([]platform.ImageFile, error) {
+	// `docker save` puts manifest.json last, so spool the archive for random access
+	tmp, err := os.CreateTemp("", "aegis-image-*.tar")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, archive); err != nil {
+		return nil, fmt.Errorf("spool failed: %v", err)
+	}
+	return scanImageArchive(tmp)
+}
+
+// scanImageArchive indexes the save archive, then applies the layers in manifest order
+func scanImageArchive(archive io.ReaderAt) ([]platform.ImageFile, error) {
+	type span struct{ off, size int64 }
+	entries := map[string]span{}
+
+	// 1. Index every archive member by offset
+	cr := &countingReader{r: io.NewSectionReader(archive, 0, 1<<62)}
+	tr := tar.NewReader(cr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("corrupt image archive: %v", err)
+		}
+		entries[path.Clean(hdr.Name)] = span{off: cr.n, size: hdr.Size}
+	}
+
+	manifestSpan, ok := entries["manifest.json"]
+	if !ok {
+		return nil, fmt.Errorf("image archive has no manifest.json")
+	}
+	var manifest []struct {
+		Layers []string `json:"Layers"`
+	}
+	if err := json.NewDecoder(io.NewSectionReader(archive, manifestSpan.off, manifestSpan.size)).Decode(&manifest); err != nil || len(manifest) == 0 {
+		return nil, fmt.Errorf("unreadable manifest.json: %v", err)
+	}
+
+	// 2. Apply layers bottom-up, honouring whiteouts
+	merged := map[string]*scannedFile{}
+	for idx, layer := range manifest[0].Layers {
+		s, ok := entries[path.Clean(layer)]
+		if !ok {
+			return nil, fmt.Errorf("layer %s missing from archive", layer)
+		}
+		if err := applyLayer(merged, idx, io.NewSectionReader(archive, s.off, s.size)); err != nil {
+			return nil, fmt.Errorf("layer %s: %v", layer, err)
+		}
+	}
+
+	files := make([]platform.ImageFile, 0, len(merged))
+	for _, f := range merged {
+		files = append(files, f.ImageFile)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+func applyLayer(merged map[string]*scannedFile, idx int, layer io.Reader) error {
+	br := bufio.NewReader(layer)
+	var r io.Reader = br
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := "/" + strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		dir, base := path.Split(name)
+
+		// Whiteouts delete what lower layers provided
+		if base == ".wh..wh..opq" {
+			removeLower(merged, strings.TrimSuffix(dir, "/"), idx, false)
+			continue
+		}
+		if strings.HasPrefix(base, ".wh.") {
+			removeLower(merged, dir+strings.TrimPrefix(base, ".wh."), idx, true)
+			continue
+		}
+
+		delete(merged, name)
+		if f := classifyEntry(name, hdr, tr); f != nil {
+			merged[name] = &scannedFile{layer: idx, ImageFile: *f}
+		}
+	}
+}
+
+// removeLower drops target (optionally) and everything beneath it from earlier layers
+func removeLower(merged map[string]*scannedFile, target string, idx int, includeSelf bool) {
+	prefix := strings.TrimSuffix(target, "/") + "/"
+	for p, f := range merged {
+		if f.layer >= idx {
+			continue
+		}
+		if (includeSelf && p == target) || strings.HasPrefix(p, prefix) {
+			delete(merged, p)
+		}
+	}
+}
+
+// classifyEntry returns the inventory record for interesting files, or nil
+func classifyEntry(name string, hdr *tar.Header, content io.Reader) *platform.ImageFile {
+	base := path.Base(name)
+	inBinDir := strings.HasSuffix(path.Dir(name), "bin")
+	mode := hdr.Mode
+	isExec := hdr.Typeflag == tar.TypeReg && mode&0111 != 0
+	isLink := hdr.Typeflag == tar.TypeSymlink || hdr.Typeflag == tar.TypeLink
+
+	f := &platform.ImageFile{Path: name, Mode: mode}
+
+	switch {
+	case hdr.Typeflag == tar.TypeReg && mode&04000 != 0:
+		f.Category = CategorySetuid
+	case hdr.Typeflag == tar.TypeReg && mode&02000 != 0:
+		f.Category = CategorySetgid
+	case (isExec || isLink) && inBinDir && shellNames[base]:
+		f.Category = CategoryShell
+	case (isExec || isLink) && inBinDir && netToolNames[base]:
+		f.Category = CategoryNetTool
+	case isExec:
+		f.Category = CategoryExecutable
+	}
+
+	for _, p := range cloudCredentialPaths {
+		if strings.HasSuffix(name, p) {
+			f.Category = CategoryCloudCredential
+		}
+	}
+
+	if hdr.Typeflag != tar.TypeReg {
+		if f.Category == "" {
+			return nil
+		}
+		return f
+	}
+
+	// Hash executables; small files are also read for embedded keys and credentials
+	h := sha256.New()
+	if hdr.Size <= maxContentScan {
+		data, err := io.ReadAll(content)
+		if err != nil {
+			return nil
+		}
+		h.Write(data)
+		// Binaries legitimately embed these markers as parser constants
+		if !isExec {
+			switch {
+			case bytes.Contains(data, []byte("-----BEGIN")) && bytes.Contains(data, []byte("PRIVATE KEY-----")):
+				f.Category = CategoryPrivateKey
+			case secretValuePattern.Match(data) && f.Category == "":
+				f.Category = CategoryCloudCredential
+			}
+		}
+	} else if f.Category != "" {
+		if _, err := io.Copy(h, content); err != nil {
+			return nil
+		}
+	}
+
+	if f.Category == "" {
+		return nil
+	}
+	f.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return f
+}
+
+// EvaluateInventory turns the inventory into one finding per rule that fired
+func (g *Gatekeeper) EvaluateInventory(files []platform.ImageFile, level string) []Finding {
+	rules := []struct {
+		rule, severity, label string
+		categories            []string
+	}{
+		{RuleSetuidBinary, "MEDIUM", "setuid/setgid binaries", []string{CategorySetuid, CategorySetgid}},
+		{RuleShell, "MEDIUM", "shells", []string{CategoryShell}},
+		{RuleNetTool, "MEDIUM", "network tools", []string{CategoryNetTool}},
+		{RulePrivateKey, "HIGH", "private keys", []string{CategoryPrivateKey}},
+		{RuleCloudCredentials, "HIGH", "cloud credentials", []string{CategoryCloudCredential}},
+	}
+
+	var findings []Finding
+	for _, r := range rules {
+		var hits []string
+		for _, f := range files {
+			for _, c := range r.categories {
+				if f.Category == c {
+					hits = append(hits, f.Path)
+				}
+			}
+		}
+		if len(hits) == 0 {
+			continue
+		}
+		detail := fmt.Sprintf("Image contains %d %s: %s", len(hits), r.label, strings.Join(firstN(hits, 5), ", "))
+		if len(hits) > 5 {
+			detail += ", ..."
+		}
+		findings = append(findings, Finding{Rule: r.rule, Severity: r.severity, Detail: detail, Action: ActionFor(r.severity, level)})
+	}
+	return findings
+}
+
+// ImageInventory returns the stored inventory for the image, exporting and scanning it on first sight
+func (g *Gatekeeper) ImageInventory(img types.ImageInspect, export ImageExporter) ([]platform.ImageFile, error) {
+	if files, ok, err := platform.LoadImageInventory(img.ID); err == nil && ok {
+		return files, nil
+	}
+
+	fmt.Printf("[GATEKEEPER] 🔬 Static analysis of %s filesystem...\n", img.ID)
+	rc, err := export(img.ID)
+	if err != nil {
+		return nil, err
+	}
+	files, err := ScanImageFilesystem(rc)
+	rc.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	image := img.ID
+	if len(img.RepoTags) > 0 {
+		image = img.RepoTags[0]
+	}
+	if err := platform.SaveImageInventory(img.ID, image, files); err != nil {
+		fmt.Printf("[GATEKEEPER] Could not persist inventory for %s: %v\n", img.ID, err)
+	}
+	return files, nil
+}
+
+// ShippedWithImage reports whether an executed path is part of the image inventory.
+// known=false means the image was never scanned.
+func ShippedWithImage(digest, exePath string) (shipped bool, sha string, known bool) {
+	f, found, scanned := platform.LookupImageFile(digest, exePath)
+	return found, f.SHA256, scanned
+}
+
+func firstN(items []string, n int) []string {
+	if len(items) > n {
+		return items[:n]
+	}
+	return items
+}
+
+// countingReader tracks the offset of the underlying stream for archive indexing
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
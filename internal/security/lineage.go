@@ -0,0 +1,97 @@
+package security
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/Debasish-87/aegis-v/internal/orchestrator"
+	"github.com/Debasish-87/aegis-v/internal/platform"
+	"github.com/Debasish-87/aegis-v/internal/proctree"
+	"github.com/Debasish-87/aegis-v/internal/rules"
+)
+
+// taskCommLen mirrors TASK_COMM_LEN: comm is the executable's name cut to 15 bytes
+const taskCommLen = 16
+
+// defaultNoSpawnClasses is the default spawn profile: main processes of these
+// classes (see the rules' classes) never start other programs. Every other main
+// process may, until the service declares a spawn list.
+var defaultNoSpawnClasses = []string{"web_server", "database"}
+
+// commOfPath is the comm a process gets from exec'ing file: its truncated base name
+func commOfPath(file string) string {
+	comm := path.Base(file)
+	if len(comm) >= taskCommLen {
+		comm = comm[:taskCommLen-1]
+	}
+	return comm
+}
+
+// ValidateSpawn rejects spawn entries no process name can match
+func ValidateSpawn(names []string) error {
+	for i, n := range names {
+		switch {
+		case n == "":
+			return fmt.Errorf("spawn[%d]: empty process name", i)
+		case strings.Contains(n, "/"):
+			return fmt.Errorf("spawn[%d]: '%s' must be a process name, not a path", i, n)
+		case len(n) >= taskCommLen:
+			return fmt.Errorf("spawn[%d]: '%s' is longer than %d bytes (process names are truncated)", i, n, taskCommLen-1)
+		}
+	}
+	return nil
+}
+
+// markRoles sets the role of every ancestor inside the container. Only the chain
+// below the container's init process counts: `docker exec` sessions and
+// anything outside the container have no role.
+//   - main: the init process and its workers (same executable), e.g. nginx
+//     master and workers, postgres and its backends
+//   - entrypoint: the program the image is configured to start, e.g. a
+//     docker-entrypoint.sh that has not exec'd the server yet
+func markRoles(info orchestrator.ContainerInfo, chain []proctree.Process) {
+	if info.InitPid == 0 {
+		return
+	}
+	root := slices.IndexFunc(chain, func(p proctree.Process) bool { return p.Pid == info.InitPid })
+	if root < 0 {
+		return
+	}
+	mainExe := chain[root].Exe
+	entry := commOfPath(info.Entrypoint)
+	for i := range chain[:root+1] {
+		p := &chain[i]
+		p.Roles = nil
+		if i == root || (mainExe != "" && p.Exe == mainExe) {
+			p.Roles = append(p.Roles, rules.RoleMain)
+		}
+		if info.Entrypoint != "" && p.Comm == entry {
+			p.Roles = append(p.Roles, rules.RoleEntrypoint)
+		}
+	}
+}
+
+// spawnVerdict applies the service's spawn profile to an exec: "" unless the
+// parent is one of the service's main processes, else allowed or denied. A main
+// process re-executing itself (binary upgrade) is not a spawn.
+func spawnVerdict(policy platform.RuntimePolicy, exe string, chain []proctree.Process) string {
+	if len(chain) == 0 || !slices.Contains(chain[0].Roles, rules.RoleMain) {
+		return ""
+	}
+	parent := chain[0]
+	if exe != "" && exe == parent.Exe {
+		return ""
+	}
+	allowed := !slices.ContainsFunc(rules.Current().ClassesOf(parent.Comm), func(c string) bool {
+		return slices.Contains(defaultNoSpawnClasses, c)
+	})
+	if policy.SpawnDefined {
+		allowed = slices.Contains(policy.Spawn, parent.Comm)
+	}
+	if allowed {
+		return rules.SpawnAllowed
+	}
+	return rules.SpawnDenied
+}
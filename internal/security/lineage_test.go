@@ -0,0 +1,81 @@
+package security
+
+import (
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/orchestrator"
+	"github.com/Debasish-87/aegis-v/internal/proctree"
+	"github.com/Debasish-87/aegis-v/internal/rules"
+)
+
+// fakeResponder records kills instead of sending signals
+type fakeResponder struct {
+	killed []int
+	names  []string
+}
+
+func (f *fakeResponder) Kill(pid int, comm string) error {
+	f.killed = append(f.killed, pid)
+	f.names = append(f.names, comm)
+	return nil
+}
+
+func (f *fakeResponder) Preserve(s *sample) (string, error) { return "", nil }
+
+func comm16(s string) [16]byte {
+	var c [16]byte
+	copy(c[:], s)
+	return c
+}
+
+// A worker forked by nginx execs a program. The exec event is read at
+// sys_enter_execve: its comm and the child's table entry are still nginx's.
+func TestSpawnAfterFork(t *testing.T) {
+	proctree.SetKernelFed(true)
+	t.Cleanup(func() { proctree.SetKernelFed(false) })
+
+	tests := []struct {
+		name      string
+		initPid   uint32
+		filename  string
+		classes   []string
+		spawn     string
+		wantKills bool
+	}{
+		{"web shell", 4100000, "/bin/sh", []string{"shell"}, rules.SpawnDenied, true},
+		{"tool by relative path", 4100010, "curl", []string{"tool"}, rules.SpawnDenied, true},
+		{"binary upgrade", 4100020, "/usr/sbin/nginx", []string{"web_server"}, "", false},
+	}
+	for _, tt := range tests {
+		worker := tt.initPid + 1
+		now := time.Now()
+		trackProcess(ProcessEvent{Kind: eventSchExec, Pid: tt.initPid, Ppid: 1, Comm: comm16("nginx"), StartTime: 1000, Filename: "/usr/sbin/nginx"}, now)
+		trackProcess(ProcessEvent{Kind: eventFork, Pid: tt.initPid, ChildPid: worker, Comm: comm16("nginx"), StartTime: 2000}, now)
+		ev := Event{Pid: worker, Ppid: tt.initPid, Uid: 33, Comm: comm16("nginx"), Filename: tt.filename}
+
+		// What enrich finds, minus the cgroup cache lookup
+		ctx := &eventContext{InCache: true, Container: orchestrator.ContainerInfo{Name: "web-1", Service: "web", Managed: true, InitPid: tt.initPid}}
+		p, ok := proctree.Lookup(worker)
+		if !ok {
+			t.Fatalf("%s: forked worker not in the process table", tt.name)
+		}
+		ctx.Process, ctx.Ancestors = &p, proctree.Ancestors(worker)
+		markRoles(ctx.Container, ctx.Ancestors)
+
+		in := ruleInput(eventNames[eventExec], commOfPath(ev.Filename), ev.Uid, ctx)
+		execImageInput(in, ev.Filename, ctx)
+		if classes := rules.Current().ClassesOf(in.Comm); !slices.Equal(classes, tt.classes) || in.Spawn != tt.spawn {
+			t.Errorf("%s: classes %v, spawn %q; want %v, %q", tt.name, classes, in.Spawn, tt.classes, tt.spawn)
+		}
+
+		resp := &fakeResponder{}
+		pl := &pipeline{selfPid: uint32(os.Getpid()), respond: resp}
+		pl.handle(ev, ctx, now)
+		if killed := slices.Contains(resp.killed, int(worker)); killed != tt.wantKills {
+			t.Errorf("%s: killed = %v, want %v", tt.name, killed, tt.wantKills)
+		}
+	}
+}
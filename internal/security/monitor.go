@@ -1,17 +1,23 @@
 package security
 
 import (
-	"bytes"
-	"encoding/binary"
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"os"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/Debasish-87/aegis-v/internal/guardian"
-	"github.com/Debasish-87/aegis-v/internal/orchestrator"
+	"github.com/Debasish-87/aegis-v/internal/proctree"
+	"github.com/Debasish-87/aegis-v/internal/rules"
+	"github.com/Debasish-87/aegis-v/internal/telemetry"
+	"github.com/Debasish-87/aegis-v/internal/trust"
 
+	"github.com/cilium/ebpf"
 	"github.com/cilium/ebpf/link"
 	"github.com/cilium/ebpf/ringbuf"
 	"github.com/cilium/ebpf/rlimit"
@@ -19,254 +25,817 @@ import (
 
 //go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target bpfel -cc clang bpf guardian.c
 
-type Event struct {
-	Pid   uint32
-	Ppid  uint32
-	Uid   uint32
-	MntNs uint32
-	Comm  [16]byte
+// probe is one attached hook, named after its section (e.g. fentry/tcp_connect)
+type probe struct {
+	name string
+	link link.Link
 }
 
-func StartSecurityMonitor() {
-	selfPid := uint32(os.Getpid())
+// probeObjects is the mandatory part of the probe: maps plus the syscall tracepoints
+type probeObjects struct {
+	bpfMaps
+	TraceExecve  *ebpf.Program `ebpf:"trace_execve"`
+	TraceOpenat  *ebpf.Program `ebpf:"trace_openat"`
+	TraceOpenat2 *ebpf.Program `ebpf:"trace_openat2"`
+}
 
-	if err := rlimit.RemoveMemlock(); err != nil {
-		log.Fatalf("[CRITICAL] Failed to remove memlock limit: %v", err)
+func (o *probeObjects) Close() error {
+	return _BpfClose(o.TraceExecve, o.TraceOpenat, o.TraceOpenat2, &o.bpfMaps)
+}
+
+// attachOpenProbes hooks openat/openat2 once the sensitive prefixes are in the kernel.
+// openat2 only exists on 5.6+, so a missing tracepoint is not fatal.
+func attachOpenProbes(objs *probeObjects) []probe {
+	var probes []probe
+	for _, tp := range []struct {
+		name string
+		prog *ebpf.Program
+	}{
+		{"sys_enter_openat", objs.TraceOpenat},
+		{"sys_enter_openat2", objs.TraceOpenat2},
+	} {
+		l, err := link.Tracepoint("syscalls", tp.name, tp.prog, nil)
+		if err != nil {
+			log.Printf("[WARN] Failed to attach %s: %v", tp.name, err)
+			continue
+		}
+		probes = append(probes, probe{"tracepoint/syscalls/" + tp.name, l})
 	}
+	return probes
+}
 
-	objs := bpfObjects{}
-	if err := loadBpfObjects(&objs, nil); err != nil {
-		log.Printf("[ERROR] Failed to load eBPF objects: %v", err)
-		return
+// sharedMaps are the maps every optional probe group must reuse from the core load,
+// so all events land in one ring buffer, all drops in one counter and one scope applies
+func sharedMaps(maps *bpfMaps) map[string]*ebpf.Map {
+	return map[string]*ebpf.Map{"rb": maps.Rb, "drops": maps.Drops, "scope": maps.Scope, "scope_cfg": maps.ScopeCfg}
+}
+
+// tracingObjects are the egress and credential probes. They need fentry/fexit
+// (BTF trampolines), so they are loaded separately and the monitor keeps running without them.
+type tracingObjects struct {
+	TraceTcpConnect  *ebpf.Program `ebpf:"trace_tcp_connect"`
+	TraceUdp4Connect *ebpf.Program `ebpf:"trace_udp4_connect"`
+	TraceUdp6Connect *ebpf.Program `ebpf:"trace_udp6_connect"`
+	TraceCommitCreds *ebpf.Program `ebpf:"trace_commit_creds"`
+}
+
+func (o *tracingObjects) Close() error {
+	return _BpfClose(o.TraceTcpConnect, o.TraceUdp4Connect, o.TraceUdp6Connect, o.TraceCommitCreds)
+}
+
+// attachTracingProbes loads the fentry/fexit probes against the shared ring buffer.
+// Each hook attaches on its own, so a missing kernel symbol only disables that probe.
+func attachTracingProbes(spec *ebpf.CollectionSpec, maps *bpfMaps) (*tracingObjects, []probe, error) {
+	objs := &tracingObjects{}
+	opts := &ebpf.CollectionOptions{MapReplacements: sharedMaps(maps)}
+	if err := spec.LoadAndAssign(objs, opts); err != nil {
+		return nil, nil, err
 	}
 
-	tp, err := link.Tracepoint("syscalls", "sys_enter_execve", objs.TraceExecve, nil)
-	if err != nil {
-		log.Printf("[ERROR] Failed to attach tracepoint: %v", err)
+	hooks := []struct {
+		name string
+		prog *ebpf.Program
+	}{
+		{"fentry/tcp_connect", objs.TraceTcpConnect},
+		{"fexit/ip4_datagram_connect", objs.TraceUdp4Connect},
+		{"fexit/ip6_datagram_connect", objs.TraceUdp6Connect},
+		{"fentry/commit_creds", objs.TraceCommitCreds},
+	}
+	var probes []probe
+	for _, h := range hooks {
+		l, err := link.AttachTracing(link.TracingOptions{Program: h.prog})
+		if err != nil {
+			log.Printf("[WARN] Failed to attach %s: %v", h.name, err)
+			continue
+		}
+		probes = append(probes, probe{h.name, l})
+	}
+	if len(probes) == 0 {
 		objs.Close()
-		return
+		return nil, nil, fmt.Errorf("no fentry/fexit probe could be attached")
 	}
+	return objs, probes, nil
+}
 
-	log.Println("[GUARDIAN] 🛡️ Advanced eBPF Security Probe Active. Monitoring Namespaces...")
+// procTreeObjects feed the process table (fork / exec / exit). All three are needed
+// for a consistent tree; without them lineage falls back to /proc.
+type procTreeObjects struct {
+	TraceSchedFork *ebpf.Program `ebpf:"trace_sched_fork"`
+	TraceSchedExec *ebpf.Program `ebpf:"trace_sched_exec"`
+	TraceSchedExit *ebpf.Program `ebpf:"trace_sched_exit"`
+}
 
-	rd, err := ringbuf.NewReader(objs.Rb)
-	if err != nil {
-		log.Printf("[ERROR] Failed to open ringbuf reader: %v", err)
-		tp.Close()
-		objs.Close()
-		return
+func (o *procTreeObjects) Close() error {
+	return _BpfClose(o.TraceSchedFork, o.TraceSchedExec, o.TraceSchedExit)
+}
+
+// attachProcTreeProbes loads the tp_btf sched probes against the shared ring buffer
+func attachProcTreeProbes(spec *ebpf.CollectionSpec, maps *bpfMaps) (*procTreeObjects, []probe, error) {
+	objs := &procTreeObjects{}
+	opts := &ebpf.CollectionOptions{MapReplacements: sharedMaps(maps)}
+	if err := spec.LoadAndAssign(objs, opts); err != nil {
+		return nil, nil, err
 	}
 
-	go func() {
-		defer tp.Close()
-		defer objs.Close()
-		defer rd.Close()
-
-		for {
-			record, err := rd.Read()
-			if err != nil {
-				if errors.Is(err, ringbuf.ErrClosed) {
-					return
-				}
-				continue
+	hooks := []struct {
+		name string
+		prog *ebpf.Program
+	}{
+		{"tp_btf/sched_process_fork", objs.TraceSchedFork},
+		{"tp_btf/sched_process_exec", objs.TraceSchedExec},
+		{"tp_btf/sched_process_exit", objs.TraceSchedExit},
+	}
+	var probes []probe
+	for _, h := range hooks {
+		l, err := link.AttachTracing(link.TracingOptions{Program: h.prog})
+		if err != nil {
+			for _, p := range probes {
+				p.link.Close()
 			}
+			objs.Close()
+			return nil, nil, err
+		}
+		probes = append(probes, probe{h.name, l})
+	}
+	return objs, probes, nil
+}
 
-			var event Event
-			if err := binary.Read(bytes.NewBuffer(record.RawSample), binary.LittleEndian, &event); err != nil {
-				continue
-			}
+// lsmObjects is the pre-exec blocker; it needs a kernel booted with BPF LSM enabled
+type lsmObjects struct {
+	CheckExec *ebpf.Program `ebpf:"check_exec"`
+}
 
-			// --- FILTER 1: Self & Engine Direct Child Check ---
-			if event.Pid == selfPid || event.Ppid == selfPid {
-				continue
-			}
+func (o *lsmObjects) Close() error {
+	return _BpfClose(o.CheckExec)
+}
 
-			comm := string(bytes.TrimRight(event.Comm[:], "\x00"))
+// attachExecBlocker loads check_exec against the shared ring buffer and deny map.
+// Without it, deny_exec is enforced by killing the process after the exec.
+func attachExecBlocker(spec *ebpf.CollectionSpec, maps *bpfMaps) (*lsmObjects, probe, error) {
+	if !bpfLSMEnabled() {
+		return nil, probe{}, fmt.Errorf("BPF LSM not enabled (add 'bpf' to the lsm= boot parameter)")
+	}
+	objs := &lsmObjects{}
+	replace := sharedMaps(maps)
+	replace["exec_deny"] = maps.ExecDeny
+	opts := &ebpf.CollectionOptions{MapReplacements: replace}
+	if err := spec.LoadAndAssign(objs, opts); err != nil {
+		return nil, probe{}, err
+	}
+	l, err := link.AttachLSM(link.LSMOptions{Program: objs.CheckExec})
+	if err != nil {
+		objs.Close()
+		return nil, probe{}, err
+	}
+	return objs, probe{"lsm/bprm_check_security", l}, nil
+}
 
-			// --- FILTER 2: DEEP LINEAGE & WHITELIST ---
-			// Added isTrustedPath for binary-level verification
-			if isWhitelisted(comm) || isAncestorWhitelisted(event.Ppid) || isTrustedPath(event.Pid, event.Ppid) {
-				continue
-			}
+// Monitor owns the eBPF probes and the event pipeline from Start to Stop
+type Monitor struct {
+	mu       sync.Mutex
+	cfg      MonitorConfig // active configuration
+	running  bool
+	started  time.Time
+	reloaded time.Time
+
+	objs      *probeObjects
+	netObjs   *tracingObjects
+	procObjs  *procTreeObjects
+	lsmObjs   *lsmObjects
+	probes    []probe
+	rd        *ringbuf.Reader
+	conn      *procConnector // fallback event source, instead of the probes
+	rec       *recorder
+	backend   string
+	degraded  string // what the backend cannot see ("" for the full probes)
+	scopeNote string // why the scope in use is not the one configured
+
+	forensicsDir atomic.Value  // string; read by the pipeline, swapped by Reload
+	done         chan struct{} // closed by Stop: ends the background loops
+	rulesStop    chan struct{} // ends the rules watcher (restarted when RulesDir changes)
+	scopeStop    chan struct{} // ends syncScope; nil with scope all
+	readerDone   chan struct{} // closed when the read loop returned
+	workers      sync.WaitGroup
+}
 
-			// --- FILTER 3: Noise signals ---
-			if isNoise(comm) {
-				continue
-			}
+// NewMonitor prepares a monitor; nothing is loaded into the kernel before Start
+func NewMonitor(cfg MonitorConfig) *Monitor {
+	m := &Monitor{cfg: cfg}
+	m.forensicsDir.Store(cfg.ForensicsDir)
+	return m
+}
 
-			containerName := orchestrator.GetContainerNameByNamespace(event.MntNs)
-			if isContainerStartup(comm) {
-				continue
-			}
+// MonitorStatus is what the running monitor hooks and with which configuration
+type MonitorStatus struct {
+	Running        bool            `json:"running"`
+	Backend        string          `json:"backend,omitempty"`  // ebpf or proc_connector
+	Degraded       string          `json:"degraded,omitempty"` // why and how coverage is reduced
+	Started        *time.Time      `json:"started,omitempty"`
+	Reloaded       *time.Time      `json:"reloaded,omitempty"`
+	Probes         []string        `json:"probes"` // attached hooks, e.g. fentry/tcp_connect
+	Features       MonitorFeatures `json:"features"`
+	Scope          string          `json:"scope"`
+	RingBufferSize int             `json:"ringbuf_size_bytes"`
+	RulesDir       string          `json:"rules_dir"`
+	RulesVersion   string          `json:"rules_version"`
+	Rules          int             `json:"rules"`
+	TrustFile      string          `json:"trust_file"`
+	Trusted        int             `json:"trusted_binaries"`
+	SensitivePaths []string        `json:"sensitive_paths"`
+	ForensicsDir   string          `json:"forensics_dir"`
+	RecordFile     string          `json:"record_file,omitempty"`
+}
 
-			// --- FILTER 4: Smart Shell Detection ---
-			if (comm == "sh" || comm == "bash" || comm == "dash") {
-				if !isInteractiveShellAttempt(event.Pid) {
-					continue
-				}
-			}
+// MonitorFeatures are the optional kernel features the probes ended up using
+type MonitorFeatures struct {
+	FileAccess   bool `json:"file_access"` // openat tracepoints
+	Tracing      bool `json:"fentry"`      // egress and privilege escalation probes
+	ProcessTree  bool `json:"tp_btf"`      // kernel-fed process table
+	ExecBlocking bool `json:"bpf_lsm"`     // deny_exec enforced before the exec
+}
 
-			// CLEAN SOURCE TAGGING FOR DATABASE
-			sourceTag := "HOST / SYSTEM"
-			if containerName != "" {
-				sourceTag = containerName 
-			} else if event.MntNs != 4026531840 && event.MntNs != 0 {
-				sourceTag = fmt.Sprintf("NS:%d", event.MntNs)
-			}
+// Start loads and attaches the probes and runs the pipeline until Stop, or until
+// ctx is cancelled. Optional probes that the kernel lacks are skipped with a warning.
+// When the eBPF probes cannot load at all (backend auto), exec monitoring falls back
+// to the netlink proc connector and the monitor reports itself degraded. An error
+// means nothing is left attached.
+func (m *Monitor) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.running {
+		return fmt.Errorf("security monitor already running")
+	}
+	selfPid := uint32(os.Getpid())
+	cfg := m.cfg
 
-			userTag := "USER"
-			riskLevel := "MEDIUM"
-			alertEmoji := "🚨"
+	if err := rules.Init(cfg.RulesDir); err != nil {
+		log.Printf("[WARN] Rules in %s not loaded, using the built-in rules: %v", cfg.RulesDir, err)
+	}
+	set := rules.Current()
+	log.Printf("[GUARDIAN] 📜 %d detection rules loaded from %d file(s) (%s)", len(set.Rules), len(set.Files), set.Version)
 
-			if event.Uid == 0 {
-				userTag = "ROOT ⚠️"
-				riskLevel = "HIGH / CRITICAL"
-			}
+	if err := trust.Init(cfg.TrustFile); err != nil {
+		log.Printf("[WARN] Trust file %s not loaded, using the built-in list: %v", cfg.TrustFile, err)
+	}
+	allow := trust.Current()
+	log.Printf("[GUARDIAN] 🔏 %d trusted binaries pinned by hash (%d not installed)", len(allow.Entries), allow.Skipped)
 
-			// --- THE CORE LOGIC ---
-			if isSensitive(comm) {
-				if isSystemBackground(event.Pid, event.Ppid) {
-					continue
-				}
+	var scopeNote string
+	cfg.Scope, scopeNote = effectiveScope(cfg.Scope)
 
-				riskLevel = "SENSITIVE ACTIVITY 🚩"
-				alertEmoji = "🚩"
+	backend, degraded := BackendEBPF, ""
+	var err error
+	if cfg.Backend != BackendProcConnector {
+		err = m.loadProbes(&cfg)
+	}
+	if cfg.Backend == BackendProcConnector || (err != nil && cfg.Backend == BackendAuto) {
+		if err != nil {
+			log.Printf("[WARN] eBPF probes unavailable, falling back to the netlink proc connector: %v", err)
+			degraded = fmt.Sprintf("eBPF unavailable (%v); ", err)
+		}
+		if cerr := m.openConnector(&cfg); cerr != nil {
+			return errors.Join(err, fmt.Errorf("proc connector: %v", cerr))
+		}
+		backend, degraded, err = BackendProcConnector, degraded+procConnectorLimits, nil
+		log.Printf("[GUARDIAN] ⚠️ Degraded monitoring via the netlink proc connector: %s", procConnectorLimits)
+	}
+	if err != nil {
+		return err
+	}
+	blocking := m.lsmObjs != nil
+
+	m.done = make(chan struct{})
+	m.rulesStop = make(chan struct{})
+	m.spawn(func() { profiles.flushLoop(m.done) })
+	m.watchRules(cfg.RulesDir)
+	if objs := m.objs; objs != nil {
+		m.spawn(func() { sampleDrops(objs.Drops, m.done) })
+		m.spawn(func() { syncExecIgnore(objs.ExecIgnore, m.done) })
+		if blocking {
+			m.spawn(func() { syncExecDeny(objs.ExecDeny, m.done) })
+		}
+	}
+	if m.procObjs != nil || m.conn != nil {
+		m.spawn(func() { proctree.Run(m.done) })
+	}
 
-				fmt.Printf("\n[EBPF ALERT] %s Unauthorized Exec Detected!\n", alertEmoji)
-				fmt.Printf("   ├─ Command:    %s\n", comm)
-				fmt.Printf("   ├─ Risk:       %s\n", riskLevel)
-				fmt.Printf("   ├─ Source:     %s\n", sourceTag)
-				fmt.Printf("   ├─ Identity:   %s\n", userTag)
-				fmt.Printf("   └─ PID:        %d (Parent: %d)\n", event.Pid, event.Ppid)
-				fmt.Printf("--------------------------------------------\n")
+	m.rec = nil
+	if cfg.RecordFile != "" {
+		hdr := recordHeader{Version: recordVersion, Started: time.Now(), SelfPid: selfPid, Blocking: blocking}
+		if rec, err := newRecorder(cfg.RecordFile, hdr); err != nil {
+			log.Printf("[WARN] Event recording disabled: %v", err)
+			cfg.RecordFile = ""
+		} else {
+			m.rec = rec
+			m.spawn(func() { rec.flushLoop(m.done) })
+			log.Printf("[GUARDIAN] ⏺️ Recording events to %s", cfg.RecordFile)
+		}
+	}
 
-				// Log to Database via Guardian
-				guardian.ProcessAndLog(comm, int(event.Pid), riskLevel, sourceTag, userTag)
+	m.cfg = cfg
+	m.forensicsDir.Store(cfg.ForensicsDir)
+	m.running = true
+	m.started = time.Now()
+	m.reloaded = time.Time{}
+	m.backend, m.degraded, m.scopeNote = backend, degraded, scopeNote
+	ringSize := cfg.RingBufferSize
+	if m.conn != nil {
+		ringSize = 0
+	}
+	telemetry.SetMonitorBackend(backend, m.degradation())
+	telemetry.SetMonitorRunning(true, ringSize)
+
+	p := &pipeline{selfPid: selfPid, blocking: blocking, respond: liveResponder{forensicsDir: m.forensics}, baseline: profiles}
+	m.readerDone = make(chan struct{})
+	if m.conn != nil {
+		go readConnector(p, m.conn, m.rec, m.readerDone)
+	} else {
+		var settle *execSettler
+		if m.procObjs != nil {
+			settle = newExecSettler()
+		}
+		go readRingBuffer(p, m.rd, settle, m.rec, m.readerDone)
+	}
 
-				// Active Defense: Kill the suspicious process
-				err := guardian.KillProcess(int(event.Pid), comm)
-				if err == nil {
-					fmt.Printf("   └─ [DEFENDER]: PID %d Neutralized. 🛡️\n", event.Pid)
-				}
-			}
+	done := m.done
+	go func() {
+		select {
+		case <-ctx.Done():
+			m.Stop()
+		case <-done:
 		}
 	}()
+	return nil
 }
 
-// isTrustedPath checks the actual binary location on disk via symlink
-func isTrustedPath(pid, ppid uint32) bool {
-	for _, id := range []uint32{pid, ppid} {
-		exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", id))
-		if err == nil {
-			// Trust any execution coming from the Aegis-V project binaries
-			if strings.Contains(exe, "aegis-ctl") || strings.Contains(exe, "aegis-engine") {
-				return true
-			}
+// loadProbes loads the eBPF objects and attaches every probe the kernel supports.
+// It fails only when exec monitoring itself cannot run; cfg is updated to what was
+// applied (scope, sensitive paths).
+func (m *Monitor) loadProbes(cfg *MonitorConfig) error {
+	if err := rlimit.RemoveMemlock(); err != nil {
+		return fmt.Errorf("remove memlock limit: %v", err)
+	}
+
+	spec, err := loadBpf()
+	if err != nil {
+		return fmt.Errorf("load eBPF objects: %v", err)
+	}
+
+	spec.Maps["rb"].MaxEntries = uint32(cfg.RingBufferSize)
+
+	objs := &probeObjects{}
+	if err := spec.LoadAndAssign(objs, nil); err != nil {
+		return fmt.Errorf("load eBPF objects: %v", err)
+	}
+	m.objs = objs
+
+	// The map of managed cgroups is filled before the mode that reads it is set:
+	// in between, managed containers would go unmonitored
+	if err := m.setScope(ScopeAll, cfg.Scope); err != nil {
+		log.Printf("[WARN] Monitoring scope %s not set, monitoring managed containers only: %v", cfg.Scope, err)
+		from := cfg.Scope
+		cfg.Scope = ScopeManaged
+		if err := m.setScope(from, cfg.Scope); err != nil {
+			m.release()
+			return fmt.Errorf("set the monitoring scope: %v", err)
 		}
 	}
-	return false
-}
+	log.Printf("[GUARDIAN] 🎯 Monitoring scope: %s", cfg.Scope)
 
-func isInteractiveShellAttempt(pid uint32) bool {
-	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	tp, err := link.Tracepoint("syscalls", "sys_enter_execve", objs.TraceExecve, nil)
 	if err != nil {
-		return false
+		m.release()
+		return fmt.Errorf("attach tracepoint: %v", err)
 	}
-	// cmdline arguments are null-separated
-	args := bytes.Split(data, []byte{0})
-	
-	// If it's a shell running a specific command (like sh -c), ignore it
-	if len(args) > 2 && len(args[1]) > 0 {
-		return false
+	m.probes = append(m.probes, probe{"tracepoint/syscalls/sys_enter_execve", tp})
+
+	if err := syncSensitivePaths(objs.SensitivePaths, nil, cfg.SensitivePaths); err != nil {
+		log.Printf("[WARN] File access monitoring disabled: %v", err)
+		cfg.SensitivePaths = nil
+	} else {
+		m.probes = append(m.probes, attachOpenProbes(objs)...)
+	}
+
+	netObjs, netProbes, err := attachTracingProbes(spec, &objs.bpfMaps)
+	if err != nil {
+		log.Printf("[WARN] Egress and escalation probes unavailable (exec monitoring continues): %v", err)
 	}
+	m.netObjs = netObjs
+	m.probes = append(m.probes, netProbes...)
 
-	// If it's a naked shell, check if it's attached to a terminal (TTY)
-	return hasTTY(pid)
+	procObjs, procProbes, err := attachProcTreeProbes(spec, &objs.bpfMaps)
+	if err != nil {
+		log.Printf("[WARN] Process tree probes unavailable, lineage falls back to /proc: %v", err)
+	}
+	m.procObjs = procObjs
+	m.probes = append(m.probes, procProbes...)
+	// Seed after attaching: forks racing the /proc scan are replayed from the ring buffer
+	proctree.Seed()
+	proctree.SetKernelFed(procObjs != nil)
+
+	lsmObjs, lsmProbe, err := attachExecBlocker(spec, &objs.bpfMaps)
+	if err == nil {
+		m.lsmObjs = lsmObjs
+		m.probes = append(m.probes, lsmProbe)
+		log.Println("[GUARDIAN] ⛔ BPF LSM exec blocking active (deny_exec enforced before exec).")
+	} else {
+		log.Printf("[WARN] Pre-exec blocking unavailable, deny_exec falls back to SIGKILL: %v", err)
+	}
+
+	m.rd, err = ringbuf.NewReader(objs.Rb)
+	if err != nil {
+		m.release()
+		return fmt.Errorf("open ringbuf reader: %v", err)
+	}
+
+	log.Println("[GUARDIAN] 🛡️ Advanced eBPF Security Probe Active. Monitoring Namespaces...")
+	return nil
 }
 
-func hasTTY(pid uint32) bool {
-	fd0, err := os.Readlink(fmt.Sprintf("/proc/%d/fd/0", pid))
+// openConnector subscribes to the proc connector with the configured scope
+func (m *Monitor) openConnector(cfg *MonitorConfig) error {
+	c, err := openProcConnector()
 	if err != nil {
-		return false
+		return err
 	}
-	// pts = Pseudo Terminal Slave (interactive terminal)
-	return strings.Contains(fd0, "/dev/pts/") || strings.Contains(fd0, "/dev/tty")
+	if err := c.setScope(cfg.Scope); err != nil {
+		log.Printf("[WARN] Monitoring scope %s not set, monitoring managed containers only: %v", cfg.Scope, err)
+		cfg.Scope = ScopeManaged
+		c.setScope(cfg.Scope)
+	}
+	log.Printf("[GUARDIAN] 🎯 Monitoring scope: %s", cfg.Scope)
+	m.conn = c
+	// The table stays a cache in front of /proc: notifications can be missed
+	proctree.Seed()
+	proctree.SetKernelFed(false)
+	return nil
 }
 
-func isAncestorWhitelisted(ppid uint32) bool {
-	currPpid := ppid
-	for i := 0; i < 6; i++ { 
-		if currPpid <= 1 { break }
-		
-		// 1. Binary path check (Strongest)
-		exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", currPpid))
-		if err == nil && (strings.Contains(exe, "aegis-ctl") || strings.Contains(exe, "aegis-engine")) {
-			return true
+// readRingBuffer feeds the ring buffer into the pipeline until the reader is closed,
+// or flushed and every pending record was handled. With settle, execs wait for
+// their completion (see execSettler); the read deadline wakes the loop when the
+// oldest one has waited long enough.
+func readRingBuffer(p *pipeline, rd *ringbuf.Reader, settle *execSettler, rec *recorder, finished chan<- struct{}) {
+	defer close(finished)
+	send := func(events []settledEvent) {
+		for _, s := range events {
+			dispatch(p, rec, BackendEBPF, s.decoded, s.arrived)
+		}
+	}
+	for {
+		record, err := rd.Read()
+		if err != nil {
+			switch {
+			case errors.Is(err, os.ErrDeadlineExceeded) && settle != nil:
+				send(settle.expire(time.Now()))
+				rd.SetDeadline(settle.deadline())
+			case errors.Is(err, ringbuf.ErrClosed), errors.Is(err, ringbuf.ErrFlushed), errors.Is(err, os.ErrDeadlineExceeded):
+				if settle != nil {
+					send(settle.flush())
+				}
+				return
+			default:
+				telemetry.CountReadError()
+			}
+			continue
 		}
+		now := time.Now()
+		telemetry.SetBacklog(record.Remaining)
 
-		// 2. Command name check
-		data, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", currPpid))
-		if err == nil && isWhitelisted(strings.TrimSpace(string(data))) {
-			return true
+		decoded, err := decodeRecord(record.RawSample)
+		if err != nil {
+			telemetry.CountDecodeError()
+			continue
 		}
+		if settle == nil {
+			dispatch(p, rec, BackendEBPF, decoded, now)
+			continue
+		}
+		send(settle.add(decoded, now))
+		rd.SetDeadline(settle.deadline())
+	}
+}
 
-		// Get parent of parent
-		statData, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", currPpid))
-		if err != nil { break }
-		fmt.Sscanf(string(statData), "%d %*s %*s %d", &currPpid, &currPpid)
+// readConnector is readRingBuffer for the proc connector fallback
+func readConnector(p *pipeline, c *procConnector, rec *recorder, finished chan<- struct{}) {
+	defer close(finished)
+	buf := make([]byte, os.Getpagesize())
+	for {
+		events, err := c.read(buf)
+		if err != nil {
+			if errors.Is(err, os.ErrClosed) || errors.Is(err, os.ErrDeadlineExceeded) {
+				return
+			}
+			telemetry.CountReadError()
+			continue
+		}
+		now := time.Now()
+		for _, decoded := range events {
+			dispatch(p, rec, BackendProcConnector, decoded, now)
+		}
 	}
-	return false
 }
 
-func isSystemBackground(pid, ppid uint32) bool {
-	return isWhitelisted(fmt.Sprintf("%d", ppid))
+// dispatch enriches one event, records it and runs it through the pipeline
+func dispatch(p *pipeline, rec *recorder, backend string, decoded interface{}, now time.Time) {
+	ctx := enrich(decoded)
+	ctx.Backend = backend
+	if rec != nil {
+		rec.write(now, decoded, ctx)
+	}
+	p.handle(decoded, ctx, now)
+	telemetry.ObserveLatency(time.Since(now))
 }
 
-func isWhitelisted(comm string) bool {
-	safeTools := []string{
-		"aegis-ctl", "aegis-engine", "docker", "dockerd",
-		"containerd", "runc", "containerd-shim", "sudo",
-		"gnome-shell", "gnome-terminal", "tmux", "sshd",
-		"systemd", "dbus-daemon", "go", "build",
+// Stop detaches every probe, handles the events still in the ring buffer and waits
+// for the background loops (profiles are flushed, the recording is closed) before
+// freeing the kernel objects. Stopping a stopped monitor does nothing.
+func (m *Monitor) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.running {
+		return
 	}
-	for _, tool := range safeTools {
-		if comm == tool || strings.Contains(comm, tool) {
-			return true
-		}
+	m.running = false
+
+	// No new events once the hooks are gone; drain what the kernel already queued
+	for _, p := range m.probes {
+		p.link.Close()
+	}
+	m.probes = nil
+	if m.conn != nil {
+		m.conn.unsubscribe()
+		m.conn.SetReadDeadline(time.Now())
+	} else {
+		// Not SetDeadline: it waits for a Read blocked on an idle ring buffer
+		m.rd.Flush()
+	}
+	<-m.readerDone
+
+	close(m.done)
+	close(m.rulesStop)
+	if m.scopeStop != nil {
+		close(m.scopeStop)
+		m.scopeStop = nil
+	}
+	m.workers.Wait()
+	telemetry.SetMonitorRunning(false, 0)
+	if m.rec != nil {
+		m.rec.close()
+		m.rec = nil
 	}
-	return false
+	m.release()
+	log.Println("[GUARDIAN] 🔌 Security probes detached, monitor stopped.")
 }
 
-func isNoise(comm string) bool {
-	noiseSignals := []string{
-		"code", "gopls", "cpuUsage", "pg_isready", "lesspipe",
-		"cron", "apt-check", "update-notifier", "nice", "ionice",
-		"node", "npm", "sa1", "ls", "ps", "grep",
+// release closes whatever Start managed to set up, newest first
+func (m *Monitor) release() {
+	if m.scopeStop != nil {
+		// Only left running when Start failed: nothing else was spawned yet
+		close(m.scopeStop)
+		m.scopeStop = nil
+		m.workers.Wait()
 	}
-	for _, signal := range noiseSignals {
-		if strings.Contains(comm, signal) {
-			return true
-		}
+	for _, p := range m.probes {
+		p.link.Close()
+	}
+	m.probes = nil
+	if m.conn != nil {
+		m.conn.Close()
+		m.conn = nil
+	}
+	if m.rd != nil {
+		m.rd.Close()
+		m.rd = nil
+	}
+	if m.lsmObjs != nil {
+		m.lsmObjs.Close()
+		m.lsmObjs = nil
+	}
+	if m.procObjs != nil {
+		m.procObjs.Close()
+		m.procObjs = nil
+		proctree.SetKernelFed(false)
+	}
+	if m.netObjs != nil {
+		m.netObjs.Close()
+		m.netObjs = nil
+	}
+	if m.objs != nil {
+		m.objs.Close()
+		m.objs = nil
 	}
-	return false
 }
 
-func isContainerStartup(comm string) bool {
-	startups := []string{"docker-entrypoi", "spawn", "apt-daily"}
-	for _, s := range startups {
-		if strings.Contains(comm, s) {
-			return true
+// Reload applies cfg to the running monitor without detaching anything, so no event
+// is missed: rules and trusted binaries are re-read (also when their paths did not
+// change), and the sensitive paths, scope and forensics directory are updated in place.
+// Each part that fails keeps its previous setting. The ring buffer size and the
+// recording file only change on a restart. A stopped monitor takes cfg on its next Start.
+func (m *Monitor) Reload(cfg MonitorConfig) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.running {
+		m.cfg = cfg
+		m.forensicsDir.Store(cfg.ForensicsDir)
+		return nil
+	}
+	old := m.cfg
+	var errs []error
+
+	if err := rules.Init(cfg.RulesDir); err != nil {
+		errs = append(errs, fmt.Errorf("rules in %s: %v", cfg.RulesDir, err))
+		cfg.RulesDir = old.RulesDir
+	} else if cfg.RulesDir != old.RulesDir {
+		close(m.rulesStop)
+		m.rulesStop = make(chan struct{})
+		m.watchRules(cfg.RulesDir)
+	}
+
+	if err := trust.Init(cfg.TrustFile); err != nil {
+		errs = append(errs, fmt.Errorf("trust file %s: %v", cfg.TrustFile, err))
+		cfg.TrustFile = old.TrustFile
+	}
+
+	// The proc connector has no file access monitoring; the paths apply on the probes only
+	if m.objs != nil {
+		if err := syncSensitivePaths(m.objs.SensitivePaths, old.SensitivePaths, cfg.SensitivePaths); err != nil {
+			errs = append(errs, err)
+			cfg.SensitivePaths = old.SensitivePaths
+		} else if len(cfg.SensitivePaths) > 0 && !m.features().FileAccess {
+			m.probes = append(m.probes, attachOpenProbes(m.objs)...)
+		}
+	}
+
+	var scopeNote string
+	cfg.Scope, scopeNote = effectiveScope(cfg.Scope)
+	if cfg.Scope != old.Scope {
+		if err := m.setScope(old.Scope, cfg.Scope); err != nil {
+			errs = append(errs, fmt.Errorf("scope %s: %v", cfg.Scope, err))
+			cfg.Scope, scopeNote = old.Scope, m.scopeNote
+		} else {
+			log.Printf("[GUARDIAN] 🎯 Monitoring scope: %s", cfg.Scope)
 		}
 	}
-	return false
+	m.scopeNote = scopeNote
+	telemetry.SetMonitorBackend(m.backend, m.degradation())
+
+	if cfg.RingBufferSize != old.RingBufferSize {
+		log.Printf("[WARN] Ring buffer size change to %d bytes applies after a restart", cfg.RingBufferSize)
+		cfg.RingBufferSize = old.RingBufferSize
+	}
+	if cfg.RecordFile != old.RecordFile {
+		log.Printf("[WARN] Recording file change to %q applies after a restart", cfg.RecordFile)
+		cfg.RecordFile = old.RecordFile
+	}
+
+	m.forensicsDir.Store(cfg.ForensicsDir)
+	m.cfg = cfg
+	m.reloaded = time.Now()
+	set := rules.Current()
+	log.Printf("[GUARDIAN] 🔄 Monitor reloaded: %d rules (%s), %d trusted binaries, scope %s", len(set.Rules), set.Version, len(trust.Current().Entries), cfg.Scope)
+	return errors.Join(errs...)
 }
 
-func isSensitive(comm string) bool {
-	tools := []string{"sh", "bash", "dash", "curl", "wget", "nc", "netcat", "whoami", "cat"}
-	for _, tool := range tools {
-		if comm == tool {
-			return true
+// setScope switches the scope of the probes, at load and on reload. The managed
+// container list is installed before the kernel starts filtering on it, so no
+// managed event is lost.
+func (m *Monitor) setScope(from, to string) error {
+	if m.conn != nil {
+		return m.conn.setScope(to)
+	}
+	started := false
+	if to != ScopeAll && m.scopeStop == nil {
+		filled := make(chan struct{})
+		m.syncScope(filled)
+		<-filled
+		started = true
+	}
+	if err := loadScope(m.objs.ScopeCfg, to); err != nil {
+		if started {
+			close(m.scopeStop)
+			m.scopeStop = nil
 		}
+		return err
+	}
+	if to == ScopeAll && m.scopeStop != nil {
+		close(m.scopeStop)
+		m.scopeStop = nil
+	}
+	return nil
+}
+
+// Status reports the attached probes and the configuration in use
+func (m *Monitor) Status() MonitorStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	set := rules.Current()
+	st := MonitorStatus{
+		Running:        m.running,
+		Probes:         []string{},
+		Scope:          m.cfg.Scope,
+		RingBufferSize: m.cfg.RingBufferSize,
+		RulesDir:       m.cfg.RulesDir,
+		RulesVersion:   set.Version,
+		Rules:          len(set.Rules),
+		TrustFile:      m.cfg.TrustFile,
+		Trusted:        len(trust.Current().Entries),
+		SensitivePaths: m.cfg.SensitivePaths,
+		ForensicsDir:   m.cfg.ForensicsDir,
+		RecordFile:     m.cfg.RecordFile,
+	}
+	if !m.running {
+		return st
+	}
+	started := m.started
+	st.Started = &started
+	if !m.reloaded.IsZero() {
+		reloaded := m.reloaded
+		st.Reloaded = &reloaded
 	}
-	return false
-}
\ No newline at end of file
+	st.Backend, st.Degraded = m.backend, m.degradation()
+	for _, p := range m.probes {
+		st.Probes = append(st.Probes, p.name)
+	}
+	if m.conn != nil {
+		st.Probes = append(st.Probes, "netlink/proc_connector")
+	}
+	st.Features = m.features()
+	return st
+}
+
+// degradation is everything the running monitor cannot see ("" when nothing)
+func (m *Monitor) degradation() string {
+	if m.degraded == "" || m.scopeNote == "" {
+		return m.degraded + m.scopeNote
+	}
+	return m.degraded + "; " + m.scopeNote
+}
+
+func (m *Monitor) features() MonitorFeatures {
+	return MonitorFeatures{
+		FileAccess: slices.ContainsFunc(m.probes, func(p probe) bool {
+			return strings.HasPrefix(p.name, "tracepoint/syscalls/sys_enter_openat")
+		}),
+		Tracing:      m.netObjs != nil,
+		ProcessTree:  m.procObjs != nil,
+		ExecBlocking: m.lsmObjs != nil,
+	}
+}
+
+// forensics is the forensics directory the live responder preserves samples in
+func (m *Monitor) forensics() string {
+	return m.forensicsDir.Load().(string)
+}
+
+// spawn runs a background loop that Stop waits for
+func (m *Monitor) spawn(f func()) {
+	m.workers.Add(1)
+	go func() {
+		defer m.workers.Done()
+		f()
+	}()
+}
+
+// watchRules hot-reloads dir until rulesStop is closed
+func (m *Monitor) watchRules(dir string) {
+	stop := m.rulesStop
+	m.spawn(func() { rules.Watch(dir, stop) })
+}
+
+// syncScope keeps the scope map current until scopeStop is closed; filled (if
+// not nil) is closed once the running managed containers are installed
+func (m *Monitor) syncScope(filled chan<- struct{}) {
+	m.scopeStop = make(chan struct{})
+	stop, scope := m.scopeStop, m.objs.Scope
+	m.spawn(func() { syncScope(scope, stop, filled) })
+}
+
+var (
+	hostOnce sync.Once
+	host     string
+)
+
+// hostName is the name of the machine detections are raised on
+func hostName() string {
+	hostOnce.Do(func() {
+		host, _ = os.Hostname()
+	})
+	return host
+}
+
+func identityOf(uid uint32) string {
+	if uid == 0 {
+		return "ROOT ⚠️"
+	}
+	return "USER"
+}
+
+func hasTTY(pid uint32) bool {
+	fd0, err := os.Readlink(fmt.Sprintf("/proc/%d/fd/0", pid))
+	if err != nil {
+		return false
+	}
+	// pts = Pseudo Terminal Slave (interactive terminal)
+	return strings.Contains(fd0, "/dev/pts/") || strings.Contains(fd0, "/dev/tty")
+}
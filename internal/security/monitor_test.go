@@ -0,0 +1,133 @@
+package security
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/orchestrator"
+	"github.com/Debasish-87/aegis-v/internal/telemetry"
+)
+
+// monitorConfig is a configuration confined to a temporary directory
+func monitorConfig(t *testing.T) MonitorConfig {
+	t.Helper()
+	dir := t.TempDir()
+	t.Chdir(dir)
+	return MonitorConfig{
+		SensitivePaths: []string{"/etc/shadow"},
+		RingBufferSize: defaultRingBufferSize,
+		RulesDir:       filepath.Join(dir, "rules"),
+		TrustFile:      filepath.Join(dir, "trusted.yaml"),
+		ForensicsDir:   filepath.Join(dir, "forensics"),
+		Scope:          ScopeManaged,
+		Backend:        BackendAuto,
+	}
+}
+
+func TestStoppedMonitor(t *testing.T) {
+	cfg := monitorConfig(t)
+	m := NewMonitor(cfg)
+
+	st := m.Status()
+	if st.Running || st.Started != nil || st.Probes == nil || len(st.Probes) != 0 {
+		t.Errorf("stopped status = %+v", st)
+	}
+	if st.Scope != ScopeManaged || st.RulesDir != cfg.RulesDir || st.ForensicsDir != cfg.ForensicsDir || st.Rules == 0 {
+		t.Errorf("stopped status config = %+v", st)
+	}
+
+	// A stopped monitor takes the whole configuration for its next Start
+	next := cfg
+	next.Scope, next.ForensicsDir, next.RingBufferSize = ScopeHost, "/var/lib/aegis/forensics", 4*defaultRingBufferSize
+	if err := m.Reload(next); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if st := m.Status(); st.Scope != ScopeHost || st.RingBufferSize != next.RingBufferSize || st.Reloaded != nil {
+		t.Errorf("after reload = %+v", st)
+	}
+	if m.forensics() != next.ForensicsDir {
+		t.Errorf("forensics dir = %s", m.forensics())
+	}
+	m.Stop() // no-op
+}
+
+// A real start needs eBPF or the proc connector (root); skipped elsewhere
+func TestMonitorLifecycle(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("needs root")
+	}
+	cfg := monitorConfig(t)
+	m := NewMonitor(cfg)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := m.Start(ctx); err != nil {
+		t.Skipf("no event source in this environment: %v", err)
+	}
+	t.Cleanup(m.Stop)
+
+	st := m.Status()
+	if !st.Running || st.Started == nil || st.Backend == "" || len(st.Probes) == 0 {
+		t.Fatalf("running status = %+v", st)
+	}
+	if !telemetry.Snapshot().MonitorRunning {
+		t.Error("telemetry does not report the monitor running")
+	}
+	if err := m.Start(ctx); err == nil {
+		t.Error("second Start: want an error")
+	}
+
+	// Parts that fail keep their previous setting; restart-only settings are kept
+	bad := cfg
+	bad.TrustFile = filepath.Join(t.TempDir(), "trusted.yaml")
+	os.WriteFile(bad.TrustFile, []byte("binaries:\n  - {name: x}\n"), 0600)
+	bad.RingBufferSize = 2 * cfg.RingBufferSize
+	bad.ForensicsDir = filepath.Join(t.TempDir(), "samples")
+	if err := m.Reload(bad); err == nil {
+		t.Error("Reload with an invalid trust file: want an error")
+	}
+	st = m.Status()
+	if st.TrustFile != cfg.TrustFile || st.RingBufferSize != cfg.RingBufferSize || st.ForensicsDir != bad.ForensicsDir || st.Reloaded == nil {
+		t.Errorf("after partial reload = %+v", st)
+	}
+
+	// Cancelling the start context stops the monitor
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for m.Status().Running && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if m.Status().Running {
+		t.Fatal("monitor still running after the context was cancelled")
+	}
+	if telemetry.Snapshot().MonitorRunning {
+		t.Error("telemetry still reports the monitor running")
+	}
+}
+
+// A managed scope that cannot be applied falls back to all and says so
+func TestMonitorScopeWithoutCgroups(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("needs root")
+	}
+	cgroupsAvailable = func() bool { return false }
+	t.Cleanup(func() { cgroupsAvailable = orchestrator.CgroupsAvailable })
+	m := NewMonitor(monitorConfig(t))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := m.Start(ctx); err != nil {
+		t.Skipf("no event source in this environment: %v", err)
+	}
+	t.Cleanup(m.Stop)
+
+	st := m.Status()
+	if st.Scope != ScopeAll || !strings.Contains(st.Degraded, noCgroupScope) {
+		t.Errorf("status scope %s, degraded %q; want all, degraded", st.Scope, st.Degraded)
+	}
+	if h := telemetry.Snapshot(); h.Status != "degraded" || !strings.Contains(h.Degraded, noCgroupScope) {
+		t.Errorf("health %s, degraded %q", h.Status, h.Degraded)
+	}
+}
@@ -0,0 +1,636 @@
+package security
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"path"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/ai"
+	"github.com/Debasish-87/aegis-v/internal/guardian"
+	"github.com/Debasish-87/aegis-v/internal/orchestrator"
+	"github.com/Debasish-87/aegis-v/internal/platform"
+	"github.com/Debasish-87/aegis-v/internal/proctree"
+	"github.com/Debasish-87/aegis-v/internal/rules"
+	"github.com/Debasish-87/aegis-v/internal/telemetry"
+	"github.com/Debasish-87/aegis-v/internal/trust"
+)
+
+// Every event goes through the same three steps:
+//
+//	enrich   — look up everything the verdict depends on (container, policy, lineage, trust, drift, samples, tty, stdio)
+//	evaluate — filters and classification, using only the event and its context
+//	respond  — active responses, behind the responder interface
+//
+// The live monitor enriches from the caches and /proc; replay takes the context
+// from the recording and stubs the responder out.
+
+// responder carries out active responses
+type responder interface {
+	Kill(pid int, comm string) error
+	Preserve(s *sample) (string, error) // keeps a fileless sample, returns where
+}
+
+// liveResponder is the real thing: SIGKILL through the guardian's safety checks
+type liveResponder struct {
+	forensicsDir func() string // changes on Monitor.Reload
+}
+
+func (liveResponder) Kill(pid int, comm string) error {
+	return guardian.KillProcess(pid, comm)
+}
+
+func (r liveResponder) Preserve(s *sample) (string, error) {
+	return preserveSample(r.forensicsDir(), s)
+}
+
+// eventContext is what an event was judged against, captured when it arrived
+type eventContext struct {
+	Container orchestrator.ContainerInfo `json:"container"`
+	InCache   bool                       `json:"in_cache"`          // container known to the cgroup cache
+	NsName    string                     `json:"ns_name,omitempty"` // namespace walk fallback (non-Docker, cgroup v1)
+	Policy    platform.RuntimePolicy     `json:"policy"`
+	Process   *proctree.Process          `json:"process,omitempty"`
+	Ancestors []proctree.Process         `json:"ancestors,omitempty"` // nearest first
+	TTY       bool                       `json:"tty,omitempty"`
+	Trust     string                     `json:"trust,omitempty"`  // allowlist name of the executable (see internal/trust)
+	Drift     *drift                     `json:"drift,omitempty"`  // container exec of a file not shipped with the image
+	Sample    *sample                    `json:"sample,omitempty"` // file of a fileless exec
+	Stdio     *stdioSocket               `json:"stdio,omitempty"`  // network socket on stdin/stdout/stderr of an exec
+	Host      string                     `json:"host,omitempty"`
+	Backend   string                     `json:"backend,omitempty"` // event source (see MonitorConfig.Backend)
+}
+
+// source is the container the event is attributed to ("" for the host)
+func (c *eventContext) source() string {
+	if c.InCache {
+		return c.Container.Name
+	}
+	return c.NsName
+}
+
+// detection is the deployment context stored with a detection of this event
+func (c *eventContext) detection() platform.DetectionContext {
+	d := platform.DetectionContext{
+		Service:       c.NsName,
+		Revision:      c.Policy.Revision,
+		Version:       c.Policy.Version,
+		SecurityLevel: c.Policy.SecurityLevel,
+		RulesVersion:  rules.Current().Version,
+		Host:          c.Host,
+		Backend:       c.Backend,
+	}
+	if c.InCache {
+		info := c.Container
+		d.Service, d.ContainerID, d.Image, d.ImageDigest = info.Service, info.ID, info.Image, info.ImageDigest
+	}
+	return d
+}
+
+// enrich looks up the context of one event from live state. Process table
+// events carry no context: they only feed the table.
+func enrich(decoded interface{}) *eventContext {
+	var (
+		pid, ppid, mntNs uint32
+		cgroupID         uint64
+		procWalk         = true // namespace fallback and trust need /proc
+		execEv           *Event
+		filelessEv       *FilelessEvent
+	)
+	switch ev := decoded.(type) {
+	case Event:
+		pid, ppid, mntNs, cgroupID, execEv = ev.Pid, ev.Ppid, ev.MntNs, ev.CgroupID, &ev
+	case ConnectEvent:
+		// Egress is judged on the cgroup cache only; no /proc walk per connection
+		pid, ppid, mntNs, cgroupID, procWalk = ev.Pid, ev.Ppid, ev.MntNs, ev.CgroupID, false
+	case OpenEvent:
+		pid, ppid, mntNs, cgroupID = ev.Pid, ev.Ppid, ev.MntNs, ev.CgroupID
+	case CredEvent:
+		pid, ppid, mntNs, cgroupID = ev.Pid, ev.Ppid, ev.MntNs, ev.CgroupID
+	case BlockedExecEvent:
+		pid, ppid, mntNs, cgroupID = ev.Pid, ev.Ppid, ev.MntNs, ev.CgroupID
+	case FilelessEvent:
+		pid, ppid, mntNs, cgroupID, filelessEv = ev.Pid, ev.Ppid, ev.MntNs, ev.CgroupID, &ev
+	default:
+		return &eventContext{}
+	}
+
+	ctx := &eventContext{Host: hostName()}
+	if info, ok := orchestrator.ContainerByCgroup(cgroupID); ok {
+		ctx.Container, ctx.InCache = info, true
+		ctx.Policy = runtimePolicy(info.Service)
+	} else if procWalk {
+		if ctx.NsName = orchestrator.GetContainerNameByNamespace(mntNs); ctx.NsName != "" {
+			ctx.Policy = runtimePolicy(ctx.NsName)
+		}
+	}
+
+	if p, ok := proctree.Lookup(pid); ok {
+		ctx.Process = &p
+		ctx.Ancestors = proctree.Ancestors(pid)
+	}
+	if ctx.Ancestors == nil && ppid != 0 {
+		// The process itself is unknown (e.g. it raced the table); start at the parent
+		if parent, ok := proctree.Lookup(ppid); ok {
+			ctx.Ancestors = append([]proctree.Process{parent}, proctree.Ancestors(ppid)...)
+		}
+	}
+
+	if ctx.InCache {
+		markRoles(ctx.Container, ctx.Ancestors)
+	}
+	if procWalk {
+		for i := range ctx.Ancestors {
+			ctx.Ancestors[i].Trust = processTrust(ctx.Ancestors[i])
+		}
+		if execEv != nil {
+			ctx.Trust = execTrust(pid, execEv.Filename)
+			if ctx.InCache {
+				ctx.Drift = checkDrift(ctx.Container, pid, execEv.Filename)
+			}
+		} else if filelessEv != nil {
+			ctx.Sample = captureSample(*filelessEv)
+			// A memfd or deleted image has no path to pin, only its content
+			if ctx.Sample != nil && filelessEv.Flags&(filelessMemfd|filelessUnlinked) != 0 {
+				if e := trust.Current().MatchContent(ctx.Sample.SHA256); e != nil {
+					ctx.Trust = e.Name
+				}
+			}
+		} else if ctx.Process != nil {
+			ctx.Trust = processTrust(*ctx.Process)
+		}
+	}
+	if execEv != nil {
+		ctx.TTY = hasTTY(pid)
+		ctx.Stdio = stdioSocketOf(pid)
+	}
+	return ctx
+}
+
+// pipeline evaluates enriched events and responds to them
+type pipeline struct {
+	selfPid  uint32
+	blocking bool // deny_exec is enforced in the kernel (BPF LSM)
+	respond  responder
+	baseline *baselines // behaviour profiles; nil during replay
+}
+
+// handle runs one event through the pipeline; now is when the event arrived
+func (p *pipeline) handle(decoded interface{}, ctx *eventContext, now time.Time) {
+	switch ev := decoded.(type) {
+	case ProcessEvent:
+		trackProcess(ev, now)
+	case ConnectEvent:
+		if ev.Pid != p.selfPid {
+			p.handleConnect(ev, ctx, now)
+		}
+	case OpenEvent:
+		if ev.Pid != p.selfPid {
+			p.handleOpen(ev, ctx, now)
+		}
+	case CredEvent:
+		if ev.Pid != p.selfPid {
+			p.handleCred(ev, ctx, now)
+		}
+	case BlockedExecEvent:
+		p.handleBlocked(ev, ctx, now)
+	case FilelessEvent:
+		if ev.Pid != p.selfPid {
+			p.handleFileless(ev, ctx, now)
+		}
+	case Event:
+		p.handleExec(ev, ctx, now)
+	}
+}
+
+func (p *pipeline) handleExec(event Event, ctx *eventContext, now time.Time) {
+	// --- FILTER 1: Self & Engine Direct Child Check ---
+	if event.Pid == p.selfPid || event.Ppid == p.selfPid {
+		telemetry.CountFiltered("self")
+		return
+	}
+
+	// --- FILTER 2: Service deny_exec, ahead of every whitelist ---
+	// With BPF LSM the exec was already refused (reported via BlockedExecEvent)
+	if ctx.InCache && ctx.Container.Managed {
+		if execDenied(ctx.Policy.DenyExec, event.Filename) {
+			if !p.blocking {
+				p.killDeniedExec(event, ctx, now)
+			}
+			telemetry.CountFiltered("deny_exec")
+			return
+		}
+	}
+
+	// The event's comm is the caller's, read at sys_enter_execve; everything below
+	// describes the program being started, and the caller is only its parent
+	comm, caller := commOfPath(event.Filename), commOf(event.Comm)
+
+	// --- Drift: a binary that did not ship with the image, whatever its name ---
+	if ctx.Drift != nil {
+		p.reportDrift(event, ctx, comm, now)
+	}
+
+	// --- Behaviour profile: learn, or report what the profile has never seen ---
+	p.checkBaseline(ctx, event.Pid, event.Uid, comm, now,
+		binaryObservation(event.Filename), spawnObservation(caller, path.Base(event.Filename)), userObservation(event.Uid))
+
+	// --- FILTER 3: Detection rules (allow lists, noise, sensitive tools) ---
+	in := ruleInput(eventNames[eventExec], comm, event.Uid, ctx)
+	execImageInput(in, event.Filename, ctx)
+	in.Argv, in.Cwd = event.Argv, event.Cwd
+	if ctx.Stdio != nil {
+		in.StdioRemote = ctx.Stdio.Remote()
+	}
+	rule := rules.Evaluate(in)
+	if rule == nil || ignored(rule) {
+		return
+	}
+
+	// CLEAN SOURCE TAGGING FOR DATABASE
+	sourceTag := "HOST / SYSTEM"
+	if containerName := ctx.source(); containerName != "" {
+		sourceTag = containerName
+	} else if event.MntNs != 4026531840 && event.MntNs != 0 {
+		sourceTag = fmt.Sprintf("NS:%d", event.MntNs)
+	}
+
+	userTag := identityOf(event.Uid)
+
+	fmt.Printf("\n[EBPF ALERT] 🚩 Unauthorized Exec Detected!\n")
+	fmt.Printf("   ├─ Command:    %s\n", comm)
+	fmt.Printf("   ├─ Exe:        %s\n", event.Filename)
+	fmt.Printf("   ├─ Args:       %s\n", guardian.FormatArgv(event.Argv, event.ArgvTruncated))
+	fmt.Printf("   ├─ Cwd:        %s\n", event.Cwd)
+	if ctx.Stdio != nil {
+		fmt.Printf("   ├─ Stdio:      %s → %s %s\n", ctx.Stdio.Streams(), ctx.Stdio.Protocol, ctx.Stdio.Remote())
+	}
+	if in.Spawn == rules.SpawnDenied {
+		fmt.Printf("   ├─ Spawn:      %s is a main process and may not start programs\n", in.Ancestors[0].Comm)
+	}
+	fmt.Printf("   ├─ Rule:       %s (%s, %s)\n", rule.ID, rule.Severity, rule.Mitre)
+	fmt.Printf("   ├─ Source:     %s\n", sourceTag)
+	fmt.Printf("   ├─ Identity:   %s\n", userTag)
+	fmt.Printf("   └─ PID:        %d (Parent: %d)\n", event.Pid, event.Ppid)
+	fmt.Printf("--------------------------------------------\n")
+
+	// Active Defense: kill rules neutralize the process before it is logged
+	response := ""
+	if rule.Action == rules.ActionKill {
+		response = "KILLED"
+		if err := p.respond.Kill(int(event.Pid), comm); err != nil {
+			response = "KILL_FAILED"
+		} else {
+			fmt.Printf("   └─ [DEFENDER]: PID %d Neutralized. 🛡️\n", event.Pid)
+		}
+	}
+
+	alert := guardian.ExecAlert{
+		Command:       comm,
+		Filename:      event.Filename,
+		Argv:          event.Argv,
+		ArgvTruncated: event.ArgvTruncated,
+		Cwd:           event.Cwd,
+		PID:           int(event.Pid),
+		Risk:          rule.Severity,
+		Source:        sourceTag,
+		Identity:      userTag,
+		Response:      response,
+		Ancestry:      ctx.Ancestors,
+		Rule:          rule.ID,
+		Mitre:         rule.Mitre,
+		Context:       ctx.detection(),
+	}
+
+	// A process driven through a network socket is a remote shell, whatever rule caught it
+	if s := ctx.Stdio; s != nil {
+		guardian.LogReverseShell(guardian.ReverseShellAlert{
+			ExecAlert:  alert,
+			Streams:    s.Streams(),
+			Protocol:   s.Protocol,
+			RemoteIP:   s.RemoteIP,
+			RemotePort: int(s.RemotePort),
+		})
+		return
+	}
+
+	// Log to Database via Guardian
+	guardian.ProcessAndLog(alert)
+}
+
+// checkBaseline feeds a managed container's observations to its behaviour profile:
+// learned during the service's learning window, reported as deviations afterwards
+func (p *pipeline) checkBaseline(ctx *eventContext, pid, uid uint32, comm string, now time.Time, obs ...observation) {
+	info := ctx.Container
+	if p.baseline == nil || !ctx.InCache || !info.Managed || info.ImageID == "" {
+		return
+	}
+	for _, o := range p.baseline.observe(info, ctx.Policy.LearnUntil, obs, now) {
+		if !shouldReport(fmt.Sprintf("deviation|%s|%s", info.Service, o.key()), now) {
+			continue
+		}
+		guardian.LogDeviation(guardian.DeviationAlert{
+			Command:  comm,
+			PID:      int(pid),
+			Source:   info.Name,
+			Service:  info.Service,
+			Identity: identityOf(uid),
+			Kind:     o.kind,
+			Value:    o.value,
+			Ancestry: ctx.Ancestors,
+			Context:  ctx.detection(),
+		})
+	}
+}
+
+// killByRule applies a kill rule to a non-exec event; "" when the rule only alerts
+func (p *pipeline) killByRule(rule *rules.Rule, pid uint32, comm string) string {
+	if rule == nil || rule.Action != rules.ActionKill {
+		return ""
+	}
+	if err := p.respond.Kill(int(pid), comm); err != nil {
+		log.Printf("[DEFENDER] Rule %s could not kill PID %d: %v", rule.ID, pid, err)
+		return "KILL_FAILED"
+	}
+	return "KILLED"
+}
+
+// ruleRef is the id and technique stored with a detection ("" when no rule matched)
+func ruleRef(rule *rules.Rule) (id, mitre string) {
+	if rule == nil {
+		return "", ""
+	}
+	return rule.ID, rule.Mitre
+}
+
+// handleConnect flags container egress outside the service's declared policy.
+// Services without an egress section are only checked against mining-pool ports.
+func (p *pipeline) handleConnect(ev ConnectEvent, ctx *eventContext, now time.Time) {
+	if ev.DestIP.IsLoopback() || ev.DestIP.IsUnspecified() {
+		return
+	}
+
+	// Container traffic only; host egress is out of scope
+	if !ctx.InCache {
+		return
+	}
+	info := ctx.Container
+	comm := commOf(ev.Comm)
+	p.checkBaseline(ctx, ev.Pid, ev.Uid, comm, now, destinationObservation(ev.DestIP, ev.DestPort, ev.Protocol), userObservation(ev.Uid))
+
+	// A matching alert/kill rule reports the connection whatever the policy says
+	in := ruleInput(eventNames[eventConnect], comm, ev.Uid, ctx)
+	in.DestIP, in.DestPort = ev.DestIP.String(), ev.DestPort
+	rule := rules.Evaluate(in)
+	if ignored(rule) {
+		return
+	}
+
+	policy := ctx.Policy
+	defined := policy.EgressDefined
+	if rule == nil {
+		if defined && EgressAllowed(policy.Egress, ev.DestIP, ev.DestPort, ev.Protocol) {
+			telemetry.CountFiltered("egress_allowed")
+			return
+		}
+		if !defined && !ai.IsMiningPort(ev.DestPort) {
+			return
+		}
+	}
+
+	response := p.killByRule(rule, ev.Pid, comm)
+	key := fmt.Sprintf("egress|%s|%s|%d|%s", info.Service, ev.DestIP, ev.DestPort, ev.Protocol)
+	if response == "" && !shouldReport(key, now) {
+		return
+	}
+
+	ruleID, mitre := ruleRef(rule)
+	guardian.LogEgress(guardian.EgressAlert{
+		Command:         comm,
+		PID:             int(ev.Pid),
+		Source:          info.Name,
+		Identity:        identityOf(ev.Uid),
+		DestIP:          ev.DestIP.String(),
+		DestPort:        int(ev.DestPort),
+		Protocol:        ev.Protocol,
+		PolicyViolation: defined,
+		Ancestry:        ctx.Ancestors,
+		Rule:            ruleID,
+		Mitre:           mitre,
+		Response:        response,
+		Context:         ctx.detection(),
+	})
+}
+
+// handleOpen reports sensitive file opens inside containers (the kernel already
+// filtered on the path prefixes)
+func (p *pipeline) handleOpen(ev OpenEvent, ctx *eventContext, now time.Time) {
+	source := ctx.source()
+	if source == "" {
+		return
+	}
+
+	comm := commOf(ev.Comm)
+	in := ruleInput(eventNames[eventOpen], comm, ev.Uid, ctx)
+	in.Path = ev.Path
+	rule := rules.Evaluate(in)
+	if ignored(rule) {
+		return
+	}
+
+	response := p.killByRule(rule, ev.Pid, comm)
+	if response == "" && !shouldReport(fmt.Sprintf("open|%s|%s|%s", source, comm, ev.Path), now) {
+		return
+	}
+
+	ruleID, mitre := ruleRef(rule)
+
+	guardian.LogFileAccess(guardian.FileAlert{
+		Command:  comm,
+		PID:      int(ev.Pid),
+		Source:   source,
+		Identity: identityOf(ev.Uid),
+		Path:     ev.Path,
+		Flags:    int(ev.Flags),
+		Write:    ev.Write(),
+		Ancestry: ctx.Ancestors,
+		Rule:     ruleID,
+		Mitre:    mitre,
+		Response: response,
+		Context:  ctx.detection(),
+	})
+}
+
+// handleCred reports privilege escalation inside containers and applies the
+// service's escalation response (see EscalationAction)
+func (p *pipeline) handleCred(ev CredEvent, ctx *eventContext, now time.Time) {
+	source := ctx.source()
+	if source == "" {
+		return // host processes (sudo, su, ...) are out of scope
+	}
+
+	comm := commOf(ev.Comm)
+	rule := rules.Evaluate(ruleInput(eventNames[eventCred], comm, ev.Uid, ctx))
+	if ignored(rule) {
+		return
+	}
+
+	gained := ev.GainedCaps()
+	severity, _ := ai.ClassifyEscalation(ev.ToRoot(), gained)
+	policy := ctx.Policy
+
+	response := "ALERTED"
+	kill := EscalationAction(severity, policy.SecurityLevel, policy.Managed) == EscalationKill
+	if kill || (rule != nil && rule.Action == rules.ActionKill) {
+		response = "KILLED"
+		if err := p.respond.Kill(int(ev.Pid), comm); err != nil {
+			log.Printf("[DEFENDER] Could not kill escalated PID %d: %v", ev.Pid, err)
+			response = "KILL_FAILED"
+		}
+	} else if !shouldReport(fmt.Sprintf("cred|%s|%s|%d|%d|%x", source, comm, ev.OldEuid, ev.NewEuid, ev.NewCaps&^ev.OldCaps), now) {
+		return
+	}
+
+	ruleID, mitre := ruleRef(rule)
+	guardian.LogPrivEscalation(guardian.PrivEscAlert{
+		Command:    comm,
+		PID:        int(ev.Pid),
+		Source:     source,
+		OldUid:     int(ev.OldUid),
+		NewUid:     int(ev.NewUid),
+		OldEuid:    int(ev.OldEuid),
+		NewEuid:    int(ev.NewEuid),
+		ToRoot:     ev.ToRoot(),
+		GainedCaps: gained,
+		Response:   response,
+		Ancestry:   ctx.Ancestors,
+		Rule:       ruleID,
+		Mitre:      mitre,
+		Context:    ctx.detection(),
+	})
+}
+
+// handleBlocked reports an exec the LSM hook refused
+func (p *pipeline) handleBlocked(ev BlockedExecEvent, ctx *eventContext, now time.Time) {
+	source := ctx.source()
+	in := ruleInput(eventNames[eventBlocked], commOf(ev.Comm), ev.Uid, ctx)
+	in.Exe, in.Path = ev.Path, ev.Path
+	if ignored(rules.Evaluate(in)) {
+		return
+	}
+	if !shouldReport(fmt.Sprintf("blocked|%s|%s", source, ev.Binary), now) {
+		return
+	}
+	guardian.LogDeniedExec(guardian.ExecAlert{
+		Command:  ev.Binary,
+		Filename: ev.Path,
+		PID:      int(ev.Pid),
+		Source:   source,
+		Identity: identityOf(ev.Uid),
+		Response: "BLOCKED",
+		Ancestry: ctx.Ancestors,
+		Context:  ctx.detection(),
+	})
+}
+
+// handleFileless reports execs of memfd-backed, unlinked or temp-directory files.
+// The sample is preserved before any kill: the process may hold the last reference.
+func (p *pipeline) handleFileless(ev FilelessEvent, ctx *eventContext, now time.Time) {
+	if ev.Ppid == p.selfPid {
+		telemetry.CountFiltered("self")
+		return
+	}
+
+	comm := commOf(ev.Comm)
+	reasons := ev.Reasons()
+	in := ruleInput(eventNames[eventFileless], comm, ev.Uid, ctx)
+	execImageInput(in, ev.Filename, ctx)
+	in.Fileless = reasons
+	rule := rules.Evaluate(in)
+	if rule == nil || ignored(rule) {
+		return
+	}
+
+	source := ctx.source()
+	if source == "" {
+		source = "HOST / SYSTEM"
+	}
+	s := ctx.Sample
+	sum := ""
+	if s != nil {
+		sum = s.SHA256
+	}
+	if rule.Action != rules.ActionKill && !shouldReport(fmt.Sprintf("fileless|%s|%s|%s", source, ev.Name, sum), now) {
+		return
+	}
+
+	alert := guardian.FilelessAlert{
+		Command:  comm,
+		Filename: ev.Filename,
+		Name:     ev.Name,
+		Reasons:  reasons,
+		PID:      int(ev.Pid),
+		Source:   source,
+		Identity: identityOf(ev.Uid),
+		Ancestry: ctx.Ancestors,
+		Context:  ctx.detection(),
+	}
+	if s != nil {
+		alert.SHA256, alert.Size = s.SHA256, s.Size
+		if kept, err := p.respond.Preserve(s); err != nil {
+			log.Printf("[FORENSICS] Sample of PID %d not kept: %v", ev.Pid, err)
+		} else {
+			alert.Sample = kept
+		}
+	}
+	alert.Response = p.killByRule(rule, ev.Pid, comm)
+	alert.Risk = rule.Severity
+	alert.Rule, alert.Mitre = ruleRef(rule)
+	guardian.LogFileless(alert)
+}
+
+// killDeniedExec is the deny_exec fallback on kernels without BPF LSM: the binary
+// already started, so it is killed as soon as the event arrives
+func (p *pipeline) killDeniedExec(event Event, ctx *eventContext, now time.Time) {
+	binary := path.Base(event.Filename)
+	source := ctx.Container.Name
+	response := "KILLED"
+	if err := p.respond.Kill(int(event.Pid), binary); err != nil {
+		log.Printf("[DEFENDER] Could not kill denied exec PID %d: %v", event.Pid, err)
+		response = "KILL_FAILED"
+	}
+	if !shouldReport(fmt.Sprintf("denied|%s|%s|%s", source, binary, response), now) {
+		return
+	}
+	guardian.LogDeniedExec(guardian.ExecAlert{
+		Command:       binary,
+		Filename:      event.Filename,
+		Argv:          event.Argv,
+		ArgvTruncated: event.ArgvTruncated,
+		Cwd:           event.Cwd,
+		PID:           int(event.Pid),
+		Source:        source,
+		Identity:      identityOf(event.Uid),
+		Response:      response,
+		Ancestry:      ctx.Ancestors,
+		Context:       ctx.detection(),
+	})
+}
+
+// trackProcess feeds the process table
+func trackProcess(ev ProcessEvent, now time.Time) {
+	comm := commOf(ev.Comm)
+	switch ev.Kind {
+	case eventFork:
+		proctree.Fork(ev.Pid, ev.ChildPid, ev.StartTime, comm, ev.CgroupID)
+	case eventSchExec:
+		proctree.Exec(ev.Pid, ev.Ppid, ev.StartTime, comm, ev.Filename, ev.CgroupID)
+	case eventExit:
+		proctree.Exit(ev.Pid, ev.StartTime, now)
+	}
+}
+
+func commOf(comm [16]byte) string {
+	return string(bytes.TrimRight(comm[:], "\x00"))
+}
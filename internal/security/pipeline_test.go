@@ -0,0 +1,187 @@
+package security
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/guardian"
+	"github.com/Debasish-87/aegis-v/internal/orchestrator"
+	"github.com/Debasish-87/aegis-v/internal/platform"
+	"github.com/Debasish-87/aegis-v/internal/proctree"
+)
+
+// detectionDB opens a throwaway database the guardian logs detections to, with
+// nothing reported yet (the rate limit outlives a test run)
+func detectionDB(t *testing.T) {
+	t.Helper()
+	db := platform.OpenTestDB(t)
+	runtimeMu.Lock()
+	reported = map[string]time.Time{}
+	runtimeMu.Unlock()
+	guardian.InitGuardian(db)
+	t.Cleanup(func() { guardian.InitGuardian(nil) })
+}
+
+// detections counts the stored detections of a type
+func detections(t *testing.T, kind string) int {
+	t.Helper()
+	var n int
+	if err := platform.DB.QueryRow("SELECT COUNT(*) FROM detections WHERE detection_type = ?", kind).Scan(&n); err != nil {
+		t.Fatalf("count %s: %v", kind, err)
+	}
+	return n
+}
+
+// containerCtx is what enrich finds for a process of a managed container
+func containerCtx(name, service, level string) *eventContext {
+	return &eventContext{
+		InCache:   true,
+		Container: orchestrator.ContainerInfo{ID: name + "-id", Name: name, Service: service, Managed: true},
+		Policy:    platform.RuntimePolicy{Managed: true, SecurityLevel: level},
+	}
+}
+
+func TestHandleOpen(t *testing.T) {
+	detectionDB(t)
+	p := &pipeline{selfPid: 1, respond: &fakeResponder{}}
+	now := time.Now()
+	open := func(pid uint32, path string) OpenEvent {
+		return OpenEvent{Pid: pid, Uid: 0, Comm: comm16("cat"), Path: path}
+	}
+
+	// Host opens are out of scope; the kernel already filtered on the prefixes
+	p.handle(open(500, "/etc/shadow"), &eventContext{}, now)
+	if n := detections(t, guardian.DetectionFileAccess); n != 0 {
+		t.Fatalf("host open stored %d detections", n)
+	}
+
+	ctx := containerCtx("open-web-1", "open-web", "medium")
+	p.handle(open(501, "/etc/shadow"), ctx, now)
+	// The same process name reading the same file again within the report window is one detection
+	p.handle(open(502, "/etc/shadow"), ctx, now.Add(time.Second))
+	p.handle(open(503, "/etc/sudoers"), ctx, now.Add(time.Second))
+	// The engine's own opens are never reported
+	p.handle(open(1, "/run/docker.sock"), ctx, now)
+	if n := detections(t, guardian.DetectionFileAccess); n != 2 {
+		t.Errorf("container opens stored %d detections, want 2", n)
+	}
+	p.handle(open(504, "/etc/shadow"), ctx, now.Add(reportWindow+time.Second))
+	if n := detections(t, guardian.DetectionFileAccess); n != 3 {
+		t.Errorf("after the report window: %d detections, want 3", n)
+	}
+
+	var service, path string
+	platform.DB.QueryRow("SELECT service, filename FROM detections WHERE detection_type = ? ORDER BY id LIMIT 1", guardian.DetectionFileAccess).Scan(&service, &path)
+	if service != "open-web" {
+		t.Errorf("detection service = %q, want open-web", service)
+	}
+}
+
+// The exec event's comm is the caller's (read at sys_enter_execve): rules on
+// comm and class must see the program being started
+func TestExecMatchesImage(t *testing.T) {
+	detectionDB(t)
+	bash := []proctree.Process{{Pid: 899, Comm: "bash", Exe: "/usr/bin/bash"}}
+	tests := []struct {
+		name     string
+		filename string
+		argv     []string
+		tty      bool
+		wantKill bool
+	}{
+		{"curl from a shell", "/usr/bin/curl", []string{"curl", "http://example.com/x"}, false, true},
+		{"ls on a terminal", "/usr/bin/ls", []string{"ls"}, true, false},
+		{"interactive shell", "/usr/bin/bash", []string{"bash"}, true, true},
+	}
+	for i, tt := range tests {
+		ctx := containerCtx("exec-web-1", "exec-web", "medium")
+		ctx.TTY, ctx.Ancestors = tt.tty, bash
+		pid := uint32(900 + i)
+		r := &fakeResponder{}
+		(&pipeline{selfPid: 1, respond: r}).handle(Event{Pid: pid, Ppid: 899, Comm: comm16("bash"), Filename: tt.filename, Argv: tt.argv}, ctx, time.Now())
+		if killed := len(r.killed) == 1 && r.killed[0] == int(pid); killed != tt.wantKill {
+			t.Errorf("%s: killed %v, want kill %v", tt.name, r.killed, tt.wantKill)
+		}
+	}
+}
+
+// The alert describes the program being started; the caller only shows up as its parent
+func TestExecAlertCommand(t *testing.T) {
+	detectionDB(t)
+	ctx := containerCtx("cmd-web-1", "cmd-web", "medium")
+	ctx.Ancestors = []proctree.Process{{Pid: 949, Comm: "bash", Exe: "/usr/bin/bash"}}
+	r := &fakeResponder{}
+	(&pipeline{selfPid: 1, respond: r}).handle(Event{Pid: 950, Ppid: 949, Comm: comm16("bash"), Filename: "/usr/bin/wget", Argv: []string{"wget", "x"}}, ctx, time.Now())
+	if len(r.names) != 1 || r.names[0] != "wget" {
+		t.Errorf("killed %v, want wget", r.names)
+	}
+
+	var command, ancestry string
+	if err := platform.DB.QueryRow("SELECT command, ancestry FROM detections WHERE pid = 950").Scan(&command, &ancestry); err != nil {
+		t.Fatalf("detection not stored: %v", err)
+	}
+	if command != "wget" || !strings.Contains(ancestry, `"comm":"bash"`) {
+		t.Errorf("command %q, ancestry %s; want wget with a bash parent", command, ancestry)
+	}
+}
+
+func TestOpenEventWrite(t *testing.T) {
+	tests := []struct {
+		flags uint32
+		want  bool
+	}{
+		{0, false}, // O_RDONLY
+		{0x1, true},
+		{0x2, true},
+		{0x40, true},  // O_CREAT
+		{0x200, true}, // O_TRUNC
+		{0x80000, false},
+	}
+	for _, tt := range tests {
+		if got := (OpenEvent{Flags: tt.flags}).Write(); got != tt.want {
+			t.Errorf("Write() with flags %#x = %v, want %v", tt.flags, got, tt.want)
+		}
+	}
+}
+
+func TestSensitivePathConfig(t *testing.T) {
+	t.Setenv("AEGIS_SENSITIVE_PATHS", "/etc/shadow, /srv/keys/")
+	if got := LoadMonitorConfig().SensitivePaths; len(got) != 2 || got[1] != "/srv/keys/" {
+		t.Errorf("SensitivePaths = %q", got)
+	}
+
+	// Invalid prefixes are refused before the kernel map is touched (nil here)
+	for _, bad := range [][]string{{"etc/shadow"}, {"/" + string(make([]byte, maxPathLen))}} {
+		if err := syncSensitivePaths(nil, nil, bad); err == nil {
+			t.Errorf("syncSensitivePaths(%q): want an error", bad[0][:min(len(bad[0]), 12)])
+		}
+	}
+
+	key := sensitiveKey("/root/.ssh")
+	if key.PrefixLen != uint32(len("/root/.ssh")*8) || string(key.Path[:10]) != "/root/.ssh" || key.Path[10] != 0 {
+		t.Errorf("sensitiveKey = %d bits, %q", key.PrefixLen, key.Path[:12])
+	}
+}
+
+func TestEventContextDetection(t *testing.T) {
+	ctx := containerCtx("ctx-web-1", "ctx-web", "high")
+	ctx.Container.Image, ctx.Container.ImageDigest = "nginx:1.27", "sha256:n1"
+	ctx.Policy.Revision, ctx.Policy.Version = 3, "1.4.0"
+	ctx.Host, ctx.Backend = "node-a", BackendEBPF
+
+	d := ctx.detection()
+	if d.Service != "ctx-web" || d.ContainerID != "ctx-web-1-id" || d.Image != "nginx:1.27" || d.ImageDigest != "sha256:n1" ||
+		d.Revision != 3 || d.Version != "1.4.0" || d.SecurityLevel != "high" || d.Host != "node-a" || d.Backend != BackendEBPF {
+		t.Errorf("container context = %+v", d)
+	}
+	if d.RulesVersion == "" {
+		t.Error("rules version not captured")
+	}
+
+	// Outside the cgroup cache only the namespace walk's name is known
+	d = (&eventContext{NsName: "legacy-app", Host: "node-a"}).detection()
+	if d.Service != "legacy-app" || d.ContainerID != "" || d.Image != "" || d.Revision != 0 {
+		t.Errorf("namespace context = %+v", d)
+	}
+}
@@ -0,0 +1,349 @@
+package security
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/orchestrator"
+	"github.com/Debasish-87/aegis-v/internal/proctree"
+	"github.com/Debasish-87/aegis-v/internal/telemetry"
+)
+
+// Netlink proc connector (linux/connector.h, linux/cn_proc.h)
+const (
+	cnIdxProc         = 1
+	cnValProc         = 1
+	procCnMcastListen = 1
+	procCnMcastIgnore = 2
+
+	procEventNone = 0x0 // subscription ack
+	procEventFork = 0x1
+	procEventExec = 0x2
+	procEventExit = 0x80000000
+
+	cnMsgSize       = 20 // struct cn_msg before its data
+	procEventHeader = 16 // what, cpu, timestamp_ns of struct proc_event
+
+	// procConnRecvBuf absorbs fork bursts while /proc is being read
+	procConnRecvBuf = 4 << 20
+	// procConnAckTimeout bounds the wait for the kernel to confirm the subscription
+	procConnAckTimeout = 2 * time.Second
+
+	tmpfsMagic = 0x01021994
+)
+
+// procConnectorLimits is what the fallback cannot see, reported in status and /health
+const procConnectorLimits = "exec only (no file access, egress, privilege escalation or pre-exec blocking); " +
+	"exec details are read from /proc afterwards, so processes that exit first are missed and scripts show their interpreter"
+
+// procConnector receives fork / exec / exit notifications over netlink. It needs
+// CAP_NET_ADMIN but no BPF support, so it is the fallback when the probes cannot
+// load. Notifications carry pids only; everything else is read from /proc and
+// turned into the events the probes would have sent.
+type procConnector struct {
+	f     *os.File
+	scope atomic.Pointer[connScope]
+	lost  uint64 // notifications the kernel dropped (socket buffer full)
+}
+
+// connScope is the monitoring scope, applied in userspace (see in_scope in guardian.c)
+type connScope struct {
+	name   string
+	hostNs uint32
+}
+
+// openProcConnector subscribes to process events and waits for the kernel to accept
+func openProcConnector() (*procConnector, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC|syscall.SOCK_NONBLOCK, syscall.NETLINK_CONNECTOR)
+	if err != nil {
+		return nil, fmt.Errorf("netlink socket: %v", err)
+	}
+	_ = syscall.SetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_RCVBUFFORCE, procConnRecvBuf)
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: cnIdxProc}); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("bind proc connector: %v", err)
+	}
+	if err := syscall.Sendto(fd, procConnMessage(procCnMcastListen), 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("subscribe to process events: %v", err)
+	}
+
+	c := &procConnector{f: os.NewFile(uintptr(fd), "proc_connector")}
+	c.scope.Store(&connScope{name: ScopeManaged})
+	if err := c.awaitAck(); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// procConnMessage is a PROC_CN_MCAST_* control message
+func procConnMessage(op uint32) []byte {
+	b := make([]byte, syscall.NLMSG_HDRLEN+cnMsgSize+4)
+	ne := binary.NativeEndian
+	ne.PutUint32(b[0:], uint32(len(b)))
+	ne.PutUint16(b[4:], syscall.NLMSG_DONE)
+	ne.PutUint32(b[12:], uint32(os.Getpid()))
+	cn := b[syscall.NLMSG_HDRLEN:]
+	ne.PutUint32(cn[0:], cnIdxProc)
+	ne.PutUint32(cn[4:], cnValProc)
+	ne.PutUint16(cn[16:], 4)
+	ne.PutUint32(cn[cnMsgSize:], op)
+	return b
+}
+
+// awaitAck reads until the subscription is confirmed; without CAP_NET_ADMIN the
+// kernel answers with EPERM instead of sending events
+func (c *procConnector) awaitAck() error {
+	c.f.SetReadDeadline(time.Now().Add(procConnAckTimeout))
+	defer c.f.SetReadDeadline(time.Time{})
+	buf := make([]byte, os.Getpagesize())
+	for {
+		n, err := c.f.Read(buf)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return fmt.Errorf("proc connector did not confirm the subscription")
+		} else if err != nil {
+			return err
+		}
+		for _, ev := range procEvents(buf[:n]) {
+			if binary.NativeEndian.Uint32(ev) != procEventNone || len(ev) < procEventHeader+8 {
+				continue
+			}
+			if errno := binary.NativeEndian.Uint32(ev[procEventHeader+4:]); errno != 0 {
+				return fmt.Errorf("subscribe to process events: %v", syscall.Errno(errno))
+			}
+			return nil
+		}
+	}
+}
+
+// setScope changes which execs are reported; process table events are not scoped
+func (c *procConnector) setScope(scope string) error {
+	s := &connScope{name: scope}
+	if scope == ScopeHost {
+		ns, err := hostMntNs()
+		if err != nil {
+			return fmt.Errorf("host mount namespace: %v", err)
+		}
+		s.hostNs = ns
+	}
+	c.scope.Store(s)
+	return nil
+}
+
+// inScope mirrors in_scope in guardian.c
+func (c *procConnector) inScope(p proctree.Process, mntNs uint32) bool {
+	s := c.scope.Load()
+	if s.name == ScopeAll {
+		return true
+	}
+	if info, ok := orchestrator.ContainerByCgroup(p.CgroupID); ok && info.Managed {
+		return true
+	}
+	return s.name == ScopeHost && mntNs == s.hostNs
+}
+
+// unsubscribe stops the notifications; the ones already queued can still be read
+func (c *procConnector) unsubscribe() {
+	raw, err := c.f.SyscallConn()
+	if err != nil {
+		return
+	}
+	raw.Control(func(fd uintptr) {
+		_ = syscall.Sendto(int(fd), procConnMessage(procCnMcastIgnore), 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK})
+	})
+}
+
+// SetReadDeadline makes read return os.ErrDeadlineExceeded once nothing is pending
+func (c *procConnector) SetReadDeadline(t time.Time) {
+	c.f.SetReadDeadline(t)
+}
+
+func (c *procConnector) Close() error {
+	return c.f.Close()
+}
+
+// read waits for the next notifications and returns them as probe events
+func (c *procConnector) read(buf []byte) ([]interface{}, error) {
+	n, err := c.f.Read(buf)
+	if errors.Is(err, syscall.ENOBUFS) {
+		// The kernel dropped notifications; detections around now may lack context
+		c.lost++
+		telemetry.SetKernelDrops(map[string]uint64{"proc_connector": c.lost}, time.Now())
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var out []interface{}
+	for _, ev := range procEvents(buf[:n]) {
+		what, data := binary.NativeEndian.Uint32(ev), ev[procEventHeader:]
+		switch {
+		case what == procEventFork && len(data) >= 16:
+			out = append(out, c.fork(data)...)
+		case what == procEventExec && len(data) >= 8:
+			out = append(out, c.exec(data)...)
+		case what == procEventExit && len(data) >= 8:
+			out = append(out, c.exit(data)...)
+		}
+	}
+	return out, nil
+}
+
+// procEvents splits a datagram into the struct proc_event of each message
+func procEvents(b []byte) [][]byte {
+	msgs, err := syscall.ParseNetlinkMessage(b)
+	if err != nil {
+		return nil
+	}
+	var out [][]byte
+	for _, m := range msgs {
+		d := m.Data
+		if len(d) < cnMsgSize+procEventHeader {
+			continue
+		}
+		ne := binary.NativeEndian
+		if ne.Uint32(d[0:]) != cnIdxProc || ne.Uint32(d[4:]) != cnValProc {
+			continue
+		}
+		out = append(out, d[cnMsgSize:])
+	}
+	return out
+}
+
+// fork: parent_pid, parent_tgid, child_pid, child_tgid. New threads are skipped.
+func (c *procConnector) fork(data []byte) []interface{} {
+	ne := binary.NativeEndian
+	parent, childPid, child := ne.Uint32(data[4:]), ne.Uint32(data[8:]), ne.Uint32(data[12:])
+	if childPid != child {
+		return nil
+	}
+	p, ok := proctree.Read(child)
+	if !ok {
+		telemetry.CountFiltered("exited_before_read")
+		return nil
+	}
+	telemetry.CountEvent(eventNames[eventFork])
+	return []interface{}{ProcessEvent{
+		Kind: eventFork, Pid: parent, CgroupID: p.CgroupID, Comm: commBytes(p.Comm),
+		ChildPid: child, StartTime: p.StartNs(),
+	}}
+}
+
+// exec: process_pid, process_tgid. The exec is complete by now, so /proc shows the
+// new image; an exec of a script shows its interpreter.
+func (c *procConnector) exec(data []byte) []interface{} {
+	pid := binary.NativeEndian.Uint32(data[4:])
+	p, ok := proctree.Read(pid)
+	if !ok || p.Exe == "" {
+		telemetry.CountFiltered("exited_before_read")
+		return nil
+	}
+	filename, _ := strings.CutSuffix(p.Exe, " (deleted)")
+	comm := commBytes(p.Comm)
+
+	telemetry.CountEvent(eventNames[eventSchExec])
+	out := []interface{}{ProcessEvent{
+		Kind: eventSchExec, Pid: pid, Ppid: p.Ppid, CgroupID: p.CgroupID, Comm: comm,
+		StartTime: p.StartNs(), Filename: filename,
+	}}
+
+	mntNs, _ := mntNsOf(pid)
+	if !c.inScope(p, mntNs) {
+		return out
+	}
+	uid, ok := uidOf(pid)
+	if !ok {
+		telemetry.CountFiltered("exited_before_read")
+		return out
+	}
+	ev := Event{
+		Pid: pid, Ppid: p.Ppid, Uid: uid, MntNs: mntNs, CgroupID: p.CgroupID, Comm: comm,
+		Filename: filename,
+	}
+	if cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid)); err == nil {
+		ev.Argv = splitCStrings(cmdline)
+	}
+	ev.Cwd, _ = os.Readlink(fmt.Sprintf("/proc/%d/cwd", pid))
+	telemetry.CountEvent(eventNames[eventExec])
+	out = append(out, ev)
+
+	if flags := filelessFlags(pid, p.Exe); flags != 0 {
+		telemetry.CountEvent(eventNames[eventFileless])
+		out = append(out, FilelessEvent{
+			Pid: pid, Ppid: p.Ppid, Uid: ev.Uid, MntNs: mntNs, CgroupID: p.CgroupID, Comm: comm,
+			Filename: filename, Name: path.Base(filename), Flags: flags,
+		})
+	}
+	return out
+}
+
+// exit: process_pid, process_tgid, ... Only the group leader counts, as in
+// trace_sched_exit; the start time comes from the table (or the zombie's /proc entry).
+func (c *procConnector) exit(data []byte) []interface{} {
+	ne := binary.NativeEndian
+	pid, tgid := ne.Uint32(data[0:]), ne.Uint32(data[4:])
+	if pid != tgid {
+		return nil
+	}
+	p, ok := proctree.Lookup(pid)
+	if !ok {
+		return nil
+	}
+	telemetry.CountEvent(eventNames[eventExit])
+	return []interface{}{ProcessEvent{Kind: eventExit, Pid: pid, CgroupID: p.CgroupID, Comm: commBytes(p.Comm), StartTime: p.StartNs()}}
+}
+
+// filelessFlags derives the FILELESS_* flags of emit_fileless from /proc: memfd and
+// unlinked images are marked " (deleted)" in the exe link
+func filelessFlags(pid uint32, exe string) uint32 {
+	var flags uint32
+	file, deleted := strings.CutSuffix(exe, " (deleted)")
+	if strings.HasPrefix(path.Base(file), "memfd:") {
+		flags |= filelessMemfd
+	}
+	if deleted {
+		flags |= filelessUnlinked
+	}
+	var st syscall.Statfs_t
+	if syscall.Statfs(fmt.Sprintf("/proc/%d/exe", pid), &st) == nil && st.Type == tmpfsMagic {
+		flags |= filelessTmpfs
+	}
+	for _, dir := range []string{"/tmp/", "/var/tmp/", "/dev/shm/"} {
+		if strings.HasPrefix(file, dir) {
+			flags |= filelessTmpdir
+		}
+	}
+	return flags
+}
+
+// uidOf is the real uid of a process (the uid the probes report)
+func uidOf(pid uint32) (uint32, bool) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return 0, false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if v, ok := strings.CutPrefix(line, "Uid:"); ok {
+			if f := strings.Fields(v); len(f) > 0 {
+				uid, err := strconv.ParseUint(f[0], 10, 32)
+				return uint32(uid), err == nil
+			}
+		}
+	}
+	return 0, false
+}
+
+func commBytes(s string) [16]byte {
+	var b [16]byte
+	copy(b[:], s)
+	return b
+}
@@ -0,0 +1,185 @@
+package security
+
+import (
+	"encoding/binary"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// procMessage builds one netlink message carrying a struct proc_event
+func procMessage(idx, what uint32, data []byte) []byte {
+	ne := binary.NativeEndian
+	ev := make([]byte, procEventHeader+len(data))
+	ne.PutUint32(ev[0:], what)
+	copy(ev[procEventHeader:], data)
+
+	n := syscall.NLMSG_HDRLEN + cnMsgSize + len(ev)
+	b := make([]byte, (n+syscall.NLMSG_ALIGNTO-1) & ^(syscall.NLMSG_ALIGNTO-1))
+	ne.PutUint32(b[0:], uint32(n))
+	ne.PutUint16(b[4:], syscall.NLMSG_DONE)
+	cn := b[syscall.NLMSG_HDRLEN:]
+	ne.PutUint32(cn[0:], idx)
+	ne.PutUint32(cn[4:], cnValProc)
+	ne.PutUint16(cn[16:], uint16(len(ev)))
+	copy(cn[cnMsgSize:], ev)
+	return b
+}
+
+func pids(v ...uint32) []byte {
+	b := make([]byte, 4*len(v))
+	for i, p := range v {
+		binary.NativeEndian.PutUint32(b[4*i:], p)
+	}
+	return b
+}
+
+func TestProcConnMessage(t *testing.T) {
+	b := procConnMessage(procCnMcastListen)
+	msgs, err := syscall.ParseNetlinkMessage(b)
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("not one netlink message: %v", err)
+	}
+	ne := binary.NativeEndian
+	d := msgs[0].Data
+	if msgs[0].Header.Type != syscall.NLMSG_DONE || ne.Uint32(d[0:]) != cnIdxProc || ne.Uint32(d[4:]) != cnValProc {
+		t.Errorf("header: type %d, cn id %d/%d", msgs[0].Header.Type, ne.Uint32(d[0:]), ne.Uint32(d[4:]))
+	}
+	if ne.Uint16(d[16:]) != 4 || ne.Uint32(d[cnMsgSize:]) != procCnMcastListen {
+		t.Errorf("payload: len %d, op %d", ne.Uint16(d[16:]), ne.Uint32(d[cnMsgSize:]))
+	}
+}
+
+func TestProcEvents(t *testing.T) {
+	var dgram []byte
+	dgram = append(dgram, procMessage(cnIdxProc, procEventFork, pids(10, 10, 11, 11))...)
+	dgram = append(dgram, procMessage(cnIdxProc+1, procEventExec, pids(12, 12))...) // another connector
+	dgram = append(dgram, procMessage(cnIdxProc, procEventExec, pids(11, 11))...)
+	dgram = append(dgram, procMessage(cnIdxProc, procEventExit, pids(11, 11, 0, 0))...)
+
+	var kinds []uint32
+	for _, ev := range procEvents(dgram) {
+		kinds = append(kinds, binary.NativeEndian.Uint32(ev))
+	}
+	if want := []uint32{procEventFork, procEventExec, procEventExit}; !slices.Equal(kinds, want) {
+		t.Errorf("events %x, want %x", kinds, want)
+	}
+	if got := procEvents(dgram[:10]); got != nil {
+		t.Errorf("truncated datagram: %d events", len(got))
+	}
+}
+
+// Notifications for a real child are turned into the events the probes send
+func TestProcConnectorEvents(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("no sleep binary")
+	}
+	cmd := exec.Command(sleep, "10")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+	child, self := uint32(cmd.Process.Pid), uint32(os.Getpid())
+	exe, _ := filepath.EvalSymlinks(sleep) // what /proc/<pid>/exe shows
+
+	c := &procConnector{}
+	c.scope.Store(&connScope{name: ScopeAll})
+
+	out := c.fork(pids(self, self, child, child))
+	if len(out) != 1 {
+		t.Fatalf("fork: %d events", len(out))
+	}
+	if f := out[0].(ProcessEvent); f.Kind != eventFork || f.Pid != self || f.ChildPid != child || f.StartTime == 0 {
+		t.Errorf("fork = %+v", f)
+	}
+	if out := c.fork(pids(self, self, child+1, child)); out != nil {
+		t.Errorf("new thread reported as a fork: %+v", out)
+	}
+
+	out = c.exec(pids(child, child))
+	if len(out) != 2 {
+		t.Fatalf("exec: %d events, want the process table event and the exec", len(out))
+	}
+	if p := out[0].(ProcessEvent); p.Kind != eventSchExec || p.Filename != exe || p.Ppid != self {
+		t.Errorf("sched exec = %+v", p)
+	}
+	ev := out[1].(Event)
+	if ev.Pid != child || ev.Filename != exe || !slices.Equal(ev.Argv, []string{sleep, "10"}) || ev.Uid != uint32(os.Getuid()) || commOf(ev.Comm) != "sleep" {
+		t.Errorf("exec = %+v", ev)
+	}
+
+	c.scope.Store(&connScope{name: ScopeManaged})
+	if out := c.exec(pids(child, child)); len(out) != 1 {
+		t.Errorf("out of scope: %d events, want the process table event only", len(out))
+	}
+	if out := c.exit(pids(child+1, child)); out != nil {
+		t.Errorf("thread exit reported: %+v", out)
+	}
+}
+
+func TestFilelessFlags(t *testing.T) {
+	const gone = 1<<31 - 1 // no /proc entry: the tmpfs check is skipped
+	tests := []struct {
+		exe  string
+		want uint32
+	}{
+		{"/usr/bin/ls", 0},
+		{"/memfd:payload (deleted)", filelessMemfd | filelessUnlinked},
+		{"/usr/local/bin/agent (deleted)", filelessUnlinked},
+		{"/tmp/x", filelessTmpdir},
+		{"/dev/shm/.x (deleted)", filelessTmpdir | filelessUnlinked},
+		{"/var/tmpfoo/x", 0},
+	}
+	for _, tt := range tests {
+		if got := filelessFlags(gone, tt.exe); got != tt.want {
+			t.Errorf("filelessFlags(%q) = %#x, want %#x", tt.exe, got, tt.want)
+		}
+	}
+}
+
+// End to end over netlink; needs CAP_NET_ADMIN
+func TestProcConnectorSubscribe(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("no sleep binary")
+	}
+	c, err := openProcConnector()
+	if err != nil {
+		t.Skipf("proc connector unavailable: %v", err)
+	}
+	defer c.Close()
+	c.setScope(ScopeAll)
+
+	cmd := exec.Command(sleep, "10")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+	pid := uint32(cmd.Process.Pid)
+	exe, _ := filepath.EvalSymlinks(sleep)
+
+	c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, os.Getpagesize())
+	for {
+		events, err := c.read(buf)
+		if err != nil {
+			t.Fatalf("no exec of %d seen: %v", pid, err)
+		}
+		for _, ev := range events {
+			if e, ok := ev.(Event); ok && e.Pid == pid && e.Filename == exe {
+				return
+			}
+		}
+	}
+}
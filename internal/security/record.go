@@ -0,0 +1,281 @@
+package security
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/telemetry"
+)
+
+// A recording is gzip-compressed JSON lines: one recordHeader, then one record per
+// event with the context it was judged against. Process table events are not
+// recorded; every record already carries its lineage.
+
+// recordVersion is bumped whenever a recording can no longer be replayed as is
+const recordVersion = 1
+
+// recordFlushInterval bounds how much of a recording is lost if the engine dies
+const recordFlushInterval = time.Second
+
+type recordHeader struct {
+	Version  int       `json:"version"`
+	Started  time.Time `json:"started"`
+	SelfPid  uint32    `json:"self_pid"`
+	Blocking bool      `json:"blocking"`
+}
+
+type record struct {
+	At    time.Time       `json:"at"`
+	Kind  string          `json:"kind"`
+	Event json.RawMessage `json:"event"`
+	Ctx   *eventContext   `json:"ctx"`
+}
+
+// recorder appends events to a recording
+type recorder struct {
+	mu  sync.Mutex
+	f   *os.File
+	gz  *gzip.Writer
+	enc *json.Encoder
+	err error
+}
+
+func newRecorder(path string, hdr recordHeader) (*recorder, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(f)
+	r := &recorder{f: f, gz: gz, enc: json.NewEncoder(gz)}
+	if err := r.enc.Encode(hdr); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *recorder) write(at time.Time, decoded interface{}, ctx *eventContext) {
+	if _, ok := decoded.(ProcessEvent); ok {
+		return
+	}
+	raw, err := json.Marshal(decoded)
+	if err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	if r.err = r.enc.Encode(record{At: at, Kind: kindOf(decoded), Event: raw, Ctx: ctx}); r.err != nil {
+		log.Printf("[WARN] Event recording stopped: %v", r.err)
+	}
+}
+
+// flushLoop pushes buffered records to disk until done is closed
+func (r *recorder) flushLoop(done <-chan struct{}) {
+	tick := time.NewTicker(recordFlushInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-tick.C:
+			r.mu.Lock()
+			if r.err == nil {
+				r.err = r.gz.Flush()
+			}
+			r.mu.Unlock()
+		}
+	}
+}
+
+func (r *recorder) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.gz.Close()
+	r.f.Close()
+	r.err = os.ErrClosed
+}
+
+// kindOf names the record type of a decoded event
+func kindOf(decoded interface{}) string {
+	switch ev := decoded.(type) {
+	case Event:
+		return eventNames[eventExec]
+	case ConnectEvent:
+		return eventNames[eventConnect]
+	case OpenEvent:
+		return eventNames[eventOpen]
+	case CredEvent:
+		return eventNames[eventCred]
+	case BlockedExecEvent:
+		return eventNames[eventBlocked]
+	case FilelessEvent:
+		return eventNames[eventFileless]
+	case ProcessEvent:
+		return eventNames[uint32(ev.Kind)]
+	}
+	return ""
+}
+
+// decodeRecorded turns a recorded event back into the type decodeRecord produces
+func decodeRecorded(kind string, raw json.RawMessage) (interface{}, error) {
+	var err error
+	switch kind {
+	case eventNames[eventExec]:
+		var ev Event
+		err = json.Unmarshal(raw, &ev)
+		return ev, err
+	case eventNames[eventConnect]:
+		var ev ConnectEvent
+		err = json.Unmarshal(raw, &ev)
+		return ev, err
+	case eventNames[eventOpen]:
+		var ev OpenEvent
+		err = json.Unmarshal(raw, &ev)
+		return ev, err
+	case eventNames[eventCred]:
+		var ev CredEvent
+		err = json.Unmarshal(raw, &ev)
+		return ev, err
+	case eventNames[eventBlocked]:
+		var ev BlockedExecEvent
+		err = json.Unmarshal(raw, &ev)
+		return ev, err
+	case eventNames[eventFileless]:
+		var ev FilelessEvent
+		err = json.Unmarshal(raw, &ev)
+		return ev, err
+	}
+	return nil, fmt.Errorf("unknown event kind '%s'", kind)
+}
+
+// dryRunResponder stands in for the defender during replay
+type dryRunResponder struct {
+	kills int
+}
+
+func (d *dryRunResponder) Kill(pid int, comm string) error {
+	d.kills++
+	fmt.Printf("   └─ [REPLAY] would kill PID %d (%s)\n", pid, comm)
+	return nil
+}
+
+func (d *dryRunResponder) Preserve(s *sample) (string, error) {
+	fmt.Printf("   └─ [REPLAY] would preserve sample %s (%d bytes)\n", s.SHA256, s.Size)
+	return "", nil
+}
+
+// ReplaySummary is what a replay run went through
+type ReplaySummary struct {
+	Events    map[string]int
+	Filtered  map[string]uint64
+	Kills     int
+	Skipped   int  // records that could not be decoded
+	Truncated bool // the recording ends mid-record (engine killed while recording)
+}
+
+// Replay feeds a recording through the same pipeline as the live monitor, with
+// responses stubbed out. Alerts are printed; nothing is written unless the caller
+// initialised the guardian's database.
+func Replay(path string) (ReplaySummary, error) {
+	sum := ReplaySummary{Events: map[string]int{}}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return sum, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return sum, fmt.Errorf("%s is not a recording: %v", path, err)
+	}
+	defer gz.Close()
+
+	sc := bufio.NewScanner(gz)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	if !sc.Scan() {
+		return sum, fmt.Errorf("%s: empty recording", path)
+	}
+	var hdr recordHeader
+	if err := json.Unmarshal(sc.Bytes(), &hdr); err != nil {
+		return sum, fmt.Errorf("%s: bad header: %v", path, err)
+	}
+	if hdr.Version != recordVersion {
+		return sum, fmt.Errorf("%s: recording version %d, this engine replays version %d", path, hdr.Version, recordVersion)
+	}
+
+	dry := &dryRunResponder{}
+	p := &pipeline{selfPid: hdr.SelfPid, blocking: hdr.Blocking, respond: dry}
+	for sc.Scan() {
+		var rec record
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil || rec.Ctx == nil {
+			sum.Skipped++
+			continue
+		}
+		decoded, err := decodeRecorded(rec.Kind, rec.Event)
+		if err != nil {
+			sum.Skipped++
+			continue
+		}
+		sum.Events[rec.Kind]++
+		telemetry.CountEvent(rec.Kind)
+		p.handle(decoded, rec.Ctx, rec.At)
+	}
+	if err := sc.Err(); err != nil {
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			return sum, err
+		}
+		sum.Truncated = true
+	}
+
+	sum.Kills = dry.kills
+	sum.Filtered = telemetry.Snapshot().EventsFiltered
+	return sum, nil
+}
+
+// String renders the summary for the terminal
+func (s ReplaySummary) String() string {
+	total := 0
+	kinds := make([]string, 0, len(s.Events))
+	for k, n := range s.Events {
+		total += n
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+
+	out := fmt.Sprintf("Replayed %d events", total)
+	for _, k := range kinds {
+		out += fmt.Sprintf("\n  %-12s %d", k, s.Events[k])
+	}
+	if len(s.Filtered) > 0 {
+		reasons := make([]string, 0, len(s.Filtered))
+		for r := range s.Filtered {
+			reasons = append(reasons, r)
+		}
+		sort.Strings(reasons)
+		out += "\nFiltered:"
+		for _, r := range reasons {
+			out += fmt.Sprintf("\n  %-22s %d", r, s.Filtered[r])
+		}
+	}
+	out += fmt.Sprintf("\nResponses stubbed: %d kill(s)", s.Kills)
+	if s.Skipped > 0 {
+		out += fmt.Sprintf("\nSkipped %d undecodable record(s)", s.Skipped)
+	}
+	if s.Truncated {
+		out += "\nRecording ends mid-record (engine stopped without closing it)"
+	}
+	return out
+}
@@ -0,0 +1,110 @@
+package security
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordTo writes a recording of the events to a temporary file; with closed
+// false the engine is "killed" after the last flush, leaving no gzip trailer
+func recordTo(t *testing.T, hdr recordHeader, closed bool, events ...interface{}) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "events.jsonl.gz")
+	r, err := newRecorder(path, hdr)
+	if err != nil {
+		t.Fatalf("newRecorder: %v", err)
+	}
+	ctx := containerCtx("replay-web-1", "replay-web", "medium")
+	ctx.Policy.DenyExec = []string{"nc"}
+	at := time.Now()
+	for _, ev := range events {
+		r.write(at, ev, ctx)
+	}
+	if closed {
+		r.close()
+		return path
+	}
+	r.gz.Flush()
+	r.f.Close()
+	return path
+}
+
+func TestRecordReplay(t *testing.T) {
+	path := recordTo(t, recordHeader{Version: recordVersion, SelfPid: 1}, true,
+		Event{Pid: 900, Ppid: 899, Comm: comm16("nc"), Filename: "/usr/bin/nc", Argv: []string{"nc", "-e", "/bin/sh"}},
+		ConnectEvent{Pid: 901, Comm: comm16("curl"), Protocol: "tcp", DestIP: netip.MustParseAddr("10.0.0.9"), DestPort: 443},
+		OpenEvent{Pid: 902, Comm: comm16("cat"), Path: "/etc/shadow"},
+		// Process table events are not recorded: every record carries its lineage
+		ProcessEvent{Kind: eventFork, Pid: 899, ChildPid: 900},
+	)
+
+	sum, err := Replay(path)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if sum.Events["exec"] != 1 || sum.Events["connect"] != 1 || sum.Events["open"] != 1 || len(sum.Events) != 3 {
+		t.Errorf("Events = %v", sum.Events)
+	}
+	// The recorded deny_exec policy is judged again; the kill is only stubbed
+	if sum.Kills != 1 || sum.Skipped != 0 || sum.Truncated {
+		t.Errorf("summary = %+v", sum)
+	}
+	if s := sum.String(); !strings.Contains(s, "Replayed 3 events") || !strings.Contains(s, "1 kill(s)") {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestReplayBlockingRecording(t *testing.T) {
+	// Recorded on a BPF LSM kernel: the exec never ran, so replay kills nothing
+	path := recordTo(t, recordHeader{Version: recordVersion, SelfPid: 1, Blocking: true}, true,
+		Event{Pid: 910, Ppid: 909, Comm: comm16("nc"), Filename: "/usr/bin/nc"},
+	)
+	sum, err := Replay(path)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if sum.Kills != 0 || sum.Filtered["deny_exec"] == 0 {
+		t.Errorf("summary = %+v", sum)
+	}
+}
+
+func TestReplayErrors(t *testing.T) {
+	path := recordTo(t, recordHeader{Version: recordVersion + 1}, true)
+	if _, err := Replay(path); err == nil || !strings.Contains(err.Error(), "version") {
+		t.Errorf("newer recording: err = %v", err)
+	}
+
+	plain := filepath.Join(t.TempDir(), "plain.jsonl")
+	os.WriteFile(plain, []byte(`{"version":1}`+"\n"), 0600)
+	if _, err := Replay(plain); err == nil || !strings.Contains(err.Error(), "not a recording") {
+		t.Errorf("uncompressed file: err = %v", err)
+	}
+
+	if _, err := Replay(filepath.Join(t.TempDir(), "missing.gz")); err == nil {
+		t.Error("missing file: want an error")
+	}
+}
+
+func TestReplayTruncated(t *testing.T) {
+	path := recordTo(t, recordHeader{Version: recordVersion, SelfPid: 1}, false,
+		OpenEvent{Pid: 920, Comm: comm16("cat"), Path: "/etc/passwd"},
+		OpenEvent{Pid: 921, Comm: comm16("cat"), Path: "/etc/group"},
+	)
+	sum, err := Replay(path)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if !sum.Truncated || sum.Events["open"] != 2 {
+		t.Errorf("summary = %+v", sum)
+	}
+}
+
+func TestDecodeRecordedUnknownKind(t *testing.T) {
+	if _, err := decodeRecorded("fork", []byte(`{}`)); err == nil {
+		t.Error("process table kind decoded")
+	}
+}
@@ -0,0 +1,98 @@
+package security
+
+import (
+	"log"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/rules"
+	"github.com/Debasish-87/aegis-v/internal/telemetry"
+
+	"github.com/cilium/ebpf"
+)
+
+// commKey mirrors struct comm_key in guardian.c (LPM trie over the task comm)
+type commKey struct {
+	PrefixLen uint32
+	Comm      [16]byte
+}
+
+// syncExecIgnore keeps the kernel exec prefilter in step with the ignore rules
+// of the active rule set (see rules.Set.CommPrefilter), until done is closed
+func syncExecIgnore(m *ebpf.Map, done <-chan struct{}) {
+	installed := map[commKey]bool{}
+	var applied *rules.Set
+	tick := time.NewTicker(rules.ReloadInterval)
+	defer tick.Stop()
+
+	for {
+		if set := rules.Current(); set != applied {
+			applied = set
+			want := map[commKey]bool{}
+			for _, pat := range set.CommPrefilter() {
+				if len(pat) > len(commKey{}.Comm) {
+					continue // longer than any comm; the rule still applies in userspace
+				}
+				k := commKey{PrefixLen: uint32(len(pat) * 8)}
+				copy(k.Comm[:], pat)
+				want[k] = true
+			}
+
+			for k := range want {
+				if installed[k] {
+					continue
+				}
+				if err := m.Put(k, uint32(1)); err != nil {
+					log.Printf("[WARN] Exec prefilter entry '%s' not installed: %v", commOf(k.Comm), err)
+					continue
+				}
+				installed[k] = true
+			}
+			for k := range installed {
+				if !want[k] {
+					_ = m.Delete(k)
+					delete(installed, k)
+				}
+			}
+		}
+
+		select {
+		case <-done:
+			return
+		case <-tick.C:
+		}
+	}
+}
+
+// ruleInput is the part of the rule input common to every event type
+func ruleInput(kind, comm string, uid uint32, ctx *eventContext) *rules.Input {
+	in := &rules.Input{Event: kind, Comm: comm, Uid: uid, TTY: ctx.TTY, Trust: ctx.Trust}
+	if ctx.InCache {
+		in.Container, in.Service, in.Image = ctx.Container.Name, ctx.Container.Service, ctx.Container.Image
+	} else if ctx.NsName != "" {
+		in.Container, in.Service = ctx.NsName, ctx.NsName
+	}
+	if ctx.Process != nil {
+		in.Exe = ctx.Process.Exe
+	}
+	for _, a := range ctx.Ancestors {
+		in.Ancestors = append(in.Ancestors, rules.Ancestor{Comm: a.Comm, Exe: a.Exe, Trust: a.Trust, Roles: a.Roles})
+	}
+	return in
+}
+
+// execImageInput describes the program an exec starts: the process table may still
+// show the caller, read at sys_enter_execve. The input's comm (and so its class)
+// must already be the name the new image runs under.
+func execImageInput(in *rules.Input, filename string, ctx *eventContext) {
+	in.Exe = filename
+	in.Spawn = spawnVerdict(ctx.Policy, filename, ctx.Ancestors)
+}
+
+// ignored reports (and counts) an event dropped by an ignore rule
+func ignored(rule *rules.Rule) bool {
+	if rule == nil || rule.Action != rules.ActionIgnore {
+		return false
+	}
+	telemetry.CountFiltered(rule.ID)
+	return true
+}
@@ -0,0 +1,64 @@
+package security
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/platform"
+	"github.com/Debasish-87/aegis-v/internal/telemetry"
+)
+
+const (
+	// policyCacheTTL bounds how long a redeployed policy takes to apply at runtime
+	policyCacheTTL = 30 * time.Second
+	// reportWindow suppresses repeats of the same runtime detection (egress, file access, ...)
+	reportWindow = time.Minute
+)
+
+type policyEntry struct {
+	policy platform.RuntimePolicy
+	loaded time.Time
+}
+
+var (
+	runtimeMu sync.Mutex
+	policies  = map[string]policyEntry{}
+	reported  = map[string]time.Time{}
+)
+
+// runtimePolicy returns the cached runtime policy of a service
+func runtimePolicy(service string) platform.RuntimePolicy {
+	runtimeMu.Lock()
+	defer runtimeMu.Unlock()
+
+	if e, ok := policies[service]; ok && time.Since(e.loaded) < policyCacheTTL {
+		return e.policy
+	}
+	p, err := platform.LoadRuntimePolicy(service)
+	if err != nil {
+		fmt.Printf("[GUARDIAN] Could not load runtime policy of %s: %v\n", service, err)
+	}
+	policies[service] = policyEntry{policy: p, loaded: time.Now()}
+	return p
+}
+
+// shouldReport rate-limits identical runtime detections
+func shouldReport(key string, now time.Time) bool {
+	runtimeMu.Lock()
+	defer runtimeMu.Unlock()
+
+	if last, ok := reported[key]; ok && now.Sub(last) < reportWindow {
+		telemetry.CountFiltered("rate_limited")
+		return false
+	}
+	if len(reported) > 4096 {
+		for k, t := range reported {
+			if now.Sub(t) >= reportWindow {
+				delete(reported, k)
+			}
+		}
+	}
+	reported[key] = now
+	return true
+}
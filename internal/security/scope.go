@@ -0,0 +1,141 @@
+package security
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/orchestrator"
+
+	"github.com/cilium/ebpf"
+)
+
+// Monitoring scopes (AEGIS_MONITOR_SCOPE)
+const (
+	ScopeManaged = "managed" // AEGIS-managed containers only (default)
+	ScopeHost    = "host"    // managed containers plus host processes, under the host rules
+	ScopeAll     = "all"     // every process, unmanaged containers included
+)
+
+// scopeModes mirror SCOPE_* in guardian.c
+var scopeModes = map[string]uint32{ScopeAll: 0, ScopeManaged: 1, ScopeHost: 2}
+
+// scopeResyncInterval backs up the container change notifications
+const scopeResyncInterval = 30 * time.Second
+
+// scopeConfig mirrors struct scope_config in guardian.c
+type scopeConfig struct {
+	Mode      uint32
+	HostMntNs uint32
+}
+
+// cgroupsAvailable is replaced in tests
+var cgroupsAvailable = orchestrator.CgroupsAvailable
+
+// noCgroupScope is the degraded note of a managed or host scope that fell back to all
+const noCgroupScope = "no cgroup v2 hierarchy: managed containers cannot be told apart, every process is monitored (scope all)"
+
+// effectiveScope is the scope this host can apply, and why it differs from the one
+// asked for. The managed and host scopes select containers by cgroup ID: without
+// cgroup v2 nothing fills the scope map and every container event would be dropped.
+func effectiveScope(scope string) (string, string) {
+	if scope == ScopeAll || cgroupsAvailable() {
+		return scope, ""
+	}
+	log.Printf("[WARN] Monitoring scope %s needs cgroup v2; monitoring every process instead", scope)
+	return ScopeAll, noCgroupScope
+}
+
+// monitorScope validates AEGIS_MONITOR_SCOPE; anything unknown falls back to managed
+func monitorScope(s string) string {
+	if _, ok := scopeModes[s]; !ok {
+		log.Printf("[WARN] AEGIS_MONITOR_SCOPE=%q is not one of managed, host, all; using %s", s, ScopeManaged)
+		return ScopeManaged
+	}
+	return s
+}
+
+// loadScope sets the monitoring scope in the kernel; called before any probe attaches
+func loadScope(m *ebpf.Map, scope string) error {
+	cfg := scopeConfig{Mode: scopeModes[scope]}
+	if scope == ScopeHost {
+		ns, err := hostMntNs()
+		if err != nil {
+			return fmt.Errorf("host mount namespace: %v", err)
+		}
+		cfg.HostMntNs = ns
+	}
+	return m.Put(uint32(0), cfg)
+}
+
+// hostMntNs is the mount namespace of init: whatever runs in it is a host process
+func hostMntNs() (uint32, error) {
+	return mntNsOf(1)
+}
+
+// mntNsOf is the mount namespace inode of a process
+func mntNsOf(pid uint32) (uint32, error) {
+	link, err := os.Readlink(fmt.Sprintf("/proc/%d/ns/mnt", pid))
+	if err != nil {
+		return 0, err
+	}
+	// Format: mnt:[4026531841]
+	inum := strings.TrimSuffix(strings.TrimPrefix(link, "mnt:["), "]")
+	n, err := strconv.ParseUint(inum, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected namespace link %q", link)
+	}
+	return uint32(n), nil
+}
+
+// syncScope keeps the scope map equal to the cgroups of the running managed
+// containers, until done is closed. It follows the container cache as it changes.
+// filled (if not nil) is closed after the first pass.
+func syncScope(m *ebpf.Map, done <-chan struct{}, filled chan<- struct{}) {
+	installed := map[uint64]bool{}
+	tick := time.NewTicker(scopeResyncInterval)
+	defer tick.Stop()
+
+	for {
+		want := map[uint64]bool{}
+		for info, ids := range orchestrator.LiveContainers() {
+			if !info.Managed {
+				continue
+			}
+			for _, id := range ids {
+				want[id] = true
+			}
+		}
+
+		for id := range want {
+			if installed[id] {
+				continue
+			}
+			if err := m.Put(id, uint32(1)); err != nil {
+				log.Printf("[WARN] Monitoring scope entry for cgroup %d not installed: %v", id, err)
+				continue
+			}
+			installed[id] = true
+		}
+		for id := range installed {
+			if !want[id] {
+				_ = m.Delete(id)
+				delete(installed, id)
+			}
+		}
+		if filled != nil {
+			close(filled)
+			filled = nil
+		}
+
+		select {
+		case <-done:
+			return
+		case <-orchestrator.ContainersChanged():
+		case <-tick.C:
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package security
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/Debasish-87/aegis-v/internal/orchestrator"
+)
+
+func TestMonitorScope(t *testing.T) {
+	for _, s := range []string{ScopeManaged, ScopeHost, ScopeAll} {
+		if got := monitorScope(s); got != s {
+			t.Errorf("monitorScope(%q) = %q", s, got)
+		}
+	}
+	for _, s := range []string{"", "Host", "containers"} {
+		if got := monitorScope(s); got != ScopeManaged {
+			t.Errorf("monitorScope(%q) = %q, want %s", s, got, ScopeManaged)
+		}
+	}
+}
+
+// Without cgroup v2 the managed and host scopes would drop every container event
+func TestEffectiveScope(t *testing.T) {
+	t.Cleanup(func() { cgroupsAvailable = orchestrator.CgroupsAvailable })
+	for _, available := range []bool{true, false} {
+		cgroupsAvailable = func() bool { return available }
+		for _, s := range []string{ScopeManaged, ScopeHost, ScopeAll} {
+			got, note := effectiveScope(s)
+			want := s
+			if !available {
+				want = ScopeAll
+			}
+			if got != want || (note != "") != (want != s) {
+				t.Errorf("cgroups %v: effectiveScope(%s) = %s, %q; want %s", available, s, got, note, want)
+			}
+		}
+	}
+}
+
+// TestScopeModesMirrorKernel keeps scopeModes in step with the SCOPE_* defines
+func TestScopeModesMirrorKernel(t *testing.T) {
+	src, err := os.ReadFile("guardian.c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defines := map[string]string{ScopeAll: "SCOPE_ALL", ScopeManaged: "SCOPE_MANAGED", ScopeHost: "SCOPE_HOST"}
+	for scope, name := range defines {
+		m := regexp.MustCompile(`#define\s+` + name + `\s+(\d+)`).FindSubmatch(src)
+		if m == nil {
+			t.Errorf("%s not defined in guardian.c", name)
+			continue
+		}
+		if n, _ := strconv.Atoi(string(m[1])); uint32(n) != scopeModes[scope] {
+			t.Errorf("scopeModes[%s] = %d, %s = %d", scope, scopeModes[scope], name, n)
+		}
+	}
+}
+
+func TestMntNsOf(t *testing.T) {
+	link, err := os.Readlink("/proc/self/ns/mnt")
+	if err != nil {
+		t.Skipf("no mount namespace link: %v", err)
+	}
+	ns, err := mntNsOf(uint32(os.Getpid()))
+	if err != nil {
+		t.Fatalf("mntNsOf(self): %v", err)
+	}
+	if want := fmt.Sprintf("mnt:[%d]", ns); link != want {
+		t.Errorf("mntNsOf(self) = %d, link %s", ns, link)
+	}
+	if _, err := mntNsOf(0); err == nil {
+		t.Error("mntNsOf(0): want an error")
+	}
+}
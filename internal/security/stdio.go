@@ -0,0 +1,126 @@
+package security
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// stdioSocket is a network socket on the stdin, stdout or stderr of a process:
+// a shell with one is driven from the network (reverse or bind shell)
+type stdioSocket struct {
+	FDs        []int  `json:"fds"`      // which of 0, 1, 2
+	Protocol   string `json:"protocol"` // tcp or udp
+	RemoteIP   string `json:"remote_ip"`
+	RemotePort uint16 `json:"remote_port"`
+	LocalPort  uint16 `json:"local_port"`
+}
+
+// Remote is the peer as host:port
+func (s *stdioSocket) Remote() string {
+	return net.JoinHostPort(s.RemoteIP, strconv.Itoa(int(s.RemotePort)))
+}
+
+// stdioNames label fds 0-2
+var stdioNames = []string{"stdin", "stdout", "stderr"}
+
+// Streams names the fds, e.g. "stdin,stdout"
+func (s *stdioSocket) Streams() string {
+	names := make([]string, 0, len(s.FDs))
+	for _, fd := range s.FDs {
+		names = append(names, stdioNames[fd])
+	}
+	return strings.Join(names, ",")
+}
+
+// stdioSocketOf inspects fds 0-2 of a process. Sockets are resolved through the
+// process's own /proc/<pid>/net tables (its network namespace); unix sockets and
+// pipes are not network sockets and yield nil.
+func stdioSocketOf(pid uint32) *stdioSocket {
+	inodes := map[string][]int{}
+	var order []string
+	for fd := 0; fd <= 2; fd++ {
+		target, err := os.Readlink(fmt.Sprintf("/proc/%d/fd/%d", pid, fd))
+		if err != nil {
+			continue
+		}
+		inode, ok := strings.CutPrefix(target, "socket:[")
+		if !ok {
+			continue
+		}
+		inode = strings.TrimSuffix(inode, "]")
+		if inodes[inode] == nil {
+			order = append(order, inode)
+		}
+		inodes[inode] = append(inodes[inode], fd)
+	}
+	if len(inodes) == 0 {
+		return nil
+	}
+
+	for _, proto := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		for _, inode := range order {
+			if s := findSocket(fmt.Sprintf("/proc/%d/net/%s", pid, proto), inode); s != nil {
+				s.Protocol = strings.TrimSuffix(proto, "6")
+				s.FDs = inodes[inode]
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+// findSocket looks an inode up in a /proc/net/{tcp,udp}[6] table
+func findSocket(table, inode string) *stdioSocket {
+	f, err := os.Open(table)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Scan() // header
+	for sc.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 10 || fields[9] != inode {
+			continue
+		}
+		_, localPort, ok1 := parseProcAddr(fields[1])
+		ip, port, ok2 := parseProcAddr(fields[2])
+		if !ok1 || !ok2 {
+			return nil
+		}
+		return &stdioSocket{RemoteIP: ip.String(), RemotePort: port, LocalPort: localPort}
+	}
+	return nil
+}
+
+// parseProcAddr decodes "0100007F:1F90": the address is in host (little-endian)
+// order per 32-bit word, the port is plain hex
+func parseProcAddr(s string) (net.IP, uint16, bool) {
+	addr, port, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, 0, false
+	}
+	raw, err := hex.DecodeString(addr)
+	if err != nil || (len(raw) != 4 && len(raw) != 16) {
+		return nil, 0, false
+	}
+	p, err := strconv.ParseUint(port, 16, 16)
+	if err != nil {
+		return nil, 0, false
+	}
+	ip := make(net.IP, len(raw))
+	for w := 0; w < len(raw); w += 4 {
+		ip[w], ip[w+1], ip[w+2], ip[w+3] = raw[w+3], raw[w+2], raw[w+1], raw[w]
+	}
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4 // ::ffff:a.b.c.d on a dual-stack socket
+	}
+	return ip, uint16(p), true
+}
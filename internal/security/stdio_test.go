@@ -0,0 +1,121 @@
+package security
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseProcAddr(t *testing.T) {
+	tests := []struct {
+		in     string
+		ip     string
+		port   uint16
+		wantOK bool
+	}{
+		{"0100007F:1F90", "127.0.0.1", 8080, true},
+		{"0A01A8C0:115C", "192.168.1.10", 4444, true},
+		{"00000000:0000", "0.0.0.0", 0, true},
+		{"00000000000000000000000001000000:0016", "::1", 22, true},
+		{"B80D0120000000000000000001000000:01BB", "2001:db8::1", 443, true},
+		{"0000000000000000FFFF00000100007F:0050", "127.0.0.1", 80, true}, // v4-mapped on a dual-stack socket
+		{"0100007F", "", 0, false},
+		{"0100007G:0050", "", 0, false},
+		{"01007F:0050", "", 0, false},
+		{"0100007F:1FFFF", "", 0, false},
+		{"0100007F:", "", 0, false},
+	}
+	for _, tt := range tests {
+		ip, port, ok := parseProcAddr(tt.in)
+		if ok != tt.wantOK {
+			t.Errorf("parseProcAddr(%q) ok = %v, want %v", tt.in, ok, tt.wantOK)
+			continue
+		}
+		if ok && (ip.String() != tt.ip || port != tt.port) {
+			t.Errorf("parseProcAddr(%q) = %s, %d; want %s, %d", tt.in, ip, port, tt.ip, tt.port)
+		}
+	}
+}
+
+func TestFindSocket(t *testing.T) {
+	table := filepath.Join(t.TempDir(), "tcp")
+	data := "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n" +
+		"   0: 00000000:0050 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1111 1 0000000000000000 100 0 0 10 0\n" +
+		"   1: 0200000A:8F2C 0A01A8C0:115C 01 00000000:00000000 00:00000000 00000000  1000        0 2222 1 0000000000000000 20 4 30 10 -1\n" +
+		"   2: 0200000A:8F2D 0A01A8C0:XYZ 01 00000000:00000000 00:00000000 00000000  1000        0 3333 1 0000000000000000 20 4 30 10 -1\n"
+	if err := os.WriteFile(table, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := findSocket(table, "2222")
+	if s == nil {
+		t.Fatal("socket 2222 not found")
+	}
+	if s.RemoteIP != "192.168.1.10" || s.RemotePort != 4444 || s.LocalPort != 36652 {
+		t.Errorf("socket 2222 = %+v, want 192.168.1.10:4444 from local port 36652", s)
+	}
+	for _, inode := range []string{"3333", "4444", "111"} {
+		if s := findSocket(table, inode); s != nil {
+			t.Errorf("inode %s: got %+v, want nil", inode, s)
+		}
+	}
+	if s := findSocket(filepath.Join(t.TempDir(), "missing"), "2222"); s != nil {
+		t.Errorf("missing table: got %+v", s)
+	}
+}
+
+// A child with a TCP connection on stdin and stdout, like a reverse shell
+func TestStdioSocketOf(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("no sleep binary")
+	}
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no loopback: %v", err)
+	}
+	defer ln.Close()
+	conn, err := net.Dial("tcp4", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	f, err := conn.(*net.TCPConn).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	cmd := exec.Command(sleep, "10")
+	cmd.Stdin, cmd.Stdout = f, f
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	s := stdioSocketOf(uint32(cmd.Process.Pid))
+	if s == nil {
+		t.Fatal("no socket found on the child's stdio")
+	}
+	if s.Protocol != "tcp" || !slices.Equal(s.FDs, []int{0, 1}) || s.Remote() != ln.Addr().String() || s.Streams() != "stdin,stdout" {
+		t.Errorf("socket = %+v (%s on %s), want tcp to %s on stdin,stdout", s, s.Remote(), s.Streams(), ln.Addr())
+	}
+
+	cmd2 := exec.Command(sleep, "10")
+	if err := cmd2.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		cmd2.Process.Kill()
+		cmd2.Wait()
+	}()
+	if s := stdioSocketOf(uint32(cmd2.Process.Pid)); s != nil {
+		t.Errorf("child on /dev/null: got %+v, want no socket on stdio", s)
+	}
+}
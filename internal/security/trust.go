@@ -0,0 +1,69 @@
+package security
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/Debasish-87/aegis-v/internal/proctree"
+	"github.com/Debasish-87/aegis-v/internal/telemetry"
+	"github.com/Debasish-87/aegis-v/internal/trust"
+)
+
+// processTrust names the allowlist entry of a live process's executable ("" when
+// untrusted). The identity is resolved first and the pid checked afterwards, so a
+// pid reused in between is never credited with the old process's binary.
+func processTrust(p proctree.Process) string {
+	e := trust.ProcessEntry(p.Pid)
+	if e == nil || !proctree.Running(p) {
+		return ""
+	}
+	return e.Name
+}
+
+// execImage resolves the image an exec loaded: the path /proc/<pid>/exe points to,
+// as seen by the process. ok only when that is the file the exec asked for: the
+// event is read after the fact, and the process may have exec'd again (or exited)
+// since. A file deleted after the exec can only be matched by name (deleted).
+func execImage(pid uint32, filename string) (exe string, deleted, ok bool) {
+	link := fmt.Sprintf("/proc/%d/exe", pid)
+	exe, err := os.Readlink(link)
+	if err != nil {
+		return "", false, false
+	}
+	if exe, deleted = strings.CutSuffix(exe, " (deleted)"); deleted {
+		return exe, true, path.Base(exe) == path.Base(filename)
+	}
+
+	target := fmt.Sprintf("/proc/%d/root%s", pid, filename)
+	if !path.IsAbs(filename) {
+		target = fmt.Sprintf("/proc/%d/cwd/%s", pid, filename)
+	}
+	running, err := os.Stat(link)
+	if err != nil {
+		return "", false, false
+	}
+	executed, err := os.Stat(target)
+	if err != nil || !os.SameFile(running, executed) {
+		return "", false, false
+	}
+	return exe, false, true
+}
+
+// execTrust names the allowlist entry of the image an exec loaded (see execImage).
+// An image that cannot be resolved is untrusted and counted as a skipped check.
+func execTrust(pid uint32, filename string) string {
+	_, deleted, ok := execImage(pid, filename)
+	if !ok {
+		telemetry.CountSkippedCheck("trust")
+		return ""
+	}
+	if deleted {
+		return ""
+	}
+	if e := trust.ProcessEntry(pid); e != nil {
+		return e.Name
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+package telemetry
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// WritePrometheus renders every metric in the Prometheus text exposition format
+func WritePrometheus(w io.Writer) {
+	h := Snapshot()
+
+	up := 0
+	if h.MonitorRunning {
+		up = 1
+	}
+	gauge(w, "aegis_monitor_up", "Whether the eBPF runtime monitor is running.", up)
+	degraded := 0
+	if h.Degraded != "" {
+		degraded = 1
+	}
+	gauge(w, "aegis_monitor_degraded", "Whether the monitor runs on a fallback backend with reduced coverage.", degraded)
+	gauge(w, "aegis_ringbuf_size_bytes", "Configured size of the kernel ring buffer.", h.RingBufferSize)
+	gauge(w, "aegis_ringbuf_backlog_bytes", "Unread bytes in the ring buffer at the last read.", h.RingBufferBacklog)
+
+	labeled(w, "aegis_events_received_total", "Events read from the ring buffer.", "event", h.EventsReceived)
+	labeled(w, "aegis_events_filtered_total", "Events discarded by a filter before reaching a detection.", "reason", h.EventsFiltered)
+	labeled(w, "aegis_checks_skipped_total", "Trust and drift checks that could not run on an exec.", "check", h.ChecksSkipped)
+	labeled(w, "aegis_ringbuf_drops_total", "Events lost in the kernel because the ring buffer was full.", "event", h.KernelDrops)
+
+	counter(w, "aegis_event_decode_errors_total", "Ring buffer records that could not be parsed.", h.DecodeErrors)
+	counter(w, "aegis_ringbuf_read_errors_total", "Failed ring buffer reads.", h.ReadErrors)
+
+	latency.mu.Lock()
+	defer latency.mu.Unlock()
+	fmt.Fprintln(w, "# HELP aegis_event_processing_seconds Time from reading an event to its verdict.")
+	fmt.Fprintln(w, "# TYPE aegis_event_processing_seconds histogram")
+	var cum uint64
+	for i, le := range latencyBuckets {
+		if latency.counts != nil {
+			cum += latency.counts[i]
+		}
+		fmt.Fprintf(w, "aegis_event_processing_seconds_bucket{le=\"%s\"} %d\n", strconv.FormatFloat(le.Seconds(), 'g', -1, 64), cum)
+	}
+	fmt.Fprintf(w, "aegis_event_processing_seconds_bucket{le=\"+Inf\"} %d\n", latency.total)
+	fmt.Fprintf(w, "aegis_event_processing_seconds_sum %s\n", strconv.FormatFloat(latency.sum.Seconds(), 'g', -1, 64))
+	fmt.Fprintf(w, "aegis_event_processing_seconds_count %d\n", latency.total)
+}
+
+func gauge(w io.Writer, name, help string, v interface{}) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %v\n", name, help, name, name, v)
+}
+
+func counter(w io.Writer, name, help string, v uint64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, v)
+}
+
+// labeled writes one counter series per label value, sorted for stable output
+func labeled(w io.Writer, name, help, label string, values map[string]uint64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s{%s=%q} %d\n", name, label, k, values[k])
+	}
+}
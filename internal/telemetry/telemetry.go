@@ -0,0 +1,254 @@
+// Package telemetry counts what happens to events between the kernel and the
+// detections table, so a quiet monitor can be told apart from a blind one.
+package telemetry
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/platform"
+)
+
+// DropWindow is how close to a kernel drop a detection has to be to be flagged
+const DropWindow = 30 * time.Second
+
+// maxDropMarks bounds the remembered drop instants (older ones are outside any window anyway)
+const maxDropMarks = 256
+
+// latencyBuckets are the upper bounds of the processing latency histogram
+var latencyBuckets = []time.Duration{
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+// counterVec is a set of counters keyed by one label
+type counterVec struct {
+	mu sync.Mutex
+	m  map[string]uint64
+}
+
+func (c *counterVec) add(label string, n uint64) {
+	c.mu.Lock()
+	if c.m == nil {
+		c.m = map[string]uint64{}
+	}
+	c.m[label] += n
+	c.mu.Unlock()
+}
+
+func (c *counterVec) snapshot() map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]uint64, len(c.m))
+	for k, v := range c.m {
+		out[k] = v
+	}
+	return out
+}
+
+type histogram struct {
+	mu     sync.Mutex
+	counts []uint64 // one per bucket, plus +Inf
+	sum    time.Duration
+	max    time.Duration
+	total  uint64
+}
+
+func (h *histogram) observe(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.counts == nil {
+		h.counts = make([]uint64, len(latencyBuckets)+1)
+	}
+	i := sort.Search(len(latencyBuckets), func(i int) bool { return d <= latencyBuckets[i] })
+	h.counts[i]++
+	h.sum += d
+	h.total++
+	if d > h.max {
+		h.max = d
+	}
+}
+
+var (
+	received   counterVec // events decoded, by event type
+	filtered   counterVec // events dropped on purpose, by reason
+	skipped    counterVec // enrichment checks that could not run, by check
+	decodeErrs atomic.Uint64
+	readErrs   atomic.Uint64
+	latency    histogram
+
+	running   atomic.Bool
+	ringSize  atomic.Int64
+	backlog   atomic.Int64
+	startedAt atomic.Int64 // unix seconds
+	backend   atomic.Pointer[monitorBackend]
+	kernelMu  sync.Mutex
+	kernel    = map[string]uint64{} // ring buffer reservation failures, by event type
+	dropMarks []time.Time           // when new kernel drops were first seen
+)
+
+// CountEvent records one event read from the ring buffer
+func CountEvent(kind string) { received.add(kind, 1) }
+
+// CountFiltered records an event that was dropped by a filter on purpose
+func CountFiltered(reason string) { filtered.add(reason, 1) }
+
+// CountSkippedCheck records an enrichment check (trust, drift) that could not
+// run on an event, e.g. because the process had exited before it was looked at
+func CountSkippedCheck(check string) { skipped.add(check, 1) }
+
+// CountDecodeError records a ring buffer record that could not be parsed
+func CountDecodeError() { decodeErrs.Add(1) }
+
+// CountReadError records a failed ring buffer read
+func CountReadError() { readErrs.Add(1) }
+
+// ObserveLatency records how long one event took from the ring buffer to its verdict
+func ObserveLatency(d time.Duration) { latency.observe(d) }
+
+// SetBacklog records the unread bytes left in the ring buffer
+func SetBacklog(n int) { backlog.Store(int64(n)) }
+
+// SetMonitorRunning marks the runtime monitor as up (with its ring buffer size) or down
+func SetMonitorRunning(on bool, ringBufferSize int) {
+	running.Store(on)
+	if on {
+		ringSize.Store(int64(ringBufferSize))
+		startedAt.Store(time.Now().Unix())
+	}
+}
+
+// monitorBackend is where the monitor gets its events from
+type monitorBackend struct {
+	name     string
+	degraded string
+}
+
+// SetMonitorBackend records the event source of the running monitor; degraded says
+// what it cannot see ("" for the full eBPF probes)
+func SetMonitorBackend(name, degraded string) {
+	backend.Store(&monitorBackend{name: name, degraded: degraded})
+}
+
+// SetKernelDrops stores the per-type drop totals read from the kernel. When they
+// grew since the last sample, detections in the surrounding window are flagged.
+func SetKernelDrops(totals map[string]uint64, now time.Time) {
+	kernelMu.Lock()
+	var grew uint64
+	for kind, n := range totals {
+		if n > kernel[kind] {
+			grew += n - kernel[kind]
+		}
+		kernel[kind] = n
+	}
+	if grew > 0 {
+		dropMarks = append(dropMarks, now)
+		if len(dropMarks) > maxDropMarks {
+			dropMarks = dropMarks[len(dropMarks)-maxDropMarks:]
+		}
+	}
+	kernelMu.Unlock()
+
+	if grew == 0 {
+		return
+	}
+	log.Printf("[WARN] Ring buffer full: %d events lost in the kernel (raise AEGIS_RINGBUF_SIZE)", grew)
+	// Detections written just before the drop was sampled are flagged after the fact
+	if platform.DB != nil {
+		since := now.Add(-DropWindow).Format(time.RFC3339Nano)
+		if _, err := platform.DB.Exec("UPDATE detections SET drops_in_window = 1 WHERE timestamp >= ?", since); err != nil {
+			log.Printf("[DB ERROR] Failed to flag detections near ring buffer drops: %v", err)
+		}
+	}
+}
+
+// DropsNear reports whether the kernel lost events within DropWindow of t,
+// i.e. whether the context of a detection at t may be incomplete
+func DropsNear(t time.Time) bool {
+	kernelMu.Lock()
+	defer kernelMu.Unlock()
+	for i := len(dropMarks) - 1; i >= 0; i-- {
+		d := t.Sub(dropMarks[i])
+		if d < 0 {
+			d = -d
+		}
+		if d <= DropWindow {
+			return true
+		}
+	}
+	return false
+}
+
+// Health is the pipeline summary served on /health
+type Health struct {
+	Status            string            `json:"status"` // ok, degraded (kernel drops in the last window, or a fallback backend) or monitor_down
+	MonitorRunning    bool              `json:"monitor_running"`
+	Backend           string            `json:"backend,omitempty"`  // ebpf or proc_connector
+	Degraded          string            `json:"degraded,omitempty"` // what the backend cannot see
+	UptimeSeconds     int64             `json:"uptime_seconds"`
+	RingBufferSize    int64             `json:"ringbuf_size_bytes"`
+	RingBufferBacklog int64             `json:"ringbuf_backlog_bytes"`
+	EventsReceived    map[string]uint64 `json:"events_received"`
+	EventsFiltered    map[string]uint64 `json:"events_filtered"`
+	ChecksSkipped     map[string]uint64 `json:"checks_skipped"`
+	KernelDrops       map[string]uint64 `json:"kernel_drops"`
+	LastDrop          *time.Time        `json:"last_drop,omitempty"`
+	DecodeErrors      uint64            `json:"decode_errors"`
+	ReadErrors        uint64            `json:"read_errors"`
+	AvgLatencyMicros  int64             `json:"avg_latency_us"`
+	MaxLatencyMicros  int64             `json:"max_latency_us"`
+}
+
+// Snapshot returns the current pipeline health
+func Snapshot() Health {
+	now := time.Now()
+	h := Health{
+		Status:            "ok",
+		MonitorRunning:    running.Load(),
+		RingBufferSize:    ringSize.Load(),
+		RingBufferBacklog: backlog.Load(),
+		EventsReceived:    received.snapshot(),
+		EventsFiltered:    filtered.snapshot(),
+		ChecksSkipped:     skipped.snapshot(),
+		DecodeErrors:      decodeErrs.Load(),
+		ReadErrors:        readErrs.Load(),
+	}
+	if h.MonitorRunning {
+		h.UptimeSeconds = now.Unix() - startedAt.Load()
+		if b := backend.Load(); b != nil {
+			h.Backend, h.Degraded = b.name, b.degraded
+		}
+	}
+
+	kernelMu.Lock()
+	h.KernelDrops = make(map[string]uint64, len(kernel))
+	for k, v := range kernel {
+		h.KernelDrops[k] = v
+	}
+	if n := len(dropMarks); n > 0 {
+		last := dropMarks[n-1]
+		h.LastDrop = &last
+	}
+	kernelMu.Unlock()
+
+	latency.mu.Lock()
+	if latency.total > 0 {
+		h.AvgLatencyMicros = (latency.sum / time.Duration(latency.total)).Microseconds()
+	}
+	h.MaxLatencyMicros = latency.max.Microseconds()
+	latency.mu.Unlock()
+
+	switch {
+	case !h.MonitorRunning:
+		h.Status = "monitor_down"
+	case h.Degraded != "", h.LastDrop != nil && now.Sub(*h.LastDrop) <= DropWindow:
+		h.Status = "degraded"
+	}
+	return h
+}
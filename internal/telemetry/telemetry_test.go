@@ -0,0 +1,144 @@
+package telemetry
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Debasish-87/aegis-v/internal/platform"
+)
+
+// resetDrops clears the kernel drop state shared by the tests
+func resetDrops(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		kernelMu.Lock()
+		kernel, dropMarks = map[string]uint64{}, nil
+		kernelMu.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestKernelDrops(t *testing.T) {
+	resetDrops(t)
+	t0 := time.Now()
+
+	SetKernelDrops(map[string]uint64{"exec": 0, "open": 0}, t0)
+	if DropsNear(t0) {
+		t.Fatal("drop window opened without drops")
+	}
+
+	SetKernelDrops(map[string]uint64{"exec": 3, "open": 0}, t0.Add(time.Minute))
+	for _, d := range []time.Duration{time.Minute, time.Minute - DropWindow, time.Minute + DropWindow} {
+		if !DropsNear(t0.Add(d)) {
+			t.Errorf("DropsNear(t0+%v) = false", d)
+		}
+	}
+	if DropsNear(t0) || DropsNear(t0.Add(2*time.Minute)) {
+		t.Error("detections outside the window flagged")
+	}
+
+	// Totals that did not grow leave no new mark
+	SetKernelDrops(map[string]uint64{"exec": 3, "open": 0}, t0.Add(5*time.Minute))
+	if DropsNear(t0.Add(5 * time.Minute)) {
+		t.Error("unchanged totals opened a drop window")
+	}
+	if got := Snapshot().KernelDrops; got["exec"] != 3 {
+		t.Errorf("KernelDrops = %v", got)
+	}
+}
+
+func TestKernelDropsFlagDetections(t *testing.T) {
+	resetDrops(t)
+	t.Chdir(t.TempDir())
+	db, err := platform.InitDB()
+	if err != nil {
+		t.Fatalf("init db: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		platform.DB = nil
+	})
+
+	now := time.Now().UTC()
+	for _, at := range []time.Time{now.Add(-10 * time.Second), now.Add(-10 * time.Minute)} {
+		if _, err := db.Exec("INSERT INTO detections (command, detection_type, timestamp) VALUES ('nc', 'EXEC', ?)", at.Format(time.RFC3339Nano)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	SetKernelDrops(map[string]uint64{"exec": 1}, now)
+
+	var flagged int
+	db.QueryRow("SELECT COUNT(*) FROM detections WHERE drops_in_window = 1").Scan(&flagged)
+	if flagged != 1 {
+		t.Errorf("%d detections flagged, want the one within %v", flagged, DropWindow)
+	}
+}
+
+func TestSnapshotStatus(t *testing.T) {
+	resetDrops(t)
+	t.Cleanup(func() {
+		SetMonitorRunning(false, 0)
+		backend.Store(nil)
+	})
+
+	SetMonitorRunning(false, 0)
+	if s := Snapshot().Status; s != "monitor_down" {
+		t.Errorf("stopped monitor: status %s", s)
+	}
+	SetMonitorRunning(true, 1<<20)
+	SetMonitorBackend("ebpf", "")
+	if h := Snapshot(); h.Status != "ok" || h.RingBufferSize != 1<<20 || h.Backend != "ebpf" {
+		t.Errorf("running monitor: %+v", h)
+	}
+	SetKernelDrops(map[string]uint64{"exec": 1}, time.Now())
+	if s := Snapshot().Status; s != "degraded" {
+		t.Errorf("recent kernel drops: status %s", s)
+	}
+	resetDrops(t)
+	SetMonitorBackend("proc_connector", "no file, network or credential events")
+	if s := Snapshot().Status; s != "degraded" {
+		t.Errorf("fallback backend: status %s", s)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	var h histogram
+	for _, d := range []time.Duration{50 * time.Microsecond, time.Millisecond, 2 * time.Second} {
+		h.observe(d)
+	}
+	// Each bucket holds the observations up to its bound; the last one is +Inf
+	want := []uint64{1, 1, 0, 0, 0, 1}
+	for i, n := range want {
+		if h.counts[i] != n {
+			t.Errorf("bucket %d = %d, want %d", i, h.counts[i], n)
+		}
+	}
+	if h.total != 3 || h.max != 2*time.Second {
+		t.Errorf("total %d, max %v", h.total, h.max)
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	resetDrops(t)
+	CountEvent("exec")
+	CountFiltered("self")
+	SetKernelDrops(map[string]uint64{"open": 2}, time.Now())
+
+	var buf bytes.Buffer
+	WritePrometheus(&buf)
+	out := buf.String()
+	for _, want := range []string{
+		"# TYPE aegis_events_received_total counter",
+		`aegis_events_filtered_total{reason="self"}`,
+		`aegis_ringbuf_drops_total{event="open"} 2`,
+		`aegis_event_processing_seconds_bucket{le="+Inf"}`,
+		"# TYPE aegis_monitor_up gauge",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("exposition lacks %q", want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package trust
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed default.yaml
+var defaultList []byte
+
+// Component names given to the AEGIS-V binaries, identified by hash alone
+const (
+	ComponentEngine = "aegis-engine"
+	ComponentCtl    = "aegis-ctl"
+	ComponentViz    = "aegis-viz"
+)
+
+// Entry is one known binary
+type Entry struct {
+	Name      string `yaml:"name"`
+	Path      string `yaml:"path,omitempty"`      // empty: matched by hash alone
+	SHA256    string `yaml:"sha256,omitempty"`    // empty: pinned from path when the list loads
+	Protected bool   `yaml:"protected,omitempty"` // never killed by the defender
+}
+
+type listFile struct {
+	Binaries []Entry `yaml:"binaries"`
+}
+
+// List is one loaded allowlist
+type List struct {
+	Entries []*Entry
+	Skipped int // entries whose path does not exist on this host
+	byHash  map[string][]*Entry
+}
+
+// Load builds the allowlist: the AEGIS components (this executable and its siblings),
+// the built-in system binaries, then the entries of file ("" or missing: none).
+// Entries without a hash are pinned to the binary at their path right now.
+func Load(file string) (*List, error) {
+	l := &List{byHash: map[string][]*Entry{}}
+	l.addComponents()
+
+	entries, err := parse(defaultList, "<built-in>")
+	if err != nil {
+		return nil, err
+	}
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		extra, err := parse(data, file)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, extra...)
+	}
+
+	for i := range entries {
+		e := &entries[i]
+		if e.SHA256 == "" {
+			id, err := OfFile(e.Path)
+			if err != nil {
+				l.Skipped++
+				continue
+			}
+			e.SHA256 = id.SHA256
+		}
+		l.add(e)
+	}
+	return l, nil
+}
+
+func parse(data []byte, file string) ([]Entry, error) {
+	var lf listFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&lf); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	for i, e := range lf.Binaries {
+		switch {
+		case e.Name == "":
+			return nil, fmt.Errorf("%s: binaries[%d]: name is required", file, i)
+		case e.Path == "" && e.SHA256 == "":
+			return nil, fmt.Errorf("%s: %s: path or sha256 is required", file, e.Name)
+		case e.Path != "" && !filepath.IsAbs(e.Path):
+			return nil, fmt.Errorf("%s: %s: path must be absolute", file, e.Name)
+		case e.SHA256 != "" && !isSHA256(e.SHA256):
+			return nil, fmt.Errorf("%s: %s: sha256 must be 64 hex characters", file, e.Name)
+		}
+	}
+	return lf.Binaries, nil
+}
+
+func isSHA256(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// addComponents trusts this executable and the AEGIS-V binaries next to it by hash
+func (l *List) addComponents() {
+	self, err := os.Executable()
+	if err != nil {
+		return
+	}
+	if id, err := OfFile(self); err == nil {
+		l.add(&Entry{Name: ComponentEngine, SHA256: id.SHA256, Protected: true})
+	}
+	for _, name := range []string{ComponentCtl, ComponentViz} {
+		if id, err := OfFile(filepath.Join(filepath.Dir(self), name)); err == nil {
+			l.add(&Entry{Name: name, SHA256: id.SHA256, Protected: true})
+		}
+	}
+}
+
+func (l *List) add(e *Entry) {
+	l.Entries = append(l.Entries, e)
+	l.byHash[e.SHA256] = append(l.byHash[e.SHA256], e)
+}
+
+// Match returns the entry an executable is, or nil. Both the hash and (for entries
+// with a path) the resolved path must match.
+func (l *List) Match(id Identity) *Entry {
+	if id.SHA256 == "" {
+		return nil
+	}
+	for _, e := range l.byHash[id.SHA256] {
+		if e.Path == "" || e.Path == id.Path {
+			return e
+		}
+	}
+	return nil
+}
+
+// MatchContent returns an entry with this hash wherever the file lives, or nil.
+// Only for images with no path left to check: memfd and deleted copies (runc
+// re-executes itself from a memfd clone, for one).
+func (l *List) MatchContent(sum string) *Entry {
+	if sum == "" || len(l.byHash[sum]) == 0 {
+		return nil
+	}
+	return l.byHash[sum][0]
+}
+
+var (
+	current  atomic.Pointer[List]
+	loadOnce sync.Once
+)
+
+// Current is the active allowlist (the built-in one until Init succeeds)
+func Current() *List {
+	if l := current.Load(); l != nil {
+		return l
+	}
+	loadOnce.Do(func() {
+		l, err := Load("")
+		if err != nil {
+			log.Fatalf("[CRITICAL] Built-in trust list is invalid: %v", err)
+		}
+		current.CompareAndSwap(nil, l)
+	})
+	return current.Load()
+}
+
+// Init loads the allowlist with the entries of file; on error the built-in list stays
+func Init(file string) error {
+	l, err := Load(file)
+	if err != nil {
+		Current()
+		return err
+	}
+	current.Store(l)
+	return nil
+}
+
+// ProcessEntry returns the allowlist entry of a running process's executable, or nil
+func ProcessEntry(pid uint32) *Entry {
+	id, err := Of(pid)
+	if err != nil {
+		return nil
+	}
+	return Current().Match(id)
+}
@@ -0,0 +1,94 @@
+// Package trust decides which executables are known and trusted by what they are —
+// resolved path, device/inode and SHA-256 — instead of by the comm name a process
+// picked for itself. Comm names are for display only.
+package trust
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// Identity is what an executable is, independent of the name its process shows
+type Identity struct {
+	Path   string // resolved path, in the mount namespace of the process
+	Dev    uint64
+	Ino    uint64
+	SHA256 string
+}
+
+// fileKey identifies one version of a file; a rewrite changes size or mtime
+type fileKey struct {
+	dev, ino, size uint64
+	mtime          int64
+}
+
+// maxHashCache bounds the hash cache; it is simply reset when full
+const maxHashCache = 4096
+
+var (
+	hashMu sync.Mutex
+	hashes = map[fileKey]string{}
+)
+
+// Of resolves the executable of a running process (/proc/<pid>/exe). A deleted
+// executable has no identity: it can no longer be checked against anything.
+func Of(pid uint32) (Identity, error) {
+	exe := fmt.Sprintf("/proc/%d/exe", pid)
+	target, err := os.Readlink(exe)
+	if err != nil {
+		return Identity{}, err
+	}
+	if strings.HasSuffix(target, " (deleted)") {
+		return Identity{Path: target}, fmt.Errorf("executable of %d was deleted", pid)
+	}
+	return identify(exe, target)
+}
+
+// OfFile resolves the executable at path
+func OfFile(path string) (Identity, error) {
+	return identify(path, path)
+}
+
+// identify stats and hashes file (opened through open, reported as path)
+func identify(open, path string) (Identity, error) {
+	f, err := os.Open(open)
+	if err != nil {
+		return Identity{}, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return Identity{}, err
+	}
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok || !fi.Mode().IsRegular() {
+		return Identity{}, fmt.Errorf("%s is not a regular file", path)
+	}
+	id := Identity{Path: path, Dev: uint64(st.Dev), Ino: st.Ino}
+	key := fileKey{dev: id.Dev, ino: id.Ino, size: uint64(fi.Size()), mtime: fi.ModTime().UnixNano()}
+
+	hashMu.Lock()
+	sum, ok := hashes[key]
+	hashMu.Unlock()
+	if !ok {
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return id, err
+		}
+		sum = hex.EncodeToString(h.Sum(nil))
+		hashMu.Lock()
+		if len(hashes) >= maxHashCache {
+			hashes = map[fileKey]string{}
+		}
+		hashes[key] = sum
+		hashMu.Unlock()
+	}
+	id.SHA256 = sum
+	return id, nil
+}
@@ -0,0 +1,146 @@
+package trust
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeBinary creates a file standing in for an executable and returns its hash
+func writeBinary(t *testing.T, path, content string) string {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestOfFile(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "tool")
+	sum := writeBinary(t, bin, "v1")
+
+	id, err := OfFile(bin)
+	if err != nil || id.SHA256 != sum || id.Path != bin || id.Ino == 0 {
+		t.Fatalf("OfFile = %+v, %v", id, err)
+	}
+	// A rewrite in place is hashed again, not served from the cache
+	sum2 := writeBinary(t, bin, "v2-longer")
+	os.Chtimes(bin, time.Now(), time.Now().Add(time.Minute))
+	if id, _ := OfFile(bin); id.SHA256 != sum2 {
+		t.Errorf("after rewrite: sha256 %s, want %s", id.SHA256, sum2)
+	}
+
+	if _, err := OfFile(dir); err == nil {
+		t.Error("directory identified")
+	}
+	if _, err := OfFile(filepath.Join(dir, "missing")); err == nil {
+		t.Error("missing file identified")
+	}
+
+	self, _ := os.Executable()
+	want, _ := OfFile(self)
+	if id, err := Of(uint32(os.Getpid())); err != nil || id.SHA256 != want.SHA256 {
+		t.Errorf("Of(self) = %+v, %v", id, err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+	if _, err := parse([]byte("binaries:\n  - {name: tool, path: /opt/tool}\n  - {name: pinned, sha256: "+sum+"}\n"), "t.yaml"); err != nil {
+		t.Errorf("valid list: %v", err)
+	}
+	if e, err := parse(nil, "empty.yaml"); err != nil || len(e) != 0 {
+		t.Errorf("empty list = %v, %v", e, err)
+	}
+
+	bad := map[string]string{
+		"no name":        "binaries:\n  - {path: /opt/tool}\n",
+		"no path or sum": "binaries:\n  - {name: tool}\n",
+		"relative path":  "binaries:\n  - {name: tool, path: bin/tool}\n",
+		"short sha256":   "binaries:\n  - {name: tool, sha256: abc}\n",
+		"upper sha256":   "binaries:\n  - {name: tool, sha256: " + strings.ToUpper(sum) + "}\n",
+		"unknown field":  "binaries:\n  - {name: tool, path: /opt/tool, trusted: true}\n",
+	}
+	for name, data := range bad {
+		if _, err := parse([]byte(data), "t.yaml"); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+}
+
+func TestLoadAndMatch(t *testing.T) {
+	dir := t.TempDir()
+	tool := filepath.Join(dir, "tool")
+	toolSum := writeBinary(t, tool, "tool build")
+	copySum := writeBinary(t, filepath.Join(dir, "renamed"), "tool build")
+	pinned := strings.Repeat("0f", 32)
+
+	file := filepath.Join(dir, "trusted.yaml")
+	os.WriteFile(file, []byte("binaries:\n"+
+		"  - {name: tool, path: "+tool+", protected: true}\n"+
+		"  - {name: gone, path: "+filepath.Join(dir, "missing")+"}\n"+
+		"  - {name: anywhere, sha256: "+pinned+"}\n"), 0600)
+
+	l, err := Load(file)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if l.Skipped == 0 {
+		t.Error("entry with a missing path not skipped")
+	}
+
+	// Path entries need both the hash and the path
+	if e := l.Match(Identity{Path: tool, SHA256: toolSum}); e == nil || e.Name != "tool" || !e.Protected {
+		t.Errorf("Match(tool) = %+v", e)
+	}
+	if e := l.Match(Identity{Path: filepath.Join(dir, "renamed"), SHA256: copySum}); e != nil {
+		t.Errorf("copy under another path matched %s", e.Name)
+	}
+	if e := l.Match(Identity{Path: tool, SHA256: pinned[:62] + "00"}); e != nil {
+		t.Errorf("modified binary matched %s", e.Name)
+	}
+	// Hash-only entries match wherever the file lives
+	if e := l.Match(Identity{Path: "/tmp/x", SHA256: pinned}); e == nil || e.Name != "anywhere" {
+		t.Errorf("Match(pinned) = %+v", e)
+	}
+	if l.Match(Identity{Path: tool}) != nil {
+		t.Error("identity without a hash matched")
+	}
+	if e := l.MatchContent(copySum); e == nil || e.Name != "tool" {
+		t.Errorf("MatchContent = %+v", e)
+	}
+	if l.MatchContent("") != nil {
+		t.Error("MatchContent of an empty hash")
+	}
+
+	// The engine itself is trusted by hash and protected
+	self, _ := os.Executable()
+	id, _ := OfFile(self)
+	if e := l.Match(id); e == nil || e.Name != ComponentEngine || !e.Protected {
+		t.Errorf("Match(self) = %+v", e)
+	}
+
+	if _, err := Load(filepath.Join(dir, "absent.yaml")); err != nil {
+		t.Errorf("missing trust file: %v", err)
+	}
+}
+
+func TestInitKeepsListOnError(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.yaml")
+	os.WriteFile(bad, []byte("binaries:\n  - {name: tool}\n"), 0600)
+
+	before := Current()
+	if err := Init(bad); err == nil {
+		t.Fatal("Init with an invalid file: want an error")
+	}
+	if Current() != before {
+		t.Error("invalid file replaced the active list")
+	}
+}